@@ -18,6 +18,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
 	"github.com/samber/do"
 	"github.com/xdefrag/william/internal/bot"
 	"github.com/xdefrag/william/internal/config"
@@ -25,7 +26,9 @@ import (
 	"github.com/xdefrag/william/internal/gpt"
 	"github.com/xdefrag/william/internal/migrations"
 	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/internal/runtimestats"
 	"github.com/xdefrag/william/internal/scheduler"
+	"github.com/xdefrag/william/internal/secrets"
 )
 
 func main() {
@@ -75,6 +78,9 @@ func main() {
 		log.Fatalf("Failed to create event router: %v", err)
 	}
 
+	// Retry failing handlers, then move their events to the failed topic
+	eventRouter.AddMiddleware(bot.DeadLetter(publisher, cfg.App.Events.MaxAttempts, time.Duration(cfg.App.Events.RetryDelayMs)*time.Millisecond))
+
 	// Subscribe to events
 	setupEventSubscribers(eventRouter, subscriber, publisher, handlers, logger)
 
@@ -90,6 +96,15 @@ func main() {
 		}
 	}()
 
+	// Catch up on chats that collected messages while the bot was down
+	go func() {
+		select {
+		case <-eventRouter.Running():
+			listener.SummarizeBacklog(ctx)
+		case <-ctx.Done():
+		}
+	}()
+
 	// Start bot listener
 	wg.Add(1)
 	go func() {
@@ -143,7 +158,7 @@ func main() {
 	select {
 	case <-done:
 		logger.Info("Graceful shutdown completed", nil)
-	case <-time.After(30 * time.Second):
+	case <-time.After(time.Duration(cfg.App.App.ShutdownTimeoutSeconds) * time.Second):
 		logger.Error("Shutdown timeout exceeded", nil, nil)
 	}
 
@@ -213,10 +228,24 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 		return pool, nil
 	})
 
+	// Register settings cipher (nil if no encryption key is configured)
+	do.Provide(injector, func(i *do.Injector) (*secrets.Cipher, error) {
+		config := do.MustInvoke[*config.Config](i)
+		logger := do.MustInvoke[watermill.LoggerAdapter](i)
+
+		if config.SettingsEncryptionKey == "" {
+			logger.Info("SETTINGS_ENCRYPTION_KEY not set, encrypted chat settings are unavailable", nil)
+			return nil, nil
+		}
+
+		return secrets.NewCipher(config.SettingsEncryptionKey)
+	})
+
 	// Register repository
 	do.Provide(injector, func(i *do.Injector) (*repo.Repository, error) {
 		pool := do.MustInvoke[*pgxpool.Pool](i)
-		return repo.New(pool), nil
+		cipher := do.MustInvoke[*secrets.Cipher](i)
+		return repo.New(pool, cipher), nil
 	})
 
 	// Register pub/sub - register both publisher and subscriber
@@ -237,17 +266,27 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 		return pubSub, nil
 	})
 
-	// Register GPT client
-	do.Provide(injector, func(i *do.Injector) (*gpt.Client, error) {
+	// Register in-memory runtime counters
+	do.Provide(injector, func(i *do.Injector) (*runtimestats.Stats, error) {
+		return runtimestats.New(), nil
+	})
+
+	// Register LLM backend selected by [llm] provider
+	do.Provide(injector, func(i *do.Injector) (gpt.Completer, error) {
 		config := do.MustInvoke[*config.Config](i)
+		stats := do.MustInvoke[*runtimestats.Stats](i)
 		logger := do.MustInvoke[*slog.Logger](i)
-		return gpt.New(config.OpenAIAPIKey, config, logger), nil
+
+		if config.App.LLM.Provider == gpt.ProviderAnthropic {
+			return gpt.NewAnthropic(config.AnthropicAPIKey, config, logger), nil
+		}
+		return gpt.New(config.OpenAIAPIKey, config, stats, logger), nil
 	})
 
 	// Register context builder
 	do.Provide(injector, func(i *do.Injector) (*williamcontext.Builder, error) {
 		repository := do.MustInvoke[*repo.Repository](i)
-		gptClient := do.MustInvoke[*gpt.Client](i)
+		gptClient := do.MustInvoke[gpt.Completer](i)
 		config := do.MustInvoke[*config.Config](i)
 		return williamcontext.New(repository, gptClient, config), nil
 	})
@@ -255,7 +294,7 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 	// Register context summarizer
 	do.Provide(injector, func(i *do.Injector) (*williamcontext.Summarizer, error) {
 		repository := do.MustInvoke[*repo.Repository](i)
-		gptClient := do.MustInvoke[*gpt.Client](i)
+		gptClient := do.MustInvoke[gpt.Completer](i)
 		config := do.MustInvoke[*config.Config](i)
 		logger := do.MustInvoke[*slog.Logger](i)
 		return williamcontext.NewSummarizer(repository, gptClient, config, logger), nil
@@ -266,7 +305,8 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 		config := do.MustInvoke[*config.Config](i)
 		logger := do.MustInvoke[watermill.LoggerAdapter](i)
 
-		tgBot, err := telego.NewBot(config.TelegramBotToken)
+		caller := bot.NewLimitedCaller(telegoapi.DefaultFastHTTPCaller, config.App.Telegram.MaxConcurrentCalls)
+		tgBot, err := telego.NewBot(config.TelegramBotToken, telego.WithAPICaller(caller))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create bot: %w", err)
 		}
@@ -285,15 +325,32 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 		return tgBot, nil
 	})
 
+	// Register outbound message sender
+	do.Provide(injector, func(i *do.Injector) (*bot.Sender, error) {
+		tgBot := do.MustInvoke[*telego.Bot](i)
+		config := do.MustInvoke[*config.Config](i)
+
+		interval := time.Duration(config.App.Telegram.SendIntervalMs) * time.Millisecond
+		return bot.NewSender(tgBot, interval), nil
+	})
+
+	// Register voice note transcriber (no speech-to-text backend is integrated yet)
+	do.Provide(injector, func(i *do.Injector) (bot.Transcriber, error) {
+		return bot.NoopTranscriber{}, nil
+	})
+
 	// Register bot listener
 	do.Provide(injector, func(i *do.Injector) (*bot.Listener, error) {
 		tgBot := do.MustInvoke[*telego.Bot](i)
 		repository := do.MustInvoke[*repo.Repository](i)
 		config := do.MustInvoke[*config.Config](i)
 		publisher := do.MustInvoke[message.Publisher](i)
+		sender := do.MustInvoke[*bot.Sender](i)
+		transcriber := do.MustInvoke[bot.Transcriber](i)
+		stats := do.MustInvoke[*runtimestats.Stats](i)
 		logger := do.MustInvoke[*slog.Logger](i)
 
-		return bot.New(tgBot, repository, config, publisher, logger), nil
+		return bot.New(tgBot, repository, config, publisher, sender, transcriber, stats, logger), nil
 	})
 
 	// Register bot handlers
@@ -302,21 +359,24 @@ func setupDependencies(injector *do.Injector, cfg *config.Config, logger watermi
 		repository := do.MustInvoke[*repo.Repository](i)
 		builder := do.MustInvoke[*williamcontext.Builder](i)
 		summarizer := do.MustInvoke[*williamcontext.Summarizer](i)
-		gptClient := do.MustInvoke[*gpt.Client](i)
+		gptClient := do.MustInvoke[gpt.Completer](i)
+		publisher := do.MustInvoke[message.Publisher](i)
+		sender := do.MustInvoke[*bot.Sender](i)
 		config := do.MustInvoke[*config.Config](i)
 		logger := do.MustInvoke[*slog.Logger](i)
 
-		return bot.NewHandlers(tgBot, repository, builder, summarizer, gptClient, config, logger), nil
+		return bot.NewHandlers(tgBot, repository, builder, summarizer, gptClient, sender, publisher, config, logger), nil
 	})
 
 	// Register scheduler
 	do.Provide(injector, func(i *do.Injector) (*scheduler.Scheduler, error) {
 		publisher := do.MustInvoke[message.Publisher](i)
 		listener := do.MustInvoke[*bot.Listener](i)
+		repository := do.MustInvoke[*repo.Repository](i)
 		config := do.MustInvoke[*config.Config](i)
 		logger := do.MustInvoke[*slog.Logger](i)
 
-		return scheduler.New(publisher, listener, config, logger), nil
+		return scheduler.New(publisher, listener, repository, config, logger), nil
 	})
 
 	return nil
@@ -376,7 +436,46 @@ func setupEventSubscribers(router *message.Router, subscriber message.Subscriber
 		},
 	)
 
+	// Subscribe to refresh profile events
+	router.AddHandler(
+		"refresh_profile_handler",
+		"refresh_profile",
+		subscriber,
+		"refresh_profile",
+		publisher,
+		func(msg *message.Message) ([]*message.Message, error) {
+			err := handlers.HandleRefreshProfileEvent(msg)
+			return nil, err
+		},
+	)
+
+	// Subscribe to weekly digest events
+	router.AddHandler(
+		"digest_handler",
+		"digest",
+		subscriber,
+		"digest",
+		publisher,
+		func(msg *message.Message) ([]*message.Message, error) {
+			err := handlers.HandleDigestEvent(msg)
+			return nil, err
+		},
+	)
+
+	// Record events that failed all handler attempts
+	router.AddHandler(
+		"failed_handler",
+		bot.FailedTopic,
+		subscriber,
+		bot.FailedTopic,
+		publisher,
+		func(msg *message.Message) ([]*message.Message, error) {
+			err := handlers.HandleFailedEvent(msg)
+			return nil, err
+		},
+	)
+
 	logger.Info("Event subscribers configured", watermill.LogFields{
-		"handlers": []string{"summarize", "mention", "midnight", "welcome"},
+		"handlers": []string{"summarize", "mention", "midnight", "welcome", "refresh_profile", "digest", bot.FailedTopic},
 	})
 }
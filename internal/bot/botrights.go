@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/mymmrac/telego"
+)
+
+// botRights are the bot's admin rights in a chat
+type botRights struct {
+	IsAdmin           bool
+	CanPinMessages    bool
+	CanDeleteMessages bool
+}
+
+// botRightsFromMember derives the bot's rights from its chat member status
+func botRightsFromMember(member telego.ChatMember) botRights {
+	switch m := member.(type) {
+	case *telego.ChatMemberOwner:
+		return botRights{IsAdmin: true, CanPinMessages: true, CanDeleteMessages: true}
+	case *telego.ChatMemberAdministrator:
+		return botRights{IsAdmin: true, CanPinMessages: m.CanPinMessages, CanDeleteMessages: m.CanDeleteMessages}
+	default:
+		return botRights{}
+	}
+}
+
+// botRightsTracker remembers the bot's rights per chat as reported by my_chat_member updates
+// or looked up on first use.
+type botRightsTracker struct {
+	mu     sync.Mutex
+	rights map[int64]botRights
+}
+
+func newBotRightsTracker() *botRightsTracker {
+	return &botRightsTracker{rights: make(map[int64]botRights)}
+}
+
+// Set records the bot's rights in a chat
+func (t *botRightsTracker) Set(chatID int64, rights botRights) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rights[chatID] = rights
+}
+
+// Get returns the bot's rights in a chat and whether they are known
+func (t *botRightsTracker) Get(chatID int64) (botRights, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rights, ok := t.rights[chatID]
+	return rights, ok
+}
+
+// handleMyChatMember records the bot's rights after it was promoted, demoted, added or removed
+func (l *Listener) handleMyChatMember(ctx context.Context, update *telego.ChatMemberUpdated) {
+	rights := botRightsFromMember(update.NewChatMember)
+	l.botRights.Set(update.Chat.ID, rights)
+
+	l.logger.InfoContext(ctx, "Bot member status changed",
+		slog.Int64("chat_id", update.Chat.ID),
+		slog.String("status", update.NewChatMember.MemberStatus()),
+		slog.Bool("can_pin_messages", rights.CanPinMessages),
+		slog.Bool("can_delete_messages", rights.CanDeleteMessages),
+	)
+}
+
+// botCan reports whether the bot may use an admin right in a chat. Rights not yet known for the chat
+// are looked up via getChatMember. With rights checks off or the lookup failing the bot tries anyway
+// and Telegram has the final word.
+func (l *Listener) botCan(ctx context.Context, chatID int64, allowed func(botRights) bool) bool {
+	if !l.config.App.App.CheckBotRights {
+		return true
+	}
+	rights, ok := l.botRights.Get(chatID)
+	if !ok {
+		rights, ok = l.fetchBotRights(ctx, chatID)
+	}
+	return !ok || allowed(rights)
+}
+
+// fetchBotRights looks up the bot's rights in a chat and remembers them
+func (l *Listener) fetchBotRights(ctx context.Context, chatID int64) (botRights, bool) {
+	member, err := l.bot.GetChatMember(ctx, &telego.GetChatMemberParams{
+		ChatID: telego.ChatID{ID: chatID},
+		UserID: l.botID(),
+	})
+	if err != nil {
+		l.logger.WarnContext(ctx, "Failed to get bot chat member", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return botRights{}, false
+	}
+
+	rights := botRightsFromMember(member)
+	l.botRights.Set(chatID, rights)
+	return rights, true
+}
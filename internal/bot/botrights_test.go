@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestBotRightsFromMember(t *testing.T) {
+	tests := []struct {
+		name   string
+		member telego.ChatMember
+		want   botRights
+	}{
+		{"owner", &telego.ChatMemberOwner{}, botRights{IsAdmin: true, CanPinMessages: true, CanDeleteMessages: true}},
+		{"admin without pin", &telego.ChatMemberAdministrator{CanDeleteMessages: true}, botRights{IsAdmin: true, CanDeleteMessages: true}},
+		{"demoted", &telego.ChatMemberMember{}, botRights{}},
+		{"removed", &telego.ChatMemberLeft{}, botRights{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := botRightsFromMember(tt.member); got != tt.want {
+				t.Errorf("botRightsFromMember() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// getMeResponse answers the bot's getMe call
+var getMeResponse = &ta.Response{Ok: true, Result: []byte(`{"id":123456,"is_bot":true,"first_name":"William","username":"william_bot"}`)}
+
+func TestPinSummaryDeclinesWithoutBotRights(t *testing.T) {
+	fake := &fakeCaller{responses: []*ta.Response{
+		{Ok: true},
+		getMeResponse,
+		{Ok: false, Error: &ta.Error{ErrorCode: 400, Description: "Bad Request: chat not found"}},
+	}}
+	tg, err := telego.NewBot("123456:"+strings.Repeat("a", 35), telego.WithAPICaller(fake), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.App.AdminUserID = 1
+	cfg.App.App.CheckBotRights = true
+	l := &Listener{
+		bot:       tg,
+		config:    cfg,
+		sender:    NewSender(tg, 0),
+		botRights: newBotRightsTracker(),
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	l.handleMyChatMember(context.Background(), &telego.ChatMemberUpdated{
+		Chat:          telego.Chat{ID: -100},
+		NewChatMember: &telego.ChatMemberMember{},
+	})
+
+	msg := &telego.Message{Chat: telego.Chat{ID: -100}, From: &telego.User{ID: 1}}
+	l.handlePinSummaryCommand(context.Background(), msg, LanguageEnglish)
+
+	want := translate(LanguageEnglish, "error.bot_cannot_pin")
+	if len(fake.bodies) != 1 || !strings.Contains(fake.bodies[0], want) {
+		t.Errorf("Expected a single decline message %q, got %v", want, fake.bodies)
+	}
+
+	if !l.botCan(context.Background(), -200, func(r botRights) bool { return r.CanPinMessages }) {
+		t.Error("Expected chats with failed rights lookup to be tried")
+	}
+	cfg.App.App.CheckBotRights = false
+	if !l.botCan(context.Background(), -100, func(r botRights) bool { return r.CanPinMessages }) {
+		t.Error("Expected rights checks to be off when disabled")
+	}
+}
+
+func TestBotCanLooksUpUnknownRights(t *testing.T) {
+	fake := &fakeCaller{responses: []*ta.Response{
+		getMeResponse,
+		{Ok: true, Result: []byte(`{"status":"administrator","user":{"id":123456,"is_bot":true,"first_name":"William"},"can_delete_messages":true}`)},
+	}}
+	tg, err := telego.NewBot("123456:"+strings.Repeat("a", 35), telego.WithAPICaller(fake), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.App.CheckBotRights = true
+	l := &Listener{
+		bot:       tg,
+		config:    cfg,
+		botRights: newBotRightsTracker(),
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	if l.botCan(ctx, -100, func(r botRights) bool { return r.CanPinMessages }) {
+		t.Error("Expected looked up rights without pin to decline")
+	}
+	if !l.botCan(ctx, -100, func(r botRights) bool { return r.CanDeleteMessages }) {
+		t.Error("Expected looked up rights with delete to allow")
+	}
+	if fake.calls != 2 {
+		t.Errorf("Expected getMe and a single rights lookup, got %d calls", fake.calls)
+	}
+	if len(fake.bodies) < 2 || !strings.Contains(fake.bodies[1], `"user_id":123456`) {
+		t.Errorf("Expected lookup of the bot member, got %v", fake.bodies)
+	}
+}
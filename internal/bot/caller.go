@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+const (
+	// rateLimitAttempts is the number of attempts made for a call hitting flood control
+	rateLimitAttempts = 3
+	// getUpdatesMethod is the long polling method, which is not limited since it blocks for the poll timeout
+	getUpdatesMethod = "/getUpdates"
+)
+
+// LimitedCaller wraps the Telegram API caller shared by the listener, handlers and commands.
+// It caps the number of concurrent calls and waits out flood control (429 retry_after)
+// before repeating the call.
+type LimitedCaller struct {
+	caller ta.Caller
+	slots  chan struct{}
+}
+
+// NewLimitedCaller creates a caller allowing at most maxConcurrent calls in flight (<= 0 = unlimited)
+func NewLimitedCaller(caller ta.Caller, maxConcurrent int) *LimitedCaller {
+	c := &LimitedCaller{caller: caller}
+	if maxConcurrent > 0 {
+		c.slots = make(chan struct{}, maxConcurrent)
+	}
+	return c
+}
+
+// Call performs the API call within the concurrency limit, retrying on flood control
+func (c *LimitedCaller) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	if strings.HasSuffix(url, getUpdatesMethod) {
+		return c.caller.Call(ctx, url, data)
+	}
+
+	// Keep the body so the request can be repeated after a readable buffer was consumed
+	var body []byte
+	if data != nil && data.Buffer != nil {
+		body = bytes.Clone(data.Buffer.Bytes())
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.call(ctx, url, data)
+		if err != nil || attempt == rateLimitAttempts {
+			return resp, err
+		}
+
+		retryAfter, limited := floodWait(resp)
+		if !limited {
+			return resp, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryAfter):
+		}
+
+		if body != nil {
+			data.Buffer = bytes.NewBuffer(bytes.Clone(body))
+		}
+	}
+}
+
+// call performs a single API call holding a concurrency slot
+func (c *LimitedCaller) call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-c.slots }()
+	}
+
+	return c.caller.Call(ctx, url, data)
+}
+
+// floodWait reports whether the response is a flood control error and how long to wait
+func floodWait(resp *ta.Response) (time.Duration, bool) {
+	if resp == nil || resp.Ok || resp.Error == nil || resp.ErrorCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	var retryAfter time.Duration
+	if resp.Parameters != nil {
+		retryAfter = time.Duration(resp.Parameters.RetryAfter) * time.Second
+	}
+	return retryAfter, true
+}
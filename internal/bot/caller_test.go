@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+// fakeCaller records concurrency and replays the queued responses
+type fakeCaller struct {
+	delay time.Duration
+
+	mu        sync.Mutex
+	inFlight  int
+	maxFlight int
+	calls     int
+	bodies    []string
+	responses []*ta.Response
+}
+
+func (f *fakeCaller) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	f.mu.Lock()
+	f.calls++
+	f.inFlight++
+	f.maxFlight = max(f.maxFlight, f.inFlight)
+	f.bodies = append(f.bodies, data.Buffer.String())
+	// Consume the buffer like a streaming HTTP client would
+	data.Buffer.Reset()
+	resp := &ta.Response{Ok: true}
+	if len(f.responses) > 0 {
+		resp = f.responses[0]
+		f.responses = f.responses[1:]
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	return resp, nil
+}
+
+func newRequestData(body string) *ta.RequestData {
+	return &ta.RequestData{ContentType: ta.ContentTypeJSON, Buffer: bytes.NewBufferString(body)}
+}
+
+func TestLimitedCallerSerializesCallsBeyondCap(t *testing.T) {
+	fake := &fakeCaller{delay: 10 * time.Millisecond}
+	caller := NewLimitedCaller(fake, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := caller.Call(context.Background(), "https://api.telegram.org/botX/sendMessage", newRequestData("{}")); err != nil {
+				t.Errorf("Unexpected call error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fake.calls != 5 {
+		t.Fatalf("Expected 5 calls, got %d", fake.calls)
+	}
+	if fake.maxFlight != 1 {
+		t.Fatalf("Expected calls to be serialized, got %d in flight", fake.maxFlight)
+	}
+}
+
+func TestLimitedCallerSkipsLongPolling(t *testing.T) {
+	fake := &fakeCaller{delay: 20 * time.Millisecond}
+	caller := NewLimitedCaller(fake, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = caller.Call(context.Background(), "https://api.telegram.org/botX/getUpdates", newRequestData("{}"))
+		}()
+	}
+	wg.Wait()
+
+	if fake.maxFlight != 2 {
+		t.Fatalf("Expected long polling to bypass the limit, got %d in flight", fake.maxFlight)
+	}
+}
+
+func TestLimitedCallerRetriesAfterFloodWait(t *testing.T) {
+	fake := &fakeCaller{responses: []*ta.Response{{
+		Ok: false,
+		Error: &ta.Error{
+			ErrorCode:   http.StatusTooManyRequests,
+			Description: "Too Many Requests: retry after 0",
+			Parameters:  &ta.ResponseParameters{RetryAfter: 0},
+		},
+	}}}
+	caller := NewLimitedCaller(fake, 1)
+
+	resp, err := caller.Call(context.Background(), "https://api.telegram.org/botX/sendMessage", newRequestData(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatalf("Unexpected call error: %v", err)
+	}
+	if !resp.Ok {
+		t.Fatalf("Expected the retried call to succeed, got %v", resp)
+	}
+	if fake.calls != 2 {
+		t.Fatalf("Expected 2 calls, got %d", fake.calls)
+	}
+	if fake.bodies[1] != `{"text":"hi"}` {
+		t.Fatalf("Expected the retry to resend the body, got %q", fake.bodies[1])
+	}
+}
+
+func TestLimitedCallerGivesUpOnPersistentFloodWait(t *testing.T) {
+	var responses []*ta.Response
+	for i := 0; i < rateLimitAttempts; i++ {
+		responses = append(responses, &ta.Response{
+			Error: &ta.Error{ErrorCode: http.StatusTooManyRequests, Parameters: &ta.ResponseParameters{}},
+		})
+	}
+	fake := &fakeCaller{responses: responses}
+	caller := NewLimitedCaller(fake, 0)
+
+	resp, err := caller.Call(context.Background(), "https://api.telegram.org/botX/sendMessage", newRequestData("{}"))
+	if err != nil {
+		t.Fatalf("Unexpected call error: %v", err)
+	}
+	if resp.Ok || fake.calls != rateLimitAttempts {
+		t.Fatalf("Expected the flood error after %d attempts, got %v after %d", rateLimitAttempts, resp, fake.calls)
+	}
+}
@@ -2,23 +2,33 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf16"
 
 	"github.com/mymmrac/telego"
+	williamcontext "github.com/xdefrag/william/internal/context"
 	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/internal/runtimestats"
+	"github.com/xdefrag/william/pkg/models"
 )
 
 // statsType represents the type of statistics to show
 type statsType string
 
 const (
-	statsTypeMsgs    statsType = "msgs"
-	statsTypeChars   statsType = "chars"
-	statsTypeLastMsg statsType = "lastmsg"
+	statsTypeMsgs      statsType = "msgs"
+	statsTypeChars     statsType = "chars"
+	statsTypeLastMsg   statsType = "lastmsg"
+	statsTypeQuestions statsType = "questions"
+	statsTypeLinks     statsType = "links"
 )
 
 const (
@@ -26,6 +36,100 @@ const (
 	maxStatsLimit     = 50
 )
 
+// commandHandler runs a command with its arguments, responding in the chat UI language
+type commandHandler func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string)
+
+// botCommand is a registered command with the catalog key of its /help description
+type botCommand struct {
+	name    string
+	helpKey string
+	handler commandHandler
+}
+
+// commandRegistry returns all supported commands in the order they are listed by /help
+func commandRegistry() []botCommand {
+	return []botCommand{
+		{"/help", "help.help", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleHelpCommand(ctx, msg, lang)
+		}},
+		{"/stats", "help.stats", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleStatsCommand(ctx, msg, args, lang)
+		}},
+		{"/summary", "help.summary", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleSummaryCommand(ctx, msg, lang)
+		}},
+		{"/rank", "help.rank", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleRankCommand(ctx, msg, args, lang)
+		}},
+		{"/toptopics", "help.toptopics", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleTopTopicsCommand(ctx, msg, args, lang)
+		}},
+		{"/find", "help.find", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleFindCommand(ctx, msg, args, lang)
+		}},
+		{"/search", "help.search", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleSearchCommand(ctx, msg, args, lang)
+		}},
+		{"/expert", "help.expert", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleExpertCommand(ctx, msg, args, lang)
+		}},
+		{"/events", "help.events", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleEventsCommand(ctx, msg, lang)
+		}},
+		{"/addevent", "help.addevent", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleAddEventCommand(ctx, msg, args, lang)
+		}},
+		{"/removeevent", "help.removeevent", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleRemoveEventCommand(ctx, msg, args, lang)
+		}},
+		{"/summarize", "help.summarize", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleSummarizeCommand(ctx, msg, lang)
+		}},
+		{"/pinsummary", "help.pinsummary", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handlePinSummaryCommand(ctx, msg, lang)
+		}},
+		{"/language", "help.language", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleLanguageCommand(ctx, msg, args, lang)
+		}},
+		{"/config", "help.config", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleConfigCommand(ctx, msg, lang)
+		}},
+		{"/recentreplies", "help.recentreplies", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleRecentRepliesCommand(ctx, msg, args, lang)
+		}},
+		{"/refreshprofile", "help.refreshprofile", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleRefreshProfileCommand(ctx, msg, args, lang)
+		}},
+		{"/setbuffer", "help.setbuffer", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleSetBufferCommand(ctx, msg, args, lang)
+		}},
+		{"/uptime", "help.uptime", func(l *Listener, ctx context.Context, msg *telego.Message, args []string, lang string) {
+			l.handleUptimeCommand(ctx, msg, lang)
+		}},
+	}
+}
+
+// findCommand looks up a registered command by its canonical name
+func findCommand(name string) (botCommand, bool) {
+	for _, cmd := range commandRegistry() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return botCommand{}, false
+}
+
+// isCommand reports whether the text starts with a registered command or its alias
+func (l *Listener) isCommand(text string) bool {
+	parts := strings.Fields(text)
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "/") {
+		return false
+	}
+
+	_, ok := findCommand(resolveCommandAlias(strings.ToLower(parts[0]), l.config.App.Commands.Aliases))
+	return ok
+}
+
 // handleCommand checks if message is a command and handles it
 // Returns true if the message was a command (handled or not)
 func (l *Listener) handleCommand(ctx context.Context, msg *telego.Message) bool {
@@ -39,20 +143,132 @@ func (l *Listener) handleCommand(ctx context.Context, msg *telego.Message) bool
 		return false
 	}
 
-	command := strings.ToLower(parts[0])
+	command := resolveCommandAlias(strings.ToLower(parts[0]), l.config.App.Commands.Aliases)
 	args := parts[1:]
 
-	switch command {
-	case "/stats":
-		go l.handleStatsCommand(ctx, msg, args)
+	cmd, ok := findCommand(command)
+	if !ok {
+		return false
+	}
+
+	// Redelivered updates must not run a command twice
+	if !l.markCommandHandled(ctx, msg) {
+		l.logger.DebugContext(ctx, "Duplicate command ignored",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+			slog.String("command", command),
+		)
+		return true
+	}
+
+	// Silently ignore commands disabled in this chat
+	if l.isCommandDisabled(ctx, msg.Chat.ID, command) {
+		l.logger.DebugContext(ctx, "Command disabled in chat",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.String("command", command),
+		)
+		return true
+	}
+
+	if !l.cooldown.Allow(msg.Chat.ID, msg.From.ID, command, time.Now()) {
+		l.logger.DebugContext(ctx, "Command on cooldown",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("user_id", msg.From.ID),
+			slog.String("command", command),
+		)
+		go func() {
+			l.sendThrottleFeedback(ctx, msg, l.chatLanguage(ctx, msg.Chat.ID), l.reactionsEnabled(ctx, msg.Chat.ID))
+		}()
 		return true
 	}
 
+	go func() { cmd.handler(l, ctx, msg, args, l.chatLanguage(ctx, msg.Chat.ID)) }()
+	return true
+}
+
+// markCommandHandled records the command message and reports whether it is handled for the first time;
+// lookup errors let the command run
+func (l *Listener) markCommandHandled(ctx context.Context, msg *telego.Message) bool {
+	isNew, err := l.repo.MarkCommandHandled(ctx, msg.Chat.ID, int64(msg.MessageID))
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to mark command handled", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+		return true
+	}
+	return isNew
+}
+
+// sendThrottleFeedback answers a command on cooldown as configured by commands.throttle_feedback.
+// The reaction is skipped in chats with reactions disabled.
+func (l *Listener) sendThrottleFeedback(ctx context.Context, msg *telego.Message, lang string, reactionsEnabled bool) {
+	switch l.config.App.Commands.ThrottleFeedback {
+	case "silent":
+	case "reaction":
+		if !reactionsEnabled {
+			return
+		}
+		if err := setReaction(ctx, l.sender, msg.Chat.ID, int64(msg.MessageID), l.config.App.Commands.ThrottleReaction); err != nil {
+			l.logger.WarnContext(ctx, "Failed to set throttle reaction", slog.Any("error", err),
+				slog.Int64("chat_id", msg.Chat.ID),
+				slog.Int("message_id", msg.MessageID),
+			)
+		}
+	default:
+		l.sendCommandError(ctx, msg, translate(lang, "error.throttled"))
+	}
+}
+
+// reactionsEnabled reports whether the bot may react in the chat; lookup errors allow reactions
+func (l *Listener) reactionsEnabled(ctx context.Context, chatID int64) bool {
+	enabled, err := l.repo.GetChatReactionsEnabled(ctx, chatID)
+	if err != nil {
+		l.logger.WarnContext(ctx, "Failed to get chat reactions setting", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return true
+	}
+	return enabled
+}
+
+// isCommandDisabled checks per-chat settings; lookup errors leave the command enabled
+func (l *Listener) isCommandDisabled(ctx context.Context, chatID int64, command string) bool {
+	disabled, err := l.repo.GetChatDisabledCommands(ctx, chatID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get disabled commands", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return false
+	}
+	return commandInList(command, disabled)
+}
+
+// commandInList reports whether the command is in the list. Leading slashes and case are ignored.
+func commandInList(command string, list []string) bool {
+	name := strings.ToLower(strings.TrimPrefix(command, "/"))
+	for _, c := range list {
+		if strings.ToLower(strings.TrimPrefix(c, "/")) == name {
+			return true
+		}
+	}
 	return false
 }
 
+// resolveCommandAlias maps a configured alias to its canonical command name.
+// Leading slashes are optional in the alias configuration.
+func resolveCommandAlias(command string, aliases map[string]string) string {
+	name := strings.TrimPrefix(command, "/")
+	for alias, canonical := range aliases {
+		if strings.ToLower(strings.TrimPrefix(alias, "/")) == name {
+			return "/" + strings.ToLower(strings.TrimPrefix(canonical, "/"))
+		}
+	}
+	return command
+}
+
 // handleStatsCommand handles the /stats command
-func (l *Listener) handleStatsCommand(ctx context.Context, msg *telego.Message, args []string) {
+func (l *Listener) handleStatsCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
 	l.logger.InfoContext(ctx, "Handling stats command",
 		slog.Int64("chat_id", msg.Chat.ID),
 		slog.Int64("user_id", msg.From.ID),
@@ -77,6 +293,10 @@ func (l *Listener) handleStatsCommand(ctx context.Context, msg *telego.Message,
 			sType = statsTypeChars
 		case "lastmsg", "last":
 			sType = statsTypeLastMsg
+		case "questions":
+			sType = statsTypeQuestions
+		case "links":
+			sType = statsTypeLinks
 		default:
 			if n, err := strconv.Atoi(arg); err == nil && n > 0 {
 				limit = n
@@ -92,11 +312,15 @@ func (l *Listener) handleStatsCommand(ctx context.Context, msg *telego.Message,
 
 	switch sType {
 	case statsTypeChars:
-		response, err = l.handleCharStats(ctx, msg.Chat.ID, limit, showBottom)
+		response, err = l.handleCharStats(ctx, msg.Chat.ID, limit, showBottom, lang)
 	case statsTypeLastMsg:
-		response, err = l.handleLastMsgStats(ctx, msg.Chat.ID, limit, showBottom)
+		response, err = l.handleLastMsgStats(ctx, msg.Chat.ID, limit, showBottom, lang)
+	case statsTypeQuestions:
+		response, err = l.handleQuestionStats(ctx, msg.Chat.ID, limit, showBottom, lang)
+	case statsTypeLinks:
+		response, err = l.handleLinkStats(ctx, msg.Chat.ID, limit, showBottom, lang)
 	default:
-		response, err = l.handleMessageStats(ctx, msg.Chat.ID, limit, showBottom)
+		response, err = l.handleMessageStats(ctx, msg.Chat.ID, limit, showBottom, lang)
 	}
 
 	if err != nil {
@@ -105,71 +329,428 @@ func (l *Listener) handleStatsCommand(ctx context.Context, msg *telego.Message,
 			slog.Int64("chat_id", msg.Chat.ID),
 			slog.String("type", string(sType)),
 		)
-		l.sendCommandError(ctx, msg, "Не удалось получить статистику")
+		l.sendCommandError(ctx, msg, translate(lang, "error.stats"))
 		return
 	}
 
 	l.sendCommandResponse(ctx, msg, response)
 }
 
+// handleConfigCommand handles the /config command (global admin only)
+func (l *Listener) handleConfigCommand(ctx context.Context, msg *telego.Message, lang string) {
+	l.logger.InfoContext(ctx, "Handling config command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	if !l.isGlobalAdmin(msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.admin_only"))
+		return
+	}
+
+	sanitized, err := l.config.Sanitized()
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to build sanitized config",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.config"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "config.title")+"\n\n"+sanitized)
+}
+
+// handleUptimeCommand handles the /uptime command (global admin only)
+func (l *Listener) handleUptimeCommand(ctx context.Context, msg *telego.Message, lang string) {
+	l.logger.InfoContext(ctx, "Handling uptime command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	if !l.isGlobalAdmin(msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.admin_only"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatUptimeResponse(l.stats.Snapshot(), lang))
+}
+
+// formatUptimeResponse formats the runtime counters, with uptime rounded to seconds
+func formatUptimeResponse(snapshot runtimestats.Snapshot, lang string) string {
+	return translate(lang, "uptime.title") + "\n\n" + translate(lang, "uptime.body",
+		snapshot.Uptime.Truncate(time.Second), snapshot.MessagesProcessed, snapshot.OpenAICalls, snapshot.Goroutines)
+}
+
+// handleSummarizeCommand handles the /summarize command (admins and moderators only)
+func (l *Listener) handleSummarizeCommand(ctx context.Context, msg *telego.Message, lang string) {
+	l.logger.InfoContext(ctx, "Handling summarize command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Int("message_thread_id", msg.MessageThreadID),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	if err := l.requestSummarize(ctx, msg); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to publish summarize event", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.summarize"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "summarize.started"))
+}
+
+// handlePinSummaryCommand handles the /pinsummary command (admins and moderators only)
+func (l *Listener) handlePinSummaryCommand(ctx context.Context, msg *telego.Message, lang string) {
+	topicID := l.getTopicID(msg)
+	l.logger.InfoContext(ctx, "Handling pin summary command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("topic_id", topicID),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	if !l.botCan(ctx, msg.Chat.ID, func(r botRights) bool { return r.CanPinMessages }) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.bot_cannot_pin"))
+		return
+	}
+
+	err := postPinnedSummary(ctx, l.repo, l.sender, l.bot, msg.Chat.ID, topicID)
+	if errors.Is(err, repo.ErrChatSummaryNotFound) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.pin_no_summary"))
+		return
+	}
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to pin summary", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.pin"))
+	}
+}
+
+// handleLanguageCommand handles the /language <ru|en> command (admins and moderators only)
+func (l *Listener) handleLanguageCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling language command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("args", args),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	if len(args) != 1 || !isSupportedLanguage(strings.ToLower(args[0])) {
+		l.sendCommandError(ctx, msg, translate(lang, "language.usage"))
+		return
+	}
+	newLang := strings.ToLower(args[0])
+
+	if err := l.repo.SetChatUILanguage(ctx, msg.Chat.ID, newLang); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to set chat UI language", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.language"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(newLang, "language.set"))
+}
+
+// handleTopTopicsCommand handles the /toptopics command
+func (l *Listener) handleTopTopicsCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	topicID := l.getTopicID(msg)
+	l.logger.InfoContext(ctx, "Handling top topics command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("topic_id", topicID),
+	)
+
+	limit := defaultStatsLimit
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			limit = min(n, maxStatsLimit)
+		}
+	}
+
+	summary, err := l.repo.GetLatestChatSummaryByTopic(ctx, msg.Chat.ID, topicID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get chat summary for top topics",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.topics"))
+		return
+	}
+
+	var topics []williamcontext.TopicCount
+	if summary != nil {
+		topics = williamcontext.TopTopics(summary.TopicsJSON, limit)
+	}
+
+	l.sendCommandResponse(ctx, msg, formatTopTopicsResponse(topics, lang))
+}
+
+// formatTopTopicsResponse formats sorted topics with their counts
+func formatTopTopicsResponse(topics []williamcontext.TopicCount, lang string) string {
+	if len(topics) == 0 {
+		return translate(lang, "topics.empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "topics.title", len(topics)) + "\n\n")
+
+	for i, topic := range topics {
+		count := strconv.FormatFloat(math.Round(topic.Count*10)/10, 'f', -1, 64)
+		sb.WriteString(fmt.Sprintf("%d. %s — %s\n", i+1, topic.Name, count))
+	}
+
+	return sb.String()
+}
+
+// maxMessageLength is the Telegram limit for message text in UTF-16 code units
+const maxMessageLength = 4096
+
+// summaryTopicsLimit is the number of top topics listed by /summary
+const summaryTopicsLimit = 5
+
+// handleSummaryCommand handles the /summary command
+func (l *Listener) handleSummaryCommand(ctx context.Context, msg *telego.Message, lang string) {
+	topicID := l.getTopicID(msg)
+	l.logger.InfoContext(ctx, "Handling summary command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("topic_id", topicID),
+	)
+
+	summary, err := l.repo.GetLatestChatSummaryByTopic(ctx, msg.Chat.ID, topicID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get chat summary",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.summary"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatSummaryResponse(summary, lang))
+}
+
+// formatSummaryResponse formats the chat summary with its top topics and upcoming events,
+// truncated to fit a single Telegram message
+func formatSummaryResponse(summary *models.ChatSummary, lang string) string {
+	if summary == nil || strings.TrimSpace(summary.Summary) == "" {
+		return translate(lang, "summary.empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "summary.title") + "\n\n")
+	sb.WriteString(strings.TrimSpace(summary.Summary))
+
+	if topics := williamcontext.TopTopics(summary.TopicsJSON, summaryTopicsLimit); len(topics) > 0 {
+		names := make([]string, len(topics))
+		for i, topic := range topics {
+			names[i] = topic.Name
+		}
+		sb.WriteString("\n\n" + translate(lang, "summary.topics") + ": " + strings.Join(names, ", "))
+	}
+
+	if len(summary.NextEventsJSON) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(formatEventsResponse(summary.NextEventsJSON, lang))
+	}
+
+	return truncateMessage(sb.String(), maxMessageLength)
+}
+
+// truncateMessage cuts text to at most limit UTF-16 code units, ending it with an ellipsis
+func truncateMessage(text string, limit int) string {
+	if len(utf16.Encode([]rune(text))) <= limit {
+		return text
+	}
+
+	const ellipsis = "…"
+	var sb strings.Builder
+	length := 0
+	for _, r := range text {
+		n := utf16.RuneLen(r)
+		if length+n > limit-1 {
+			break
+		}
+		sb.WriteRune(r)
+		length += n
+	}
+
+	return strings.TrimRightFunc(sb.String(), unicode.IsSpace) + ellipsis
+}
+
+// requestSummarize publishes a summarize event for the chat topic the message belongs to
+func (l *Listener) requestSummarize(ctx context.Context, msg *telego.Message) error {
+	return l.publishSummarizeEvent(ctx, msg.Chat.ID, l.getTopicID(msg))
+}
+
+// canModerate checks if the user is the global admin or has an active admin/moderator role in the chat
+func (l *Listener) canModerate(ctx context.Context, chatID, userID int64) bool {
+	if l.isGlobalAdmin(userID) {
+		return true
+	}
+
+	role, err := l.repo.GetUserRole(ctx, userID, chatID)
+	if err != nil {
+		l.logger.DebugContext(ctx, "No role found for user", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+			slog.Int64("user_id", userID),
+		)
+		return false
+	}
+
+	return isModeratorRole(role, time.Now())
+}
+
+// isModeratorRole checks if the role grants admin/moderator rights and has not expired
+func isModeratorRole(role *models.UserRole, now time.Time) bool {
+	if role == nil || (role.ExpiresAt != nil && !role.ExpiresAt.After(now)) {
+		return false
+	}
+	return role.Role == "admin" || role.Role == "moderator"
+}
+
+// isGlobalAdmin checks if the user is the configured global bot administrator
+func (l *Listener) isGlobalAdmin(userID int64) bool {
+	adminID := l.config.App.App.AdminUserID
+	return adminID != 0 && adminID == userID
+}
+
 // handleMessageStats handles message count statistics
-func (l *Listener) handleMessageStats(ctx context.Context, chatID int64, limit int, showBottom bool) (string, error) {
-	stats, err := l.repo.GetUserMessageStats(ctx, chatID, limit, showBottom)
+func (l *Listener) handleMessageStats(ctx context.Context, chatID int64, limit int, showBottom bool, lang string) (string, error) {
+	stats, err := l.repo.GetUserMessageStats(ctx, chatID, l.statsQueryLimit(limit), showBottom)
 	if err != nil {
 		return "", err
 	}
+	if l.config.App.Stats.MergeUnknownUsers {
+		stats = mergeUnknownMessageStats(stats, showBottom, limit)
+	}
 
 	if len(stats) == 0 {
-		return "Статистика пока недоступна — нет данных о сообщениях.", nil
+		return translate(lang, "stats.empty"), nil
 	}
 
-	return l.formatStatsResponse(stats, showBottom, limit), nil
+	return l.formatStatsResponse(stats, showBottom, limit, lang), nil
 }
 
 // handleCharStats handles character count statistics
-func (l *Listener) handleCharStats(ctx context.Context, chatID int64, limit int, showBottom bool) (string, error) {
-	stats, err := l.repo.GetUserCharStats(ctx, chatID, limit, showBottom)
+func (l *Listener) handleCharStats(ctx context.Context, chatID int64, limit int, showBottom bool, lang string) (string, error) {
+	stats, err := l.repo.GetUserCharStats(ctx, chatID, l.statsQueryLimit(limit), showBottom)
 	if err != nil {
 		return "", err
 	}
+	if l.config.App.Stats.MergeUnknownUsers {
+		stats = mergeUnknownCharStats(stats, showBottom, limit)
+	}
 
 	if len(stats) == 0 {
-		return "Статистика пока недоступна — нет данных о сообщениях.", nil
+		return translate(lang, "stats.empty"), nil
 	}
 
-	return l.formatCharStatsResponse(stats, showBottom), nil
+	return l.formatCharStatsResponse(stats, showBottom, lang), nil
 }
 
 // handleLastMsgStats handles last message time statistics
-func (l *Listener) handleLastMsgStats(ctx context.Context, chatID int64, limit int, showBottom bool) (string, error) {
+func (l *Listener) handleLastMsgStats(ctx context.Context, chatID int64, limit int, showBottom bool, lang string) (string, error) {
 	stats, err := l.repo.GetUserLastMessageStats(ctx, chatID, limit, showBottom)
 	if err != nil {
 		return "", err
 	}
 
 	if len(stats) == 0 {
-		return "Статистика пока недоступна — нет данных о сообщениях.", nil
+		return translate(lang, "stats.empty"), nil
 	}
 
-	return l.formatLastMsgStatsResponse(stats, showBottom), nil
+	return l.formatLastMsgStatsResponse(stats, showBottom, lang), nil
+}
+
+// handleQuestionStats handles questions asked statistics
+func (l *Listener) handleQuestionStats(ctx context.Context, chatID int64, limit int, showBottom bool, lang string) (string, error) {
+	stats, err := l.repo.GetUserQuestionStats(ctx, chatID, l.statsQueryLimit(limit), showBottom)
+	if err != nil {
+		return "", err
+	}
+	if l.config.App.Stats.MergeUnknownUsers {
+		stats = mergeUnknownMessageStats(stats, showBottom, limit)
+	}
+
+	if len(stats) == 0 {
+		return translate(lang, "stats.questions_empty"), nil
+	}
+
+	if showBottom {
+		return l.formatCountStatsResponse(stats, "stats.questions_bottom", "word.question", lang), nil
+	}
+	return l.formatCountStatsResponse(stats, "stats.questions_top", "word.question", lang), nil
+}
+
+// handleLinkStats handles links shared statistics
+func (l *Listener) handleLinkStats(ctx context.Context, chatID int64, limit int, showBottom bool, lang string) (string, error) {
+	stats, err := l.repo.GetUserLinkStats(ctx, chatID, l.statsQueryLimit(limit), showBottom)
+	if err != nil {
+		return "", err
+	}
+	if l.config.App.Stats.MergeUnknownUsers {
+		stats = mergeUnknownMessageStats(stats, showBottom, limit)
+	}
+
+	if len(stats) == 0 {
+		return translate(lang, "stats.links_empty"), nil
+	}
+
+	if showBottom {
+		return l.formatCountStatsResponse(stats, "stats.links_bottom", "word.link", lang), nil
+	}
+	return l.formatCountStatsResponse(stats, "stats.links_top", "word.link", lang), nil
+}
+
+// formatCountStatsResponse formats per-user counts with catalog keys for the title and the counted item
+func (l *Listener) formatCountStatsResponse(stats []*repo.UserMessageStats, titleKey, wordKey, lang string) string {
+	var sb strings.Builder
+
+	sb.WriteString(translate(lang, titleKey, len(stats)) + "\n\n")
+
+	for i, s := range stats {
+		displayName := l.formatUserDisplayName(s, lang)
+		word := pluralWord(lang, wordKey, int64(s.MessageCount))
+		sb.WriteString(fmt.Sprintf("%d. %s — %d %s\n", i+1, displayName, s.MessageCount, word))
+	}
+
+	return sb.String()
 }
 
 // formatStatsResponse formats the stats into a readable message
-func (l *Listener) formatStatsResponse(stats []*repo.UserMessageStats, showBottom bool, limit int) string {
+func (l *Listener) formatStatsResponse(stats []*repo.UserMessageStats, showBottom bool, limit int, lang string) string {
 	var sb strings.Builder
 
 	if showBottom {
-		sb.WriteString(fmt.Sprintf("📊 Наименее активные участники (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.messages_bottom", len(stats)) + "\n\n")
 	} else {
-		sb.WriteString(fmt.Sprintf("📊 Самые активные участники (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.messages_top", len(stats)) + "\n\n")
 	}
 
 	for i, s := range stats {
 		// Format user display name
-		displayName := l.formatUserDisplayName(s)
+		displayName := l.formatUserDisplayName(s, lang)
 
 		// Format message count with proper plural form
-		msgWord := l.pluralize(s.MessageCount, "сообщение", "сообщения", "сообщений")
+		msgWord := pluralWord(lang, "word.message", int64(s.MessageCount))
 
 		sb.WriteString(fmt.Sprintf("%d. %s — %d %s\n", i+1, displayName, s.MessageCount, msgWord))
 	}
@@ -178,7 +759,17 @@ func (l *Listener) formatStatsResponse(stats []*repo.UserMessageStats, showBotto
 }
 
 // formatUserDisplay formats user info for display (generic version)
-func (l *Listener) formatUserDisplay(userID int64, username *string, firstName string, lastName *string) string {
+func (l *Listener) formatUserDisplay(userID int64, username *string, firstName string, lastName *string, lang string) string {
+	var unknownLabel string
+	if isUnknownUser(username, firstName, lastName) {
+		unknownLabel = l.config.App.Stats.UnknownUserLabel
+	}
+	return userDisplay(userID, username, firstName, lastName, unknownLabel, lang)
+}
+
+// userDisplay formats user info for display, using unknownLabel (if set) for users without
+// username or name
+func userDisplay(userID int64, username *string, firstName string, lastName *string, unknownLabel, lang string) string {
 	// Build full name
 	fullName := firstName
 	if lastName != nil && *lastName != "" {
@@ -197,27 +788,107 @@ func (l *Listener) formatUserDisplay(userID int64, username *string, firstName s
 		return fullName
 	}
 
+	if unknownLabel != "" {
+		return unknownLabel
+	}
+	if userID == unknownUsersBucketID {
+		return translate(lang, "stats.unknown_users")
+	}
+
 	return fmt.Sprintf("User %d", userID)
 }
 
+// unknownUsersBucketID is the user id of the merged entry for users without username or name
+const unknownUsersBucketID = 0
+
+// isUnknownUser reports whether the user has neither username nor name to display
+func isUnknownUser(username *string, firstName string, lastName *string) bool {
+	return (username == nil || *username == "") && firstName == "" && (lastName == nil || *lastName == "")
+}
+
+// statsQueryLimit is the number of users to query for count-based stats. Merging unknown users needs
+// all of them, so the bucket sums every unknown user and the merged list is trimmed afterwards.
+func (l *Listener) statsQueryLimit(limit int) int {
+	if l.config.App.Stats.MergeUnknownUsers {
+		return 0
+	}
+	return limit
+}
+
+// mergeUnknownMessageStats combines unknown users into one entry, restores the count order and
+// keeps the first limit entries
+func mergeUnknownMessageStats(stats []*repo.UserMessageStats, ascending bool, limit int) []*repo.UserMessageStats {
+	var merged []*repo.UserMessageStats
+	var bucket *repo.UserMessageStats
+
+	for _, s := range stats {
+		if !isUnknownUser(s.Username, s.FirstName, s.LastName) {
+			merged = append(merged, s)
+			continue
+		}
+		if bucket == nil {
+			bucket = &repo.UserMessageStats{UserID: unknownUsersBucketID}
+			merged = append(merged, bucket)
+		}
+		bucket.MessageCount += s.MessageCount
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if ascending {
+			return merged[i].MessageCount < merged[j].MessageCount
+		}
+		return merged[i].MessageCount > merged[j].MessageCount
+	})
+
+	return merged[:min(len(merged), limit)]
+}
+
+// mergeUnknownCharStats combines unknown users into one entry, restores the count order and
+// keeps the first limit entries
+func mergeUnknownCharStats(stats []*repo.UserCharStats, ascending bool, limit int) []*repo.UserCharStats {
+	var merged []*repo.UserCharStats
+	var bucket *repo.UserCharStats
+
+	for _, s := range stats {
+		if !isUnknownUser(s.Username, s.FirstName, s.LastName) {
+			merged = append(merged, s)
+			continue
+		}
+		if bucket == nil {
+			bucket = &repo.UserCharStats{UserID: unknownUsersBucketID}
+			merged = append(merged, bucket)
+		}
+		bucket.CharCount += s.CharCount
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if ascending {
+			return merged[i].CharCount < merged[j].CharCount
+		}
+		return merged[i].CharCount > merged[j].CharCount
+	})
+
+	return merged[:min(len(merged), limit)]
+}
+
 // formatUserDisplayName formats user info for display
-func (l *Listener) formatUserDisplayName(s *repo.UserMessageStats) string {
-	return l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName)
+func (l *Listener) formatUserDisplayName(s *repo.UserMessageStats, lang string) string {
+	return l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName, lang)
 }
 
 // formatCharStatsResponse formats character statistics into a readable message
-func (l *Listener) formatCharStatsResponse(stats []*repo.UserCharStats, showBottom bool) string {
+func (l *Listener) formatCharStatsResponse(stats []*repo.UserCharStats, showBottom bool, lang string) string {
 	var sb strings.Builder
 
 	if showBottom {
-		sb.WriteString(fmt.Sprintf("📊 Наименее активные по символам (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.chars_bottom", len(stats)) + "\n\n")
 	} else {
-		sb.WriteString(fmt.Sprintf("📊 Самые активные по символам (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.chars_top", len(stats)) + "\n\n")
 	}
 
 	for i, s := range stats {
-		displayName := l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName)
-		charWord := l.pluralize64(s.CharCount, "символ", "символа", "символов")
+		displayName := l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName, lang)
+		charWord := pluralWord(lang, "word.char", s.CharCount)
 		sb.WriteString(fmt.Sprintf("%d. %s — %s %s\n", i+1, displayName, l.formatNumber(s.CharCount), charWord))
 	}
 
@@ -225,18 +896,18 @@ func (l *Listener) formatCharStatsResponse(stats []*repo.UserCharStats, showBott
 }
 
 // formatLastMsgStatsResponse formats last message statistics into a readable message
-func (l *Listener) formatLastMsgStatsResponse(stats []*repo.UserLastMessageStats, showBottom bool) string {
+func (l *Listener) formatLastMsgStatsResponse(stats []*repo.UserLastMessageStats, showBottom bool, lang string) string {
 	var sb strings.Builder
 
 	if showBottom {
-		sb.WriteString(fmt.Sprintf("📊 Давно не писали (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.lastmsg_bottom", len(stats)) + "\n\n")
 	} else {
-		sb.WriteString(fmt.Sprintf("📊 Последние отписавшиеся (топ-%d)\n\n", len(stats)))
+		sb.WriteString(translate(lang, "stats.lastmsg_top", len(stats)) + "\n\n")
 	}
 
 	for i, s := range stats {
-		displayName := l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName)
-		timeAgo := l.formatTimeAgo(s.LastMessageAt)
+		displayName := l.formatUserDisplay(s.UserID, s.Username, s.FirstName, s.LastName, lang)
+		timeAgo := l.formatTimeAgo(s.LastMessageAt, lang)
 		sb.WriteString(fmt.Sprintf("%d. %s — %s\n", i+1, displayName, timeAgo))
 	}
 
@@ -261,37 +932,41 @@ func (l *Listener) formatNumber(n int64) string {
 	return result.String()
 }
 
-// formatTimeAgo formats time as relative string
-func (l *Listener) formatTimeAgo(t time.Time) string {
+// location returns the configured timezone used to display times, UTC if unset
+func (l *Listener) location() *time.Location {
+	if l.config.Location == nil {
+		return time.UTC
+	}
+	return l.config.Location
+}
+
+// formatTimeAgo formats time as relative string, showing clock times in the configured timezone
+func (l *Listener) formatTimeAgo(t time.Time, lang string) string {
 	now := time.Now()
 	diff := now.Sub(t)
+	t = t.In(l.location())
 
 	switch {
 	case diff < time.Minute:
-		return "только что"
+		return translate(lang, "time.just_now")
 	case diff < time.Hour:
-		mins := int(diff.Minutes())
-		return fmt.Sprintf("%d %s назад", mins, l.pluralize(mins, "минуту", "минуты", "минут"))
+		mins := int64(diff.Minutes())
+		return translate(lang, "time.ago", mins, pluralWord(lang, "word.minute", mins))
 	case diff < 24*time.Hour:
-		hours := int(diff.Hours())
-		return fmt.Sprintf("%d %s назад", hours, l.pluralize(hours, "час", "часа", "часов"))
+		hours := int64(diff.Hours())
+		return translate(lang, "time.ago", hours, pluralWord(lang, "word.hour", hours))
 	case diff < 48*time.Hour:
-		return fmt.Sprintf("вчера в %s", t.Format("15:04"))
+		return translate(lang, "time.yesterday", t.Format("15:04"))
 	case diff < 7*24*time.Hour:
-		days := int(diff.Hours() / 24)
-		return fmt.Sprintf("%d %s назад", days, l.pluralize(days, "день", "дня", "дней"))
+		days := int64(diff.Hours() / 24)
+		return translate(lang, "time.ago", days, pluralWord(lang, "word.day", days))
 	default:
 		return t.Format("02.01.2006 15:04")
 	}
 }
 
-// pluralize64 returns the correct Russian plural form for int64
-func (l *Listener) pluralize64(n int64, one, few, many string) string {
-	return l.pluralize(int(n%100), one, few, many)
-}
-
-// pluralize returns the correct Russian plural form
-func (l *Listener) pluralize(n int, one, few, many string) string {
+// russianPlural returns the correct Russian plural form
+func russianPlural(n int64, one, few, many string) string {
 	abs := n
 	if abs < 0 {
 		abs = -abs
@@ -324,7 +999,7 @@ func (l *Listener) sendCommandResponse(ctx context.Context, msg *telego.Message,
 		params.MessageThreadID = msg.MessageThreadID
 	}
 
-	_, err := l.bot.SendMessage(ctx, params)
+	_, err := l.sender.SendMessage(ctx, params)
 	if err != nil {
 		l.logger.ErrorContext(ctx, "Failed to send command response",
 			slog.Any("error", err),
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/mymmrac/telego"
+)
+
+// Bounds of the per-chat summarization buffer set by /setbuffer
+const (
+	minChatMsgBuffer = 5
+	maxChatMsgBuffer = 1000
+)
+
+// handleSetBufferCommand handles the /setbuffer <N|0> command (global admin only): sets the number
+// of messages collected before a chat topic is summarized, 0 resets it to the global default
+func (l *Listener) handleSetBufferCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling set buffer command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("args", args),
+	)
+
+	if !l.isGlobalAdmin(msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.admin_only"))
+		return
+	}
+
+	size, ok := parseBufferSize(args)
+	if !ok {
+		l.sendCommandError(ctx, msg, translate(lang, "buffer.usage", minChatMsgBuffer, maxChatMsgBuffer))
+		return
+	}
+
+	if err := l.repo.SetChatMaxMsgBuffer(ctx, msg.Chat.ID, size); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to set chat message buffer", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.buffer"))
+		return
+	}
+	l.limits.Invalidate(msg.Chat.ID)
+
+	if size == 0 {
+		l.sendCommandResponse(ctx, msg, translate(lang, "buffer.reset", l.config.App.Limits.MaxMsgBuffer))
+		return
+	}
+	l.sendCommandResponse(ctx, msg, translate(lang, "buffer.set", size))
+}
+
+// parseBufferSize parses the /setbuffer argument: a size within bounds or 0 to reset
+func parseBufferSize(args []string) (int, bool) {
+	if len(args) != 1 {
+		return 0, false
+	}
+
+	size, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, false
+	}
+	if size != 0 && (size < minChatMsgBuffer || size > maxChatMsgBuffer) {
+		return 0, false
+	}
+	return size, true
+}
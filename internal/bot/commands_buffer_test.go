@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestParseBufferSize(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   int
+		wantOK bool
+	}{
+		{[]string{"30"}, 30, true},
+		{[]string{"0"}, 0, true},
+		{[]string{"5"}, 5, true},
+		{[]string{"1000"}, 1000, true},
+		{[]string{"4"}, 0, false},
+		{[]string{"1001"}, 0, false},
+		{[]string{"-10"}, 0, false},
+		{[]string{"many"}, 0, false},
+		{nil, 0, false},
+		{[]string{"30", "40"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseBufferSize(tt.args)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseBufferSize(%v) = %d, %v, want %d, %v", tt.args, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestHandleMessageUsesChatBuffer(t *testing.T) {
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	cfg := &config.Config{}
+	cfg.App.Limits.MaxMsgBuffer = 100
+	l := newTestListener(t, cfg, chatID, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if err := l.repo.SetChatMaxMsgBuffer(ctx, chatID, 5); err != nil {
+		t.Fatalf("SetChatMaxMsgBuffer failed: %v", err)
+	}
+
+	send := func(id int) {
+		l.handleMessage(ctx, &telego.Message{
+			MessageID: id,
+			Date:      time.Now().Unix(),
+			Chat:      telego.Chat{ID: chatID, Type: "supergroup"},
+			From:      &telego.User{ID: 1, FirstName: "Alice"},
+			Text:      "hello",
+		})
+	}
+
+	for id := 1; id <= 4; id++ {
+		send(id)
+	}
+	if count, err := l.repo.GetMessageCounter(ctx, chatID); err != nil || count != 4 {
+		t.Fatalf("Expected 4 counted messages below the chat buffer, got %d (%v)", count, err)
+	}
+
+	// The fifth message fills the chat's buffer and resets the counter for summarization
+	send(5)
+	if count, err := l.repo.GetMessageCounter(ctx, chatID); err != nil || count != 0 {
+		t.Errorf("Expected the chat buffer to trigger summarization, got counter %d (%v)", count, err)
+	}
+}
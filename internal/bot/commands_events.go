@@ -0,0 +1,290 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// eventDateLayouts are the accepted ISO-8601 forms for event dates
+var eventDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// handleEventsCommand handles the /events command
+func (l *Listener) handleEventsCommand(ctx context.Context, msg *telego.Message, lang string) {
+	topicID := l.getTopicID(msg)
+	l.logger.InfoContext(ctx, "Handling events command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("topic_id", topicID),
+	)
+
+	if l.config.App.Limits.StoreChatEvents {
+		stored, err := l.repo.GetUpcomingEvents(ctx, msg.Chat.ID, topicID, time.Now(), 0)
+		if err != nil {
+			l.logger.ErrorContext(ctx, "Failed to get upcoming events",
+				slog.Any("error", err),
+				slog.Int64("chat_id", msg.Chat.ID),
+			)
+			l.sendCommandError(ctx, msg, translate(lang, "error.events"))
+			return
+		}
+
+		l.sendCommandResponse(ctx, msg, formatEventsResponse(storedEvents(stored), lang))
+		return
+	}
+
+	summary, err := l.repo.GetLatestChatSummaryByTopic(ctx, msg.Chat.ID, topicID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get chat summary for events",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.events"))
+		return
+	}
+
+	var events []models.Event
+	if summary != nil {
+		events = summary.NextEventsJSON
+	}
+
+	l.sendCommandResponse(ctx, msg, formatEventsResponse(events, lang))
+}
+
+// handleAddEventCommand handles the /addevent <date> <title> command (admins and moderators only)
+func (l *Listener) handleAddEventCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling add event command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	event, err := parseAddEventArgs(args)
+	if err != nil {
+		l.sendCommandError(ctx, msg, translate(lang, "events.add_usage"))
+		return
+	}
+
+	topicID := l.getTopicID(msg)
+	_, err = l.repo.UpdateChatSummaryEvents(ctx, msg.Chat.ID, topicID, func(events []models.Event) ([]models.Event, error) {
+		return append(events, event), nil
+	})
+	if err != nil {
+		l.handleEventUpdateError(ctx, msg, err, lang)
+		return
+	}
+
+	if l.config.App.Limits.StoreChatEvents {
+		stored := &models.ChatEvent{ChatID: msg.Chat.ID, TopicID: topicID, Title: event.Title, Date: event.Date}
+		if end, ok := eventEnd(event.Date, l.location()); ok {
+			stored.EndsAt = &end
+		}
+		if err := l.repo.SaveChatEvents(ctx, []*models.ChatEvent{stored}); err != nil {
+			l.handleEventUpdateError(ctx, msg, err, lang)
+			return
+		}
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "events.added", event.Date, event.Title))
+}
+
+// handleRemoveEventCommand handles the /removeevent <number> command (admins and moderators only)
+func (l *Listener) handleRemoveEventCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling remove event command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	if len(args) != 1 {
+		l.sendCommandError(ctx, msg, translate(lang, "events.remove_usage"))
+		return
+	}
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		l.sendCommandError(ctx, msg, translate(lang, "events.remove_usage"))
+		return
+	}
+
+	if l.config.App.Limits.StoreChatEvents {
+		l.removeStoredEvent(ctx, msg, number, lang)
+		return
+	}
+
+	var removed models.Event
+	_, err = l.repo.UpdateChatSummaryEvents(ctx, msg.Chat.ID, l.getTopicID(msg), func(events []models.Event) ([]models.Event, error) {
+		updated, event, err := removeEvent(events, number)
+		removed = event
+		return updated, err
+	})
+	if err != nil {
+		l.handleEventUpdateError(ctx, msg, err, lang)
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "events.removed", removed.Title))
+}
+
+// removeStoredEvent removes the event with the given number in the stored upcoming events
+// listed by /events, dropping it from the chat summary too so summarization doesn't store it again
+func (l *Listener) removeStoredEvent(ctx context.Context, msg *telego.Message, number int, lang string) {
+	topicID := l.getTopicID(msg)
+	stored, err := l.repo.GetUpcomingEvents(ctx, msg.Chat.ID, topicID, time.Now(), 0)
+	if err != nil {
+		l.handleEventUpdateError(ctx, msg, err, lang)
+		return
+	}
+	if number < 1 || number > len(stored) {
+		l.handleEventUpdateError(ctx, msg, errEventNotFound, lang)
+		return
+	}
+
+	removed := stored[number-1]
+	if err := l.repo.DeleteChatEvent(ctx, msg.Chat.ID, removed.ID); err != nil {
+		l.handleEventUpdateError(ctx, msg, err, lang)
+		return
+	}
+
+	_, err = l.repo.UpdateChatSummaryEvents(ctx, msg.Chat.ID, topicID, func(events []models.Event) ([]models.Event, error) {
+		return withoutEvent(events, removed.Title, removed.Date), nil
+	})
+	if err != nil && !errors.Is(err, repo.ErrChatSummaryNotFound) {
+		l.handleEventUpdateError(ctx, msg, err, lang)
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "events.removed", removed.Title))
+}
+
+// handleEventUpdateError replies to a failed event update with a user-facing reason
+func (l *Listener) handleEventUpdateError(ctx context.Context, msg *telego.Message, err error, lang string) {
+	switch {
+	case errors.Is(err, repo.ErrChatSummaryNotFound):
+		l.sendCommandError(ctx, msg, translate(lang, "error.no_summary"))
+	case errors.Is(err, errEventNotFound):
+		l.sendCommandError(ctx, msg, translate(lang, "error.event_not_found"))
+	default:
+		l.logger.ErrorContext(ctx, "Failed to update chat summary events",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.events_update"))
+	}
+}
+
+// errEventNotFound is returned when an event number is out of range
+var errEventNotFound = errors.New("event not found")
+
+// parseAddEventArgs parses "<date> <title...>" validating the date as ISO-8601
+func parseAddEventArgs(args []string) (models.Event, error) {
+	if len(args) < 2 {
+		return models.Event{}, fmt.Errorf("expected date and title")
+	}
+
+	date := args[0]
+	if !isISODate(date) {
+		return models.Event{}, fmt.Errorf("invalid ISO-8601 date %q", date)
+	}
+
+	return models.Event{Title: strings.Join(args[1:], " "), Date: date}, nil
+}
+
+// isISODate reports whether value parses as one of the accepted ISO-8601 layouts
+func isISODate(value string) bool {
+	for _, layout := range eventDateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEvent removes the event with the given 1-based number and returns it
+func removeEvent(events []models.Event, number int) ([]models.Event, models.Event, error) {
+	if number < 1 || number > len(events) {
+		return nil, models.Event{}, errEventNotFound
+	}
+
+	removed := events[number-1]
+	updated := make([]models.Event, 0, len(events)-1)
+	updated = append(updated, events[:number-1]...)
+	updated = append(updated, events[number:]...)
+	return updated, removed, nil
+}
+
+// withoutEvent drops events with the given title (case-insensitive) and date
+func withoutEvent(events []models.Event, title, date string) []models.Event {
+	var kept []models.Event
+	for _, event := range events {
+		if strings.EqualFold(event.Title, title) && event.Date == date {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
+
+// storedEvents converts stored chat events for formatEventsResponse
+func storedEvents(stored []*models.ChatEvent) []models.Event {
+	events := make([]models.Event, 0, len(stored))
+	for _, event := range stored {
+		events = append(events, models.Event{Title: event.Title, Date: event.Date})
+	}
+	return events
+}
+
+// eventEnd parses an event date and returns the moment it is considered over:
+// its start, or the end of the day for date-only events
+func eventEnd(date string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range eventDateLayouts {
+		t, err := time.ParseInLocation(layout, date, loc)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			return t.AddDate(0, 0, 1), true
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// formatEventsResponse formats upcoming events as a numbered list
+func formatEventsResponse(events []models.Event, lang string) string {
+	if len(events) == 0 {
+		return translate(lang, "events.empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "events.title") + "\n\n")
+
+	for i, event := range events {
+		if event.Date != "" {
+			sb.WriteString(fmt.Sprintf("%d. %s — %s\n", i+1, event.Date, event.Title))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, event.Title))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestParseAddEventArgs(t *testing.T) {
+	event, err := parseAddEventArgs([]string{"2025-12-31", "Новогодний", "созвон"})
+	if err != nil {
+		t.Fatalf("parseAddEventArgs returned error: %v", err)
+	}
+	if event.Date != "2025-12-31" || event.Title != "Новогодний созвон" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	for _, date := range []string{"2025-12-31T18:00:00+03:00", "2025-12-31T18:00"} {
+		if _, err := parseAddEventArgs([]string{date, "Meetup"}); err != nil {
+			t.Errorf("Expected %q to be a valid ISO-8601 date: %v", date, err)
+		}
+	}
+
+	for _, args := range [][]string{{"31.12.2025", "Meetup"}, {"2025-12-31"}, nil} {
+		if _, err := parseAddEventArgs(args); err == nil {
+			t.Errorf("Expected error for args %v", args)
+		}
+	}
+}
+
+func TestAddAndRemoveEventShownInEvents(t *testing.T) {
+	events := []models.Event{{Title: "Release", Date: "2025-12-01"}}
+
+	added, err := parseAddEventArgs([]string{"2025-12-31", "Party"})
+	if err != nil {
+		t.Fatalf("parseAddEventArgs returned error: %v", err)
+	}
+	events = append(events, added)
+
+	listing := formatEventsResponse(events, LanguageRussian)
+	if !strings.Contains(listing, "2. 2025-12-31 — Party") {
+		t.Errorf("Expected added event in /events output, got %q", listing)
+	}
+
+	events, removed, err := removeEvent(events, 2)
+	if err != nil {
+		t.Fatalf("removeEvent returned error: %v", err)
+	}
+	if removed.Title != "Party" {
+		t.Errorf("Expected removed event Party, got %+v", removed)
+	}
+
+	listing = formatEventsResponse(events, LanguageRussian)
+	if strings.Contains(listing, "Party") || !strings.Contains(listing, "1. 2025-12-01 — Release") {
+		t.Errorf("Expected only Release in /events output, got %q", listing)
+	}
+
+	if _, _, err := removeEvent(events, 5); !errors.Is(err, errEventNotFound) {
+		t.Errorf("Expected errEventNotFound, got %v", err)
+	}
+
+	if got := formatEventsResponse(nil, LanguageRussian); got != "📅 Запланированных событий нет." {
+		t.Errorf("Unexpected empty events output: %q", got)
+	}
+}
+
+func TestStoredEventsRemoval(t *testing.T) {
+	stored := []*models.ChatEvent{{ID: 1, Title: "Release", Date: "2025-12-01"}, {ID: 2, Title: "Party"}}
+	if got := formatEventsResponse(storedEvents(stored), LanguageEnglish); !strings.Contains(got, "1. 2025-12-01 — Release") || !strings.Contains(got, "2. Party") {
+		t.Errorf("Unexpected stored events output: %q", got)
+	}
+
+	events := []models.Event{{Title: "release", Date: "2025-12-01"}, {Title: "Release", Date: "2025-12-08"}, {Title: "Party"}}
+	kept := withoutEvent(events, "Release", "2025-12-01")
+	if len(kept) != 2 || kept[0].Date != "2025-12-08" || kept[1].Title != "Party" {
+		t.Errorf("Expected only the matching event to be dropped, got %+v", kept)
+	}
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+const defaultExpertMaxResults = 3
+
+// handleExpertCommand handles the /expert <topic> command
+func (l *Listener) handleExpertCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling expert command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	topic := strings.TrimSpace(strings.Join(args, " "))
+	if topic == "" {
+		l.sendCommandError(ctx, msg, translate(lang, "expert.usage"))
+		return
+	}
+
+	limit := l.config.App.Commands.ExpertMaxResults
+	if limit <= 0 {
+		limit = defaultExpertMaxResults
+	}
+
+	experts, err := l.repo.FindTopicExperts(ctx, msg.Chat.ID, topic, limit)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to find topic experts",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.expert"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, l.formatExpertResponse(experts, topic, l.config.App.Commands.ExpertMentions, lang))
+}
+
+// formatExpertResponse lists ranked experts with their matching competency.
+// With mentions enabled, users with a username are mentioned so they get notified.
+func (l *Listener) formatExpertResponse(experts []*repo.UserExpertise, topic string, mention bool, lang string) string {
+	if len(experts) == 0 {
+		return translate(lang, "expert.empty", topic)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "expert.title", topic) + "\n\n")
+
+	for i, e := range experts {
+		name := l.formatUserDisplay(e.UserID, e.Username, e.FirstName, e.LastName, lang)
+		if mention && e.Username != nil && *e.Username != "" {
+			name = "@" + *e.Username
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s — %s (%d)\n", i+1, name, e.Competency, e.Score))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+func TestFormatExpertResponse(t *testing.T) {
+	l := &Listener{config: &config.Config{}}
+	alice := "alice"
+	experts := []*repo.UserExpertise{
+		{UserID: 1, Username: &alice, FirstName: "Alice", Competency: "Kubernetes", Score: 9},
+		{UserID: 2, FirstName: "Bob", Competency: "kubernetes networking", Score: 6},
+	}
+
+	listed := l.formatExpertResponse(experts, "kubernetes", false, LanguageRussian)
+	for _, want := range []string{"«kubernetes»", "1. alice (Alice) — Kubernetes (9)", "2. Bob — kubernetes networking (6)"} {
+		if !strings.Contains(listed, want) {
+			t.Errorf("Expected response to contain %q, got %q", want, listed)
+		}
+	}
+	if strings.Contains(listed, "@") {
+		t.Errorf("Expected no mentions when disabled, got %q", listed)
+	}
+
+	mentioned := l.formatExpertResponse(experts, "kubernetes", true, LanguageRussian)
+	if !strings.Contains(mentioned, "1. @alice — Kubernetes (9)") || !strings.Contains(mentioned, "2. Bob —") {
+		t.Errorf("Expected users with a username to be mentioned, got %q", mentioned)
+	}
+}
+
+func TestFormatExpertResponseNoMatch(t *testing.T) {
+	l := &Listener{config: &config.Config{}}
+
+	if got := l.formatExpertResponse(nil, "Rust", false, LanguageEnglish); got != "🎓 No experts on “Rust” yet." {
+		t.Errorf("Unexpected no-match response %q", got)
+	}
+}
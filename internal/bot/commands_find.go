@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+const (
+	defaultFindMaxResults = 5
+	// findSnippetRadius is the number of characters shown around a keyword match
+	findSnippetRadius = 80
+)
+
+// handleFindCommand handles the /find <keyword> command
+func (l *Listener) handleFindCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling find command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	keyword := strings.TrimSpace(strings.Join(args, " "))
+	if keyword == "" {
+		l.sendCommandError(ctx, msg, translate(lang, "find.usage"))
+		return
+	}
+
+	limit := l.config.App.Commands.FindMaxResults
+	if limit <= 0 {
+		limit = defaultFindMaxResults
+	}
+
+	summaries, err := l.repo.FindChatSummaries(ctx, msg.Chat.ID, keyword, limit)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to find chat summaries",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.find"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatFindResponse(summaries, keyword, lang))
+}
+
+// formatFindResponse lists matching topics and a text snippet for each found summary
+func formatFindResponse(summaries []*models.ChatSummary, keyword, lang string) string {
+	if len(summaries) == 0 {
+		return translate(lang, "find.empty", keyword)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "find.title", keyword) + "\n")
+
+	for _, summary := range summaries {
+		sb.WriteString("\n")
+		if summary.TopicID != nil {
+			sb.WriteString(translate(lang, "find.topic", *summary.TopicID) + "\n")
+		}
+		if topics := matchingTopics(summary.TopicsJSON, keyword); len(topics) > 0 {
+			sb.WriteString(translate(lang, "find.topics", strings.Join(topics, ", ")) + "\n")
+		}
+		if snippet := keywordSnippet(summary.Summary, keyword, findSnippetRadius); snippet != "" {
+			sb.WriteString(snippet + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// matchingTopics returns sorted topic names containing the keyword (case-insensitive)
+func matchingTopics(topics map[string]interface{}, keyword string) []string {
+	needle := strings.ToLower(keyword)
+
+	var matched []string
+	for name := range topics {
+		if strings.Contains(strings.ToLower(name), needle) {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+
+	return matched
+}
+
+// keywordSnippet returns the text around the first case-insensitive keyword match,
+// with ellipses where the text is cut, or "" if the keyword is absent
+func keywordSnippet(text, keyword string, radius int) string {
+	// Lowercase rune by rune so positions in lower match positions in runes
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	needle := []rune(keyword)
+	for i, r := range needle {
+		needle[i] = unicode.ToLower(r)
+	}
+
+	index := -1
+	for i := 0; i+len(needle) <= len(lower); i++ {
+		if string(lower[i:i+len(needle)]) == string(needle) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return ""
+	}
+
+	start := max(index-radius, 0)
+	end := min(index+len(needle)+radius, len(runes))
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+
+	return snippet
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestKeywordSnippet(t *testing.T) {
+	text := "Обсуждали релиз. Решили перенести Деплой на пятницу, чтобы успеть с тестами."
+
+	if got := keywordSnippet(text, "деплой", 10); got != "…перенести Деплой на пятниц…" {
+		t.Errorf("keywordSnippet() = %q", got)
+	}
+	if got := keywordSnippet(text, "релиз", 100); got != text {
+		t.Errorf("Expected whole text without ellipses, got %q", got)
+	}
+	if got := keywordSnippet(text, "кубернетес", 10); got != "" {
+		t.Errorf("Expected no snippet for missing keyword, got %q", got)
+	}
+}
+
+func TestFormatFindResponse(t *testing.T) {
+	topicID := int64(7)
+	summaries := []*models.ChatSummary{
+		{
+			TopicID:    &topicID,
+			Summary:    "Команда обсуждала деплой в Kubernetes.",
+			TopicsJSON: map[string]interface{}{"Kubernetes деплой": 0.8, "Отпуск": 0.2, "деплой бота": 0.5},
+		},
+	}
+
+	got := formatFindResponse(summaries, "Деплой", LanguageRussian)
+
+	for _, want := range []string{"«Деплой»", "Тема #7", "Темы: Kubernetes деплой, деплой бота", "Команда обсуждала деплой в Kubernetes."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected response to contain %q, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "Отпуск") {
+		t.Errorf("Expected non-matching topics to be omitted, got %q", got)
+	}
+
+	if got := formatFindResponse(nil, "деплой", LanguageRussian); !strings.Contains(got, "ничего не найдено") {
+		t.Errorf("Expected empty result message, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/mymmrac/telego"
+)
+
+// handleHelpCommand handles the /help command
+func (l *Listener) handleHelpCommand(ctx context.Context, msg *telego.Message, lang string) {
+	l.logger.InfoContext(ctx, "Handling help command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	// Commands disabled in this chat are left out; lookup errors list everything
+	disabled, err := l.repo.GetChatDisabledCommands(ctx, msg.Chat.ID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get disabled commands", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+	}
+
+	l.sendCommandResponse(ctx, msg, formatHelpResponse(commandRegistry(), disabled, l.config.App.App.MentionUsername, lang))
+}
+
+// formatHelpResponse lists registered commands with their descriptions and explains mentions
+func formatHelpResponse(commands []botCommand, disabled []string, mentionUsername, lang string) string {
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "help.title") + "\n\n")
+
+	for _, cmd := range commands {
+		if commandInList(cmd.name, disabled) {
+			continue
+		}
+		sb.WriteString(cmd.name + " — " + translate(lang, cmd.helpKey) + "\n")
+		if cmd.name == "/stats" {
+			sb.WriteString(translate(lang, "help.stats_args") + "\n")
+		}
+	}
+
+	if mentionUsername != "" {
+		sb.WriteString("\n" + translate(lang, "help.mention", mentionUsername))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatHelpResponseListsRegistry(t *testing.T) {
+	commands := commandRegistry()
+	response := formatHelpResponse(commands, nil, "@william_bot", LanguageRussian)
+
+	if !strings.HasPrefix(response, "📖 Доступные команды") {
+		t.Errorf("Expected Russian help header, got %q", response)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(response, cmd.name+" — ") {
+			t.Errorf("Expected %s in help output, got %q", cmd.name, response)
+		}
+	}
+	for _, arg := range []string{"top", "bottom", "msgs", "chars", "lastmsg"} {
+		if !strings.Contains(response, arg) {
+			t.Errorf("Expected stats argument %q in help output", arg)
+		}
+	}
+	if !strings.Contains(response, "@william_bot") {
+		t.Errorf("Expected mention behavior in help output, got %q", response)
+	}
+}
+
+func TestFormatHelpResponseSkipsDisabledCommands(t *testing.T) {
+	response := formatHelpResponse(commandRegistry(), []string{"find", "/events"}, "", LanguageEnglish)
+
+	if strings.Contains(response, "/find") || strings.Contains(response, "/events —") {
+		t.Errorf("Expected disabled commands to be omitted, got %q", response)
+	}
+	if !strings.Contains(response, "/stats — member statistics") {
+		t.Errorf("Expected English descriptions, got %q", response)
+	}
+	if strings.Contains(response, "💬") {
+		t.Errorf("Expected no mention line without a username, got %q", response)
+	}
+}
+
+func TestCommandRegistryHelpKeys(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, cmd := range commandRegistry() {
+		if seen[cmd.name] {
+			t.Errorf("Command %s registered twice", cmd.name)
+		}
+		seen[cmd.name] = true
+
+		if _, ok := messageCatalog[defaultLanguage][cmd.helpKey]; !ok {
+			t.Errorf("Missing help text %q for %s", cmd.helpKey, cmd.name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// handleRefreshProfileCommand handles the /refreshprofile <@username|user id> command (global admin only).
+// Replying to a user's message targets that user.
+func (l *Listener) handleRefreshProfileCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling refresh profile command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	if !l.isGlobalAdmin(msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.admin_only"))
+		return
+	}
+
+	userID, username := commandUserTarget(msg, args)
+	if userID == 0 && username == "" {
+		l.sendCommandError(ctx, msg, translate(lang, "refresh_profile.usage"))
+		return
+	}
+
+	if userID == 0 {
+		var err error
+		userID, err = l.repo.FindUserIDByUsername(ctx, msg.Chat.ID, username)
+		if errors.Is(err, repo.ErrUserNotFound) {
+			l.sendCommandError(ctx, msg, translate(lang, "error.user_not_found", "@"+username))
+			return
+		}
+		if err != nil {
+			l.logger.ErrorContext(ctx, "Failed to find user by username",
+				slog.Any("error", err),
+				slog.Int64("chat_id", msg.Chat.ID),
+			)
+			l.sendCommandError(ctx, msg, translate(lang, "error.refresh_profile"))
+			return
+		}
+	}
+
+	if err := publishRefreshProfileEvent(l.publisher, msg.Chat.ID, userID); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to publish refresh profile event",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("target_user_id", userID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.refresh_profile"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, translate(lang, "refresh_profile.queued"))
+}
+
+// commandUserTarget returns the user targeted by a command such as /refreshprofile or /rank: the
+// author of the replied message, a numeric user id or a username (without @) to look up
+func commandUserTarget(msg *telego.Message, args []string) (int64, string) {
+	if len(args) == 0 {
+		if reply := msg.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+			return reply.From.ID, ""
+		}
+		return 0, ""
+	}
+
+	if userID, err := strconv.ParseInt(args[0], 10, 64); err == nil && userID > 0 {
+		return userID, ""
+	}
+	return 0, strings.TrimPrefix(args[0], "@")
+}
+
+// publishRefreshProfileEvent publishes a refresh profile event for the chat user
+func publishRefreshProfileEvent(publisher message.Publisher, chatID, userID int64) error {
+	event := RefreshProfileEvent{
+		ChatID:    chatID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+	}
+
+	msgData, err := event.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh profile event: %w", err)
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), msgData)
+	return publisher.Publish("refresh_profile", msg)
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestCommandUserTarget(t *testing.T) {
+	reply := &telego.Message{ReplyToMessage: &telego.Message{From: &telego.User{ID: 7}}}
+	botReply := &telego.Message{ReplyToMessage: &telego.Message{From: &telego.User{ID: 8, IsBot: true}}}
+
+	tests := []struct {
+		name         string
+		msg          *telego.Message
+		args         []string
+		wantUserID   int64
+		wantUsername string
+	}{
+		{"reply", reply, nil, 7, ""},
+		{"reply to bot", botReply, nil, 0, ""},
+		{"no target", &telego.Message{}, nil, 0, ""},
+		{"username", reply, []string{"@ann"}, 0, "ann"},
+		{"user id", &telego.Message{}, []string{"12345"}, 12345, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, username := commandUserTarget(tt.msg, tt.args)
+			if userID != tt.wantUserID || username != tt.wantUsername {
+				t.Errorf("Expected (%d, %q), got (%d, %q)", tt.wantUserID, tt.wantUsername, userID, username)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// handleRankCommand handles the /rank [@username|user id] command: the user's position in the chat's
+// message count leaderboard. Without a target it reports the replied or invoking user.
+func (l *Listener) handleRankCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling rank command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	userID, username := commandUserTarget(msg, args)
+	if userID == 0 && username == "" {
+		userID = msg.From.ID
+	}
+
+	if userID == 0 {
+		var err error
+		userID, err = l.repo.FindUserIDByUsername(ctx, msg.Chat.ID, username)
+		if errors.Is(err, repo.ErrUserNotFound) {
+			l.sendCommandError(ctx, msg, translate(lang, "error.user_not_found", "@"+username))
+			return
+		}
+		if err != nil {
+			l.logger.ErrorContext(ctx, "Failed to find user by username",
+				slog.Any("error", err),
+				slog.Int64("chat_id", msg.Chat.ID),
+			)
+			l.sendCommandError(ctx, msg, translate(lang, "error.rank"))
+			return
+		}
+	}
+
+	rank, err := l.repo.GetUserMessageRank(ctx, msg.Chat.ID, userID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get user message rank",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("target_user_id", userID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.rank"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatRank(rank, rankTargetLabel(msg, args, lang), lang))
+}
+
+// rankTargetLabel names the /rank target: the given argument, or the replied or invoking user
+func rankTargetLabel(msg *telego.Message, args []string, lang string) string {
+	if len(args) > 0 {
+		return strings.TrimPrefix(args[0], "@")
+	}
+
+	user := msg.From
+	if reply := msg.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		user = reply.From
+	}
+	return userDisplay(user.ID, &user.Username, user.FirstName, &user.LastName, "", lang)
+}
+
+// formatRank formats a user's leaderboard position, e.g. "#7 of 42"
+func formatRank(rank *repo.UserMessageRank, label, lang string) string {
+	if rank.Rank == 0 {
+		return translate(lang, "rank.no_messages", label)
+	}
+	return translate(lang, "rank.position", label, rank.Rank, rank.Total, rank.MessageCount,
+		pluralWord(lang, "word.message", int64(rank.MessageCount)))
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+func TestFormatRank(t *testing.T) {
+	tests := []struct {
+		name string
+		rank repo.UserMessageRank
+		lang string
+		want string
+	}{
+		{"ranked", repo.UserMessageRank{Rank: 7, Total: 42, MessageCount: 21}, LanguageRussian, "🏅 ann: #7 из 42 (21 сообщение)"},
+		{"ranked en", repo.UserMessageRank{Rank: 1, Total: 3, MessageCount: 5}, LanguageEnglish, "🏅 ann: #1 of 3 (5 messages)"},
+		{"no messages", repo.UserMessageRank{Total: 42}, LanguageEnglish, "🏅 ann has no messages in this chat yet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRank(&tt.rank, "ann", tt.lang); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRankTargetLabel(t *testing.T) {
+	msg := &telego.Message{From: &telego.User{ID: 1, FirstName: "Ann", Username: "ann"}}
+	if got := rankTargetLabel(msg, nil, LanguageEnglish); got != "ann (Ann)" {
+		t.Errorf("Expected the invoking user, got %q", got)
+	}
+
+	msg.ReplyToMessage = &telego.Message{From: &telego.User{ID: 2, FirstName: "Bob"}}
+	if got := rankTargetLabel(msg, nil, LanguageEnglish); got != "Bob" {
+		t.Errorf("Expected the replied user, got %q", got)
+	}
+
+	if got := rankTargetLabel(msg, []string{"@carol"}, LanguageEnglish); got != "carol" {
+		t.Errorf("Expected the username argument, got %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+const (
+	defaultRecentRepliesLimit = 5
+	maxRecentRepliesLimit     = 50
+	// recentReplyPreviewLength is the number of characters shown per reply
+	recentReplyPreviewLength = 200
+)
+
+// handleRecentRepliesCommand handles the /recentreplies [N] command (admins and moderators only)
+func (l *Listener) handleRecentRepliesCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	topicID := l.getTopicID(msg)
+	l.logger.InfoContext(ctx, "Handling recent replies command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+		slog.Any("topic_id", topicID),
+	)
+
+	if !l.canModerate(ctx, msg.Chat.ID, msg.From.ID) {
+		l.sendCommandError(ctx, msg, translate(lang, "error.moderators_only"))
+		return
+	}
+
+	limit := l.config.App.Commands.RecentRepliesLimit
+	if limit <= 0 {
+		limit = defaultRecentRepliesLimit
+	}
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	limit = min(limit, maxRecentRepliesLimit)
+
+	messages, err := l.repo.GetRecentBotMessages(ctx, msg.Chat.ID, topicID, limit)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get recent bot replies",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.recent_replies"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatRecentRepliesResponse(messages, l.location(), lang))
+}
+
+// formatRecentRepliesResponse lists bot replies with their timestamps, newest first
+func formatRecentRepliesResponse(messages []*models.Message, loc *time.Location, lang string) string {
+	if len(messages) == 0 {
+		return translate(lang, "recent_replies.empty")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "recent_replies.title", len(messages)) + "\n")
+
+	for _, msg := range messages {
+		text := ""
+		if msg.Text != nil {
+			text = previewText(*msg.Text, recentReplyPreviewLength)
+		}
+		sb.WriteString(fmt.Sprintf("\n🕒 %s\n%s\n", msg.CreatedAt.In(loc).Format("02.01.2006 15:04"), text))
+	}
+
+	return truncateMessage(sb.String(), maxMessageLength)
+}
+
+// previewText collapses whitespace and cuts text to at most limit characters with an ellipsis
+func previewText(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
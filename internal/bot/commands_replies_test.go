@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestFormatRecentRepliesResponse(t *testing.T) {
+	if got := formatRecentRepliesResponse(nil, time.UTC, LanguageEnglish); got != translate(LanguageEnglish, "recent_replies.empty") {
+		t.Errorf("Expected empty message, got %q", got)
+	}
+
+	newest, older := "Созвон  в\nпятницу", strings.Repeat("а", 300)
+	messages := []*models.Message{
+		{IsBot: true, Text: &newest, CreatedAt: time.Date(2025, 3, 2, 18, 30, 0, 0, time.UTC)},
+		{IsBot: true, Text: &older, CreatedAt: time.Date(2025, 3, 1, 9, 5, 0, 0, time.UTC)},
+	}
+
+	response := formatRecentRepliesResponse(messages, time.UTC, LanguageEnglish)
+	for _, want := range []string{"Recent bot replies (2)", "02.03.2025 18:30\nСозвон в пятницу", "01.03.2025 09:05"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("Expected %q in response, got %q", want, response)
+		}
+	}
+	if strings.Index(response, "18:30") > strings.Index(response, "09:05") {
+		t.Errorf("Expected newest reply first, got %q", response)
+	}
+	if !strings.Contains(response, strings.Repeat("а", recentReplyPreviewLength-1)+"…") || strings.Contains(response, older) {
+		t.Errorf("Expected long reply to be cut to a preview, got %q", response)
+	}
+}
+
+func TestFormatRecentRepliesResponseTimezone(t *testing.T) {
+	text := "reply"
+	messages := []*models.Message{{IsBot: true, Text: &text, CreatedAt: time.Date(2025, 3, 2, 18, 30, 0, 0, time.UTC)}}
+
+	response := formatRecentRepliesResponse(messages, time.FixedZone("MSK", 3*60*60), LanguageEnglish)
+	if !strings.Contains(response, "02.03.2025 21:30") {
+		t.Errorf("Expected the reply time in the configured timezone, got %q", response)
+	}
+}
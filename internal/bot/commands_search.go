@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// searchHighlight strips the markers ts_headline puts around matched words, responses are plain text
+var searchHighlight = strings.NewReplacer("<b>", "", "</b>", "")
+
+// handleSearchCommand handles the /search <query> command: full-text search over stored messages
+func (l *Listener) handleSearchCommand(ctx context.Context, msg *telego.Message, args []string, lang string) {
+	l.logger.InfoContext(ctx, "Handling search command",
+		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("user_id", msg.From.ID),
+	)
+
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		l.sendCommandError(ctx, msg, translate(lang, "search.usage"))
+		return
+	}
+
+	limit := l.config.App.Commands.FindMaxResults
+	if limit <= 0 {
+		limit = defaultFindMaxResults
+	}
+
+	results, err := l.repo.SearchMessages(ctx, msg.Chat.ID, query, l.config.App.Search.Language, limit)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to search messages",
+			slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+		)
+		l.sendCommandError(ctx, msg, translate(lang, "error.find"))
+		return
+	}
+
+	l.sendCommandResponse(ctx, msg, formatSearchResponse(results, query, l.location(), lang))
+}
+
+// formatSearchResponse lists matching messages with their author, time and snippet, best match first
+func formatSearchResponse(results []*repo.MessageSearchResult, query string, loc *time.Location, lang string) string {
+	if len(results) == 0 {
+		return translate(lang, "search.empty", query)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "search.title", query) + "\n")
+
+	for _, result := range results {
+		msg := result.Message
+		author := msg.UserFirstName
+		if msg.UserLastName != nil {
+			author += " " + *msg.UserLastName
+		}
+		snippet := previewText(searchHighlight.Replace(result.Snippet), recentReplyPreviewLength)
+		sb.WriteString(fmt.Sprintf("\n👤 %s, %s\n%s\n", author, msg.CreatedAt.In(loc).Format("02.01.2006 15:04"), snippet))
+	}
+
+	return truncateMessage(sb.String(), maxMessageLength)
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestFormatSearchResponse(t *testing.T) {
+	lastName := "Smith"
+	results := []*repo.MessageSearchResult{
+		{
+			Message: &models.Message{UserFirstName: "Ann", UserLastName: &lastName, CreatedAt: time.Date(2025, 12, 5, 18, 30, 0, 0, time.UTC)},
+			Snippet: "В пятницу будет <b>созвон</b> по релизу",
+		},
+		{
+			Message: &models.Message{UserFirstName: "Bob", CreatedAt: time.Date(2025, 12, 4, 9, 0, 0, 0, time.UTC)},
+			Snippet: "<b>Созвон</b> перенесли",
+		},
+	}
+
+	got := formatSearchResponse(results, "созвоны", time.UTC, LanguageRussian)
+
+	for _, want := range []string{"«созвоны»", "👤 Ann Smith, 05.12.2025 18:30\nВ пятницу будет созвон по релизу", "👤 Bob, 04.12.2025 09:00\nСозвон перенесли"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected response to contain %q, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "<b>") {
+		t.Errorf("Expected highlight markers to be stripped, got %q", got)
+	}
+
+	if got := formatSearchResponse(nil, "созвоны", time.UTC, LanguageRussian); !strings.Contains(got, "не найдено") {
+		t.Errorf("Expected empty result message, got %q", got)
+	}
+}
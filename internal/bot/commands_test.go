@@ -0,0 +1,319 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+	williamcontext "github.com/xdefrag/william/internal/context"
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/internal/runtimestats"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestResolveCommandAlias(t *testing.T) {
+	aliases := map[string]string{
+		"/стата": "/stats",
+		"конфиг": "config",
+	}
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"/стата", "/stats"},
+		{"/конфиг", "/config"},
+		{"/stats", "/stats"},
+		{"/unknown", "/unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := resolveCommandAlias(tt.command, aliases); got != tt.expected {
+			t.Errorf("resolveCommandAlias(%q) = %q, expected %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Commands.Aliases = map[string]string{"/стата": "/stats"}
+	l := &Listener{config: cfg}
+
+	for text, expected := range map[string]bool{
+		"/stats top":        true,
+		"/Стата":            true,
+		"/unknown":          false,
+		"path /stats":       false,
+		"обычное сообщение": false,
+	} {
+		if got := l.isCommand(text); got != expected {
+			t.Errorf("isCommand(%q) = %v, expected %v", text, got, expected)
+		}
+	}
+}
+
+func TestRequestSummarizePublishesOneEvent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	messages, err := pubSub.Subscribe(ctx, "summarize")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	l := &Listener{
+		publisher: pubSub,
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	msg := &telego.Message{
+		Chat:            telego.Chat{ID: 42},
+		MessageThreadID: 7,
+	}
+
+	if err := l.requestSummarize(ctx, msg); err != nil {
+		t.Fatalf("requestSummarize returned error: %v", err)
+	}
+
+	select {
+	case received := <-messages:
+		received.Ack()
+		event, err := UnmarshalSummarizeEvent(received.Payload)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal summarize event: %v", err)
+		}
+		if event.ChatID != 42 || event.TopicID == nil || *event.TopicID != 7 {
+			t.Errorf("Unexpected summarize event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected summarize event to be published")
+	}
+
+	select {
+	case extra := <-messages:
+		extra.Ack()
+		t.Fatal("Expected exactly one summarize event")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIsModeratorRole(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		role     *models.UserRole
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"admin", &models.UserRole{Role: "admin"}, true},
+		{"moderator", &models.UserRole{Role: "moderator", ExpiresAt: &future}, true},
+		{"expired moderator", &models.UserRole{Role: "moderator", ExpiresAt: &past}, false},
+		{"member", &models.UserRole{Role: "member"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isModeratorRole(tt.role, now); got != tt.expected {
+			t.Errorf("%s: isModeratorRole = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatCountStatsResponse(t *testing.T) {
+	l := &Listener{}
+	username := "alice"
+	stats := []*repo.UserMessageStats{
+		{UserID: 1, Username: &username, FirstName: "Alice", MessageCount: 5},
+		{UserID: 2, FirstName: "Bob", MessageCount: 1},
+	}
+
+	got := l.formatCountStatsResponse(stats, "stats.questions_top", "word.question", LanguageRussian)
+	want := "📊 Чаще всех задают вопросы (топ-2)\n\n1. alice (Alice) — 5 вопросов\n2. Bob — 1 вопрос\n"
+	if got != want {
+		t.Errorf("formatCountStatsResponse() = %q, want %q", got, want)
+	}
+}
+
+func TestCommandInListPerChat(t *testing.T) {
+	disabledByChat := map[int64][]string{
+		1: {"/stats", "Summarize"},
+		2: nil,
+	}
+
+	if !commandInList("/stats", disabledByChat[1]) {
+		t.Error("Expected /stats to be disabled in chat 1")
+	}
+	if !commandInList("/summarize", disabledByChat[1]) {
+		t.Error("Expected /summarize to be disabled in chat 1 regardless of case and slash")
+	}
+	if commandInList("/config", disabledByChat[1]) {
+		t.Error("Expected /config to stay enabled in chat 1")
+	}
+	if commandInList("/stats", disabledByChat[2]) {
+		t.Error("Expected /stats to be enabled in chat 2")
+	}
+}
+
+func TestFormatTopTopicsResponse(t *testing.T) {
+	summary := &models.ChatSummary{
+		TopicsJSON: map[string]interface{}{
+			"go":      float64(12),
+			"rust":    3,
+			"k8s":     7.25,
+			"weather": float64(1),
+		},
+	}
+
+	got := formatTopTopicsResponse(williamcontext.TopTopics(summary.TopicsJSON, 3), LanguageRussian)
+	want := "🏷 Популярные темы (топ-3)\n\n1. go — 12\n2. k8s — 7.3\n3. rust — 3\n"
+	if got != want {
+		t.Errorf("formatTopTopicsResponse() = %q, want %q", got, want)
+	}
+
+	if got := formatTopTopicsResponse(nil, LanguageRussian); got != "Темы пока недоступны — сводка ещё не составлена." {
+		t.Errorf("Unexpected empty response: %q", got)
+	}
+}
+
+func TestFormatSummaryResponse(t *testing.T) {
+	if got := formatSummaryResponse(nil, LanguageEnglish); !strings.Contains(got, "not enough messages") {
+		t.Errorf("Expected not enough messages note, got %q", got)
+	}
+
+	summary := &models.ChatSummary{
+		Summary:        "Discussed the release",
+		TopicsJSON:     map[string]interface{}{"go": float64(5), "ci": float64(2)},
+		NextEventsJSON: []models.Event{{Date: "2025-12-31", Title: "Release"}},
+	}
+
+	got := formatSummaryResponse(summary, LanguageEnglish)
+	for _, want := range []string{"📝 Chat summary", "Discussed the release", "🏷 Topics: go, ci", "Release"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in summary response %q", want, got)
+		}
+	}
+
+	summary.Summary = strings.Repeat("😁 слово ", 1000)
+	got = formatSummaryResponse(summary, LanguageEnglish)
+	if n := len(utf16.Encode([]rune(got))); n > maxMessageLength {
+		t.Errorf("Expected summary to fit %d UTF-16 units, got %d", maxMessageLength, n)
+	}
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("Expected truncated summary to end with an ellipsis")
+	}
+}
+
+func TestFormatUserDisplayUnknownUser(t *testing.T) {
+	cfg := &config.Config{}
+	l := &Listener{config: cfg}
+	empty := ""
+
+	if got := l.formatUserDisplay(42, &empty, "", nil, LanguageRussian); got != "User 42" {
+		t.Errorf("Expected id fallback without label, got %q", got)
+	}
+	if got := l.formatUserDisplay(unknownUsersBucketID, nil, "", nil, LanguageRussian); got != "Неизвестные участники" {
+		t.Errorf("Expected default bucket label, got %q", got)
+	}
+
+	cfg.App.Stats.UnknownUserLabel = "Удалённый аккаунт"
+	if got := l.formatUserDisplay(42, nil, "", &empty, LanguageRussian); got != "Удалённый аккаунт" {
+		t.Errorf("Expected configured label, got %q", got)
+	}
+	if got := l.formatUserDisplay(43, nil, "Bob", nil, LanguageRussian); got != "Bob" {
+		t.Errorf("Expected name for known user, got %q", got)
+	}
+}
+
+func TestMergeUnknownMessageStats(t *testing.T) {
+	username := "alice"
+	stats := []*repo.UserMessageStats{
+		{UserID: 1, Username: &username, MessageCount: 5},
+		{UserID: 2, MessageCount: 4},
+		{UserID: 3, FirstName: "Bob", MessageCount: 3},
+		{UserID: 4, MessageCount: 2},
+	}
+
+	merged := mergeUnknownMessageStats(stats, false, 10)
+
+	if len(merged) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(merged))
+	}
+	if merged[0].UserID != unknownUsersBucketID || merged[0].MessageCount != 6 {
+		t.Errorf("Expected unknown bucket with 6 messages first, got %+v", merged[0])
+	}
+	if merged[1].UserID != 1 || merged[2].UserID != 3 {
+		t.Errorf("Expected known users to keep count order, got %d, %d", merged[1].UserID, merged[2].UserID)
+	}
+
+	l := &Listener{config: &config.Config{}}
+	l.config.App.Stats.UnknownUserLabel = "Удалённый аккаунт"
+	got := l.formatStatsResponse(merged, false, 10, LanguageRussian)
+	want := "📊 Самые активные участники (топ-3)\n\n1. Удалённый аккаунт — 6 сообщений\n2. alice — 5 сообщений\n3. Bob — 3 сообщения\n"
+	if got != want {
+		t.Errorf("formatStatsResponse() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeUnknownCharStatsAscending(t *testing.T) {
+	stats := []*repo.UserCharStats{
+		{UserID: 1, CharCount: 10},
+		{UserID: 2, FirstName: "Bob", CharCount: 20},
+		{UserID: 3, CharCount: 30},
+	}
+
+	merged := mergeUnknownCharStats(stats, true, 10)
+
+	if len(merged) != 2 || merged[0].UserID != 2 || merged[1].CharCount != 40 {
+		t.Errorf("Expected Bob then bucket of 40 chars, got %+v, %+v", merged[0], merged[len(merged)-1])
+	}
+}
+
+func TestMergeUnknownMessageStatsTrimsToLimit(t *testing.T) {
+	// All users are queried before merging, so the bucket sums every unknown user
+	stats := []*repo.UserMessageStats{
+		{UserID: 1, FirstName: "Ann", MessageCount: 9},
+		{UserID: 2, FirstName: "Bob", MessageCount: 8},
+		{UserID: 3, MessageCount: 7},
+		{UserID: 4, FirstName: "Eve", MessageCount: 6},
+		{UserID: 5, MessageCount: 5},
+		{UserID: 6, MessageCount: 4},
+	}
+
+	merged := mergeUnknownMessageStats(stats, false, 3)
+
+	if len(merged) != 3 {
+		t.Fatalf("Expected the merged list trimmed to 3 entries, got %d", len(merged))
+	}
+	if merged[0].UserID != unknownUsersBucketID || merged[0].MessageCount != 16 {
+		t.Errorf("Expected unknown bucket with 16 messages first, got %+v", merged[0])
+	}
+	if merged[1].UserID != 1 || merged[2].UserID != 2 {
+		t.Errorf("Expected the top known users after the bucket, got %d, %d", merged[1].UserID, merged[2].UserID)
+	}
+}
+
+func TestFormatUptimeResponse(t *testing.T) {
+	stats := runtimestats.New()
+	stats.IncMessages()
+	stats.IncOpenAICalls()
+
+	response := formatUptimeResponse(stats.Snapshot(), LanguageEnglish)
+
+	for _, want := range []string{"⏱ Bot status", "Messages processed: 1", "OpenAI calls: 1", "Goroutines: "} {
+		if !strings.Contains(response, want) {
+			t.Errorf("Expected %q in uptime response, got %q", want, response)
+		}
+	}
+}
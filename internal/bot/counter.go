@@ -0,0 +1,223 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// Counter modes
+const (
+	CounterModeDB     = "db"
+	CounterModeMemory = "memory"
+)
+
+// Midnight counter reset policies
+const (
+	CounterResetAll         = "all"
+	CounterResetOnlyGeneral = "only_general"
+	CounterResetNone        = "none"
+)
+
+// messageCounter tracks per chat/topic message counts used to trigger summarization
+type messageCounter interface {
+	Increment(ctx context.Context, chatID int64, topicID *int64) (int, error)
+	Reset(ctx context.Context, chatID int64, topicID *int64) error
+	ResetAll(ctx context.Context) error
+	ResetGeneral(ctx context.Context) error
+}
+
+// dbCounter stores counters directly in the message_counters table
+type dbCounter struct {
+	repo *repo.Repository
+}
+
+func (c *dbCounter) Increment(ctx context.Context, chatID int64, topicID *int64) (int, error) {
+	return c.repo.IncrementMessageCounter(ctx, chatID, topicID)
+}
+
+func (c *dbCounter) Reset(ctx context.Context, chatID int64, topicID *int64) error {
+	return c.repo.ResetMessageCounter(ctx, chatID, topicID)
+}
+
+func (c *dbCounter) ResetAll(ctx context.Context) error {
+	return c.repo.ResetAllMessageCounters(ctx)
+}
+
+func (c *dbCounter) ResetGeneral(ctx context.Context) error {
+	return c.repo.ResetGeneralMessageCounters(ctx)
+}
+
+// counterKey identifies a chat/topic counter in memory
+type counterKey struct {
+	chatID   int64
+	topicID  int64
+	hasTopic bool
+}
+
+func newCounterKey(chatID int64, topicID *int64) counterKey {
+	if topicID == nil {
+		return counterKey{chatID: chatID}
+	}
+	return counterKey{chatID: chatID, topicID: *topicID, hasTopic: true}
+}
+
+// general reports whether the key is a general topic counter (no topic or topic 0)
+func (k counterKey) general() bool {
+	return !k.hasTopic || k.topicID == 0
+}
+
+// counterStore persists in-memory counters
+type counterStore interface {
+	GetTopicMessageCounter(ctx context.Context, chatID int64, topicID *int64) (int, error)
+	SetMessageCounter(ctx context.Context, chatID int64, topicID *int64, count int) error
+	ResetAllMessageCounters(ctx context.Context) error
+	ResetGeneralMessageCounters(ctx context.Context) error
+}
+
+// memoryCounter keeps counters in memory and flushes changed ones to the database.
+// Each counter starts from its persisted count, so a restart doesn't lose flushed counts.
+// Counts not yet flushed are lost on crash.
+type memoryCounter struct {
+	repo counterStore
+
+	// flushMu serializes flushes with resets, so a flush can't write pre-reset counts
+	// back after the reset cleared them in the database
+	flushMu sync.Mutex
+
+	mu     sync.Mutex
+	counts map[counterKey]int
+	dirty  map[counterKey]struct{}
+	seeded map[counterKey]struct{} // Counters loaded from the database or reset since
+}
+
+func newMemoryCounter(repo counterStore) *memoryCounter {
+	return &memoryCounter{
+		repo:   repo,
+		counts: make(map[counterKey]int),
+		dirty:  make(map[counterKey]struct{}),
+		seeded: make(map[counterKey]struct{}),
+	}
+}
+
+// seed loads the persisted count of a counter on its first use
+func (c *memoryCounter) seed(ctx context.Context, key counterKey, topicID *int64) error {
+	c.mu.Lock()
+	_, seeded := c.seeded[key]
+	c.mu.Unlock()
+	if seeded {
+		return nil
+	}
+
+	count, err := c.repo.GetTopicMessageCounter(ctx, key.chatID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to load message counter for chat %d: %w", key.chatID, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, seeded := c.seeded[key]; !seeded {
+		c.counts[key] += count
+		c.seeded[key] = struct{}{}
+	}
+	return nil
+}
+
+func (c *memoryCounter) Increment(ctx context.Context, chatID int64, topicID *int64) (int, error) {
+	key := newCounterKey(chatID, topicID)
+	if err := c.seed(ctx, key, topicID); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[key]++
+	c.dirty[key] = struct{}{}
+	return c.counts[key], nil
+}
+
+func (c *memoryCounter) Reset(ctx context.Context, chatID int64, topicID *int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := newCounterKey(chatID, topicID)
+	c.counts[key] = 0
+	c.dirty[key] = struct{}{}
+	c.seeded[key] = struct{}{}
+	return nil
+}
+
+// ResetAll resets the counters in the database before dropping them from memory, so a counter
+// loaded in between can't bring back a pre-reset count
+func (c *memoryCounter) ResetAll(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	if err := c.repo.ResetAllMessageCounters(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.counts = make(map[counterKey]int)
+	c.dirty = make(map[counterKey]struct{})
+	c.seeded = make(map[counterKey]struct{})
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *memoryCounter) ResetGeneral(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	if err := c.repo.ResetGeneralMessageCounters(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	for key := range c.counts {
+		if key.general() {
+			delete(c.counts, key)
+			delete(c.dirty, key)
+			delete(c.seeded, key)
+		}
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Flush writes changed counters to the database. Counters that fail to write stay dirty
+// for the next flush.
+func (c *memoryCounter) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	pending := make(map[counterKey]int, len(c.dirty))
+	for key := range c.dirty {
+		pending[key] = c.counts[key]
+	}
+	c.dirty = make(map[counterKey]struct{})
+	c.mu.Unlock()
+
+	var errs []error
+	for key, count := range pending {
+		var topicID *int64
+		if key.hasTopic {
+			topicID = &key.topicID
+		}
+		if err := c.repo.SetMessageCounter(ctx, key.chatID, topicID, count); err != nil {
+			c.mu.Lock()
+			c.dirty[key] = struct{}{}
+			c.mu.Unlock()
+			errs = append(errs, fmt.Errorf("failed to flush message counter for chat %d: %w", key.chatID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
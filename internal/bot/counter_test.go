@@ -0,0 +1,290 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestMemoryCounterConcurrentIncrements(t *testing.T) {
+	ctx := context.Background()
+	counter := newMemoryCounter(&fakeCounterStore{})
+	topicID := int64(3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := counter.Increment(ctx, 1, &topicID); err != nil {
+				t.Errorf("Increment returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, _ := counter.Increment(ctx, 1, &topicID)
+	if count != 101 {
+		t.Errorf("Expected count 101, got %d", count)
+	}
+
+	// Counters are separate per topic
+	if count, _ := counter.Increment(ctx, 1, nil); count != 1 {
+		t.Errorf("Expected general topic count 1, got %d", count)
+	}
+}
+
+func TestCountMessageMemoryModeTriggersSummarization(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	messages, err := pubSub.Subscribe(ctx, "summarize")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.Limits.MaxMsgBuffer = 3
+	l := &Listener{
+		config:    cfg,
+		publisher: pubSub,
+		counter:   newMemoryCounter(&fakeCounterStore{}),
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	topicID := int64(0)
+	go func() {
+		for i := 0; i < 7; i++ {
+			l.countMessage(ctx, 42, &topicID, cfg.App.Limits.MaxMsgBuffer)
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-messages:
+			msg.Ack()
+		case <-ctx.Done():
+			t.Fatalf("Expected 2 summarize events, got %d", i)
+		}
+	}
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		t.Fatal("Expected exactly 2 summarize events for 7 messages")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// resetRecorder is a messageCounter recording which reset ran
+type resetRecorder struct {
+	messageCounter
+	resets []string
+}
+
+func (c *resetRecorder) ResetAll(ctx context.Context) error {
+	c.resets = append(c.resets, CounterResetAll)
+	return nil
+}
+
+func (c *resetRecorder) ResetGeneral(ctx context.Context) error {
+	c.resets = append(c.resets, CounterResetOnlyGeneral)
+	return nil
+}
+
+func TestResetCountersForAllChatsPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{CounterResetAll, []string{CounterResetAll}},
+		{CounterResetOnlyGeneral, []string{CounterResetOnlyGeneral}},
+		{CounterResetNone, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.App.Limits.MidnightCounterReset = tt.policy
+			counter := &resetRecorder{}
+			l := &Listener{config: cfg, counter: counter, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			l.ResetCountersForAllChats()
+
+			if !reflect.DeepEqual(counter.resets, tt.want) {
+				t.Errorf("Expected resets %v, got %v", tt.want, counter.resets)
+			}
+		})
+	}
+}
+
+func TestMemoryCounterKeyGeneral(t *testing.T) {
+	general, topic := int64(0), int64(5)
+	if !newCounterKey(1, nil).general() || !newCounterKey(1, &general).general() {
+		t.Error("Expected no topic and topic 0 to be general counters")
+	}
+	if newCounterKey(1, &topic).general() {
+		t.Error("Expected a forum topic counter not to be general")
+	}
+}
+
+// fakeCounterStore records counter writes and serves persisted counts from counts;
+// SetMessageCounter fails for chats in fail and blocks on block when set
+type fakeCounterStore struct {
+	mu      sync.Mutex
+	counts  map[int64]int
+	loads   int
+	fail    map[int64]bool
+	block   chan struct{}
+	entered chan struct{}
+	ops     []string
+}
+
+func (s *fakeCounterStore) GetTopicMessageCounter(ctx context.Context, chatID int64, topicID *int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return s.counts[chatID], nil
+}
+
+func (s *fakeCounterStore) record(op string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, op)
+}
+
+func (s *fakeCounterStore) SetMessageCounter(ctx context.Context, chatID int64, topicID *int64, count int) error {
+	if s.entered != nil {
+		s.entered <- struct{}{}
+	}
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	failed := s.fail[chatID]
+	s.mu.Unlock()
+	if failed {
+		return errors.New("connection reset")
+	}
+	s.record(fmt.Sprintf("set %d=%d", chatID, count))
+	return nil
+}
+
+func (s *fakeCounterStore) ResetAllMessageCounters(ctx context.Context) error {
+	s.record("reset all")
+	return nil
+}
+
+func (s *fakeCounterStore) ResetGeneralMessageCounters(ctx context.Context) error {
+	s.record("reset general")
+	return nil
+}
+
+func TestMemoryCounterFlushRequeuesFailedCounters(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeCounterStore{fail: map[int64]bool{1: true, 2: true}}
+	counter := newMemoryCounter(store)
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if _, err := counter.Increment(ctx, chatID, nil); err != nil {
+			t.Fatalf("Increment returned error: %v", err)
+		}
+	}
+
+	if err := counter.Flush(ctx); err == nil {
+		t.Fatal("Expected flush error for failed counters")
+	}
+	if !reflect.DeepEqual(store.ops, []string{"set 3=1"}) {
+		t.Fatalf("Expected only chat 3 flushed, got %v", store.ops)
+	}
+
+	// Every failed counter is retried, not just the first one
+	store.fail = nil
+	store.ops = nil
+	if err := counter.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	sort.Strings(store.ops)
+	if !reflect.DeepEqual(store.ops, []string{"set 1=1", "set 2=1"}) {
+		t.Errorf("Expected failed counters flushed on retry, got %v", store.ops)
+	}
+}
+
+func TestMemoryCounterResetWaitsForFlush(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeCounterStore{block: make(chan struct{}), entered: make(chan struct{}, 1)}
+	counter := newMemoryCounter(store)
+	if _, err := counter.Increment(ctx, 1, nil); err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- counter.Flush(ctx) }()
+	<-store.entered
+
+	reset := make(chan error, 1)
+	go func() { reset <- counter.ResetAll(ctx) }()
+
+	select {
+	case <-reset:
+		t.Fatal("Expected reset to wait for the in-flight flush")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(store.block)
+	if err := <-flushed; err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if err := <-reset; err != nil {
+		t.Fatalf("ResetAll returned error: %v", err)
+	}
+
+	// The pre-reset count is written before the reset, never after it
+	if !reflect.DeepEqual(store.ops, []string{"set 1=1", "reset all"}) {
+		t.Errorf("Expected flush before reset, got %v", store.ops)
+	}
+}
+
+func TestMemoryCounterSeedsPersistedCounts(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeCounterStore{counts: map[int64]int{1: 5}}
+	counter := newMemoryCounter(store)
+
+	// A restarted counter continues from the flushed count instead of overwriting it
+	for want := 6; want <= 7; want++ {
+		if count, err := counter.Increment(ctx, 1, nil); err != nil || count != want {
+			t.Fatalf("Expected count %d, got %d (err %v)", want, count, err)
+		}
+	}
+	if err := counter.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if store.loads != 1 || !reflect.DeepEqual(store.ops, []string{"set 1=7"}) {
+		t.Errorf("Expected the count loaded once and flushed on top of it, got %d loads and %v", store.loads, store.ops)
+	}
+
+	// A reset counter is not reloaded
+	if err := counter.Reset(ctx, 1, nil); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if count, _ := counter.Increment(ctx, 1, nil); count != 1 {
+		t.Errorf("Expected count 1 after reset, got %d", count)
+	}
+	if store.loads != 1 {
+		t.Errorf("Expected no reload after reset, got %d loads", store.loads)
+	}
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// FailedTopic receives events whose handler failed all attempts
+const FailedTopic = "failed"
+
+// Metadata keys set on dead-lettered events
+const (
+	failedTopicKey    = "failed_topic"
+	failedHandlerKey  = "failed_handler"
+	failedErrorKey    = "failed_error"
+	failedAttemptsKey = "failed_attempts"
+)
+
+// DeadLetter returns router middleware that retries a failing handler up to maxAttempts times,
+// waiting retryDelay between attempts, then publishes the event with its error to FailedTopic
+// and acks it. Events of FailedTopic itself are passed through to avoid loops.
+func DeadLetter(publisher message.Publisher, maxAttempts int, retryDelay time.Duration) message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			topic := message.SubscribeTopicFromCtx(msg.Context())
+			if topic == FailedTopic {
+				return h(msg)
+			}
+
+			var err error
+			for attempt := 1; attempt <= maxAttempts; attempt++ {
+				var produced []*message.Message
+				produced, err = h(msg)
+				if err == nil {
+					return produced, nil
+				}
+				if attempt == maxAttempts {
+					break
+				}
+
+				select {
+				case <-msg.Context().Done():
+					return nil, err
+				case <-time.After(retryDelay):
+				}
+			}
+
+			failed := msg.Copy()
+			failed.Metadata.Set(failedTopicKey, topic)
+			failed.Metadata.Set(failedHandlerKey, message.HandlerNameFromCtx(msg.Context()))
+			failed.Metadata.Set(failedErrorKey, err.Error())
+			failed.Metadata.Set(failedAttemptsKey, strconv.Itoa(maxAttempts))
+			if pubErr := publisher.Publish(FailedTopic, failed); pubErr != nil {
+				return nil, fmt.Errorf("failed to publish dead-lettered event: %w (handler error: %w)", pubErr, err)
+			}
+
+			return nil, nil
+		}
+	}
+}
+
+// HandleFailedEvent records a dead-lettered event so operators can inspect and replay it
+func (h *Handlers) HandleFailedEvent(msg *message.Message) error {
+	ctx := context.Background()
+
+	event := failedEvent(msg)
+	h.logger.ErrorContext(ctx, "Event handler failed all attempts",
+		slog.String("topic", event.Topic),
+		slog.String("handler", event.Handler),
+		slog.String("error", event.Error),
+		slog.Int("attempts", event.Attempts),
+	)
+
+	if err := h.repo.SaveFailedEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to save failed event: %w", err)
+	}
+
+	return nil
+}
+
+// failedEvent builds the failed event record of a dead-lettered message
+func failedEvent(msg *message.Message) *models.FailedEvent {
+	attempts, _ := strconv.Atoi(msg.Metadata.Get(failedAttemptsKey))
+	return &models.FailedEvent{
+		Topic:    msg.Metadata.Get(failedTopicKey),
+		Handler:  msg.Metadata.Get(failedHandlerKey),
+		Payload:  string(msg.Payload),
+		Error:    msg.Metadata.Get(failedErrorKey),
+		Attempts: attempts,
+	}
+}
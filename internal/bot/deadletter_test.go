@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+)
+
+func TestDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	var attempts int
+	failing := func(msg *message.Message) ([]*message.Message, error) {
+		attempts++
+		return nil, errors.New("boom")
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{"chat_id":42}`))
+	if _, err := DeadLetter(pubSub, 3, time.Millisecond)(failing)(msg); err != nil {
+		t.Fatalf("Expected a dead-lettered event to be acked, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+	failed, err := pubSub.Subscribe(ctx, FailedTopic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	select {
+	case got := <-failed:
+		got.Ack()
+		event := failedEvent(got)
+		if event.Payload != `{"chat_id":42}` || event.Error != "boom" || event.Attempts != 3 {
+			t.Errorf("Unexpected failed event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the event on the failed topic")
+	}
+}
+
+func TestDeadLetterRecoversOnRetry(t *testing.T) {
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	var attempts int
+	flaky := func(msg *message.Message) ([]*message.Message, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("temporary")
+		}
+		return nil, nil
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	if _, err := DeadLetter(pubSub, 3, time.Millisecond)(flaky)(msg); err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+	failed, err := pubSub.Subscribe(context.Background(), FailedTopic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	select {
+	case got := <-failed:
+		t.Errorf("Expected no dead-lettered event, got %s", got.Payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
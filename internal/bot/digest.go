@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// digestActiveUsersLimit is the number of most active users listed in the weekly digest
+const digestActiveUsersLimit = 3
+
+// HandleDigestEvent posts the weekly digest to the general chat of every opted-in chat
+// that was active during the week
+func (h *Handlers) HandleDigestEvent(msg *message.Message) error {
+	ctx := context.Background()
+
+	event, err := UnmarshalDigestEvent(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal digest event: %w", err)
+	}
+
+	h.logger.InfoContext(ctx, "Processing digest event",
+		slog.Time("triggered_at", event.TriggeredAt),
+	)
+
+	optedIn, err := h.repo.GetWeeklyDigestChats(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get weekly digest chats: %w", err)
+	}
+	if len(optedIn) == 0 {
+		return nil
+	}
+
+	active, err := h.repo.GetActiveChatIDs(ctx, event.TriggeredAt.AddDate(0, 0, -7))
+	if err != nil {
+		return fmt.Errorf("failed to get active chats: %w", err)
+	}
+	activeChats := make(map[int64]bool, len(active))
+	for _, chatID := range active {
+		activeChats[chatID] = true
+	}
+
+	weekStart := event.TriggeredAt.AddDate(0, 0, -7)
+	for _, chatID := range optedIn {
+		if !activeChats[chatID] {
+			continue
+		}
+		if err := h.postDigest(ctx, chatID, weekStart); err != nil {
+			// Log error but continue with other chats
+			h.logger.ErrorContext(ctx, "Failed to post weekly digest", slog.Any("error", err),
+				slog.Int64("chat_id", chatID),
+			)
+		}
+	}
+
+	h.logger.InfoContext(ctx, "Weekly digest completed")
+
+	return nil
+}
+
+// postDigest posts the digest of the week since weekStart: the general chat summary if it
+// was updated during the week and the users with the most messages during the week
+func (h *Handlers) postDigest(ctx context.Context, chatID int64, weekStart time.Time) error {
+	summary, err := h.repo.GetLatestChatSummaryByTopic(ctx, chatID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get chat summary: %w", err)
+	}
+	if summary != nil && (strings.TrimSpace(summary.Summary) == "" || summary.UpdatedAt.Before(weekStart)) {
+		summary = nil // Nothing new to recap
+	}
+
+	stats, err := h.repo.GetUserMessageStatsSince(ctx, chatID, weekStart, digestActiveUsersLimit)
+	if err != nil {
+		return fmt.Errorf("failed to get user message stats: %w", err)
+	}
+	if summary == nil && len(stats) == 0 {
+		return nil
+	}
+
+	settings, err := h.repo.GetChatSettings(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat settings: %w", err)
+	}
+	lang := settings.UILanguage
+	if !isSupportedLanguage(lang) {
+		lang = defaultLanguage
+	}
+
+	// No thread id: the digest goes to the general chat
+	_, err = h.sender.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID: telego.ChatID{ID: chatID},
+		Text:   formatDigest(summary, stats, h.config.App.Stats.UnknownUserLabel, lang),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+
+	return nil
+}
+
+// formatDigest formats the weekly digest, truncated to fit a single Telegram message.
+// summary is nil when the chat summary was not updated during the week.
+func formatDigest(summary *models.ChatSummary, stats []*repo.UserMessageStats, unknownLabel, lang string) string {
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "digest.title"))
+	if summary != nil {
+		sb.WriteString("\n\n" + strings.TrimSpace(summary.Summary))
+	}
+
+	if len(stats) > 0 {
+		sb.WriteString("\n\n" + translate(lang, "digest.active_users") + "\n")
+		for i, s := range stats {
+			name := userDisplay(s.UserID, s.Username, s.FirstName, s.LastName, unknownLabel, lang)
+			word := pluralWord(lang, "word.message", int64(s.MessageCount))
+			sb.WriteString(fmt.Sprintf("%d. %s — %d %s\n", i+1, name, s.MessageCount, word))
+		}
+	}
+
+	return truncateMessage(strings.TrimRight(sb.String(), "\n"), maxMessageLength)
+}
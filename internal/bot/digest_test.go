@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestFormatDigest(t *testing.T) {
+	username := "ann"
+	summary := &models.ChatSummary{Summary: "  Обсуждали релиз  "}
+	stats := []*repo.UserMessageStats{
+		{UserID: 1, Username: &username, FirstName: "Ann", MessageCount: 21},
+		{UserID: 2, FirstName: "Bob", MessageCount: 5},
+	}
+
+	got := formatDigest(summary, stats, "", LanguageRussian)
+	want := "🗓 Итоги недели\n\nОбсуждали релиз\n\n🏆 Самые активные участники\n1. ann (Ann) — 21 сообщение\n2. Bob — 5 сообщений"
+	if got != want {
+		t.Errorf("formatDigest() = %q, want %q", got, want)
+	}
+
+	if got := formatDigest(summary, nil, "", LanguageEnglish); strings.Contains(got, "Most active") {
+		t.Errorf("Expected no active users section without stats, got %q", got)
+	}
+
+	// A summary not updated during the week is left out; the week's active users remain
+	got = formatDigest(nil, stats[1:], "", LanguageEnglish)
+	if want := "🗓 Weekly digest\n\n🏆 Most active members\n1. Bob — 5 messages"; got != want {
+		t.Errorf("formatDigest() without summary = %q, want %q", got, want)
+	}
+}
@@ -70,6 +70,24 @@ func UnmarshalMidnightEvent(data []byte) (MidnightEvent, error) {
 	return event, err
 }
 
+// ScheduledEvent is published by a configured scheduler job to its topic
+type ScheduledEvent struct {
+	Job         string    `json:"job"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// Marshal serializes the event to JSON
+func (e ScheduledEvent) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UnmarshalScheduledEvent deserializes JSON to ScheduledEvent
+func UnmarshalScheduledEvent(data []byte) (ScheduledEvent, error) {
+	var event ScheduledEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
 // WelcomeEvent represents an event when new members join a chat
 type WelcomeEvent struct {
 	ChatID    int64     `json:"chat_id"`
@@ -92,3 +110,40 @@ func UnmarshalWelcomeEvent(data []byte) (WelcomeEvent, error) {
 	err := json.Unmarshal(data, &event)
 	return event, err
 }
+
+// RefreshProfileEvent represents a request to rebuild a single user's profile
+type RefreshProfileEvent struct {
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Marshal serializes the event to JSON
+func (e RefreshProfileEvent) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UnmarshalRefreshProfileEvent deserializes JSON to RefreshProfileEvent
+func UnmarshalRefreshProfileEvent(data []byte) (RefreshProfileEvent, error) {
+	var event RefreshProfileEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
+
+// DigestEvent triggers the weekly digest post. It is published by the weekly_digest
+// scheduler job, whose ScheduledEvent payload it reads.
+type DigestEvent struct {
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// Marshal serializes the event to JSON
+func (e DigestEvent) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// UnmarshalDigestEvent deserializes JSON to DigestEvent
+func UnmarshalDigestEvent(data []byte) (DigestEvent, error) {
+	var event DigestEvent
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
@@ -7,12 +7,15 @@ import (
 	"log/slog"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
 	"github.com/xdefrag/william/internal/config"
 	williamcontext "github.com/xdefrag/william/internal/context"
 	"github.com/xdefrag/william/internal/gpt"
+	"github.com/xdefrag/william/internal/reactions"
 	"github.com/xdefrag/william/internal/repo"
 	"github.com/xdefrag/william/pkg/models"
 )
@@ -23,7 +26,10 @@ type Handlers struct {
 	repo       *repo.Repository
 	builder    *williamcontext.Builder
 	summarizer *williamcontext.Summarizer
-	gptClient  *gpt.Client
+	gptClient  gpt.Completer
+	sender     *Sender
+	publisher  message.Publisher
+	refreshes  *refreshDebounce
 	config     *config.Config
 	logger     *slog.Logger
 }
@@ -34,7 +40,9 @@ func NewHandlers(
 	repo *repo.Repository,
 	builder *williamcontext.Builder,
 	summarizer *williamcontext.Summarizer,
-	gptClient *gpt.Client,
+	gptClient gpt.Completer,
+	sender *Sender,
+	publisher message.Publisher,
 	config *config.Config,
 	logger *slog.Logger,
 ) *Handlers {
@@ -44,6 +52,9 @@ func NewHandlers(
 		builder:    builder,
 		summarizer: summarizer,
 		gptClient:  gptClient,
+		sender:     sender,
+		publisher:  publisher,
+		refreshes:  newRefreshDebounce(summaryRefreshInterval),
 		config:     config,
 		logger:     logger.WithGroup("bot.handlers"),
 	}
@@ -63,8 +74,15 @@ func (h *Handlers) HandleSummarizeEvent(msg *message.Message) error {
 		slog.Any("topic_id", event.TopicID),
 	)
 
+	limits, err := h.repo.GetChatLimits(ctx, event.ChatID, defaultChatLimits(h.config))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get chat limits, using defaults", slog.Any("error", err),
+			slog.Int64("chat_id", event.ChatID),
+		)
+	}
+
 	// Perform topic-specific summarization
-	if err := h.summarizer.SummarizeChatTopic(ctx, event.ChatID, event.TopicID, h.config.App.Limits.SummarizeMaxMessages); err != nil {
+	if err := h.summarizer.SummarizeChatTopic(ctx, event.ChatID, event.TopicID, limits.SummarizeMaxMessages); err != nil {
 		h.logger.ErrorContext(ctx, "Failed to summarize chat topic", slog.Any("error", err),
 			slog.Int64("chat_id", event.ChatID),
 			slog.Any("topic_id", event.TopicID),
@@ -77,9 +95,72 @@ func (h *Handlers) HandleSummarizeEvent(msg *message.Message) error {
 		slog.Any("topic_id", event.TopicID),
 	)
 
+	if h.config.App.App.AutoRepinSummary {
+		h.repinSummary(ctx, event.ChatID, event.TopicID)
+	}
+
 	return nil
 }
 
+// HandleRefreshProfileEvent rebuilds a single user's profile
+func (h *Handlers) HandleRefreshProfileEvent(msg *message.Message) error {
+	ctx := context.Background()
+
+	event, err := UnmarshalRefreshProfileEvent(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal refresh profile event: %w", err)
+	}
+
+	h.logger.InfoContext(ctx, "Processing refresh profile event",
+		slog.Int64("chat_id", event.ChatID),
+		slog.Int64("user_id", event.UserID),
+	)
+
+	if err := h.summarizer.RefreshUserProfile(ctx, event.ChatID, event.UserID, h.config.App.Commands.RefreshProfileMessages); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to refresh user profile", slog.Any("error", err),
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("user_id", event.UserID),
+		)
+		return fmt.Errorf("failed to refresh user profile: %w", err)
+	}
+
+	h.logger.InfoContext(ctx, "User profile refreshed successfully",
+		slog.Int64("chat_id", event.ChatID),
+		slog.Int64("user_id", event.UserID),
+	)
+
+	return nil
+}
+
+// repinSummary reposts and pins the fresh summary if one is already pinned for this chat topic
+func (h *Handlers) repinSummary(ctx context.Context, chatID int64, topicID *int64) {
+	settings, err := h.repo.GetChatSettings(ctx, chatID)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get chat settings for repin", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return
+	}
+	if settings.PinnedSummaryMessageID == nil || !sameTopic(settings.PinnedSummaryTopicID, topicID) {
+		return
+	}
+
+	if err := postPinnedSummary(ctx, h.repo, h.sender, h.bot, chatID, topicID); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to repin summary", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+			slog.Any("topic_id", topicID),
+		)
+	}
+}
+
+// sameTopic compares optional topic ids
+func sameTopic(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // HandleMentionEvent handles mention events
 func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 	ctx := context.Background()
@@ -96,6 +177,18 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 		slog.Any("event_topic_id", event.TopicID),
 	)
 
+	// Answer capability questions with the configured intro instead of asking GPT
+	if reply, ok := h.introReply(event); ok {
+		h.logger.InfoContext(ctx, "Intro trigger matched, sending intro text",
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("user_id", event.UserID),
+		)
+		if err := h.sendResponse(ctx, event.ChatID, event.TopicID, h.replyTarget(event), reply); err != nil {
+			return fmt.Errorf("failed to send intro response: %w", err)
+		}
+		return nil
+	}
+
 	// Build context for the mention
 	params := williamcontext.BuildContextForResponseParams{
 		ChatID:   event.ChatID,
@@ -113,8 +206,25 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 		return fmt.Errorf("failed to build context: %w", err)
 	}
 
-	// Extract user query (remove @william mention)
-	userQuery := h.extractUserQuery(event.Text)
+	// Avoid answering from nothing in chats without any context yet
+	if reply, ok := h.noContextReply(contextReq, event.MessageID); ok {
+		h.logger.InfoContext(ctx, "No context available for mention, sending canned reply",
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("user_id", event.UserID),
+		)
+		if err := h.sendResponse(ctx, event.ChatID, event.TopicID, h.replyTarget(event), reply); err != nil {
+			return fmt.Errorf("failed to send no-context response: %w", err)
+		}
+		return nil
+	}
+
+	// Refresh stale summary in background; the response still uses the current one
+	if contextReq.SummaryStale {
+		h.scheduleSummaryRefresh(ctx, event.ChatID, event.TopicID, contextReq.SummaryAge)
+	}
+
+	// Extract user query (remove @william mention and the private reply keyword)
+	userQuery := h.extractUserQuery(h.stripPrivateReplyKeyword(event.Text))
 	contextReq.UserQuery = userQuery
 
 	// Add reply context if present
@@ -123,7 +233,17 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 	contextReq.BotName = h.config.App.App.Name
 
 	// Generate response
-	mentionResponse, err := h.gptClient.GenerateResponse(ctx, *contextReq)
+	mentionResponse, stream, err := h.generateResponse(ctx, event, *contextReq)
+	if err != nil {
+		stream.discard(ctx)
+	}
+	if errors.Is(err, gpt.ErrEmptyCompletion) {
+		h.logger.WarnContext(ctx, "OpenAI returned empty content for mention",
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("user_id", event.UserID),
+		)
+		return h.sendEmptyCompletionFallback(ctx, event)
+	}
 	if err != nil {
 		h.logger.ErrorContext(ctx, "Failed to generate response", slog.Any("error", err),
 			slog.Int64("chat_id", event.ChatID),
@@ -131,28 +251,30 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 		)
 		return fmt.Errorf("failed to generate response: %w", err)
 	}
+	h.saveTokenUsage(ctx, event.ChatID, mentionResponse.Usage)
+
+	reaction := reactions.Emoji(mentionResponse.Reaction)
+	if reaction == "" && mentionResponse.Reaction != "" {
+		h.logger.WarnContext(ctx, "Dropping unsupported reaction",
+			slog.Int64("chat_id", event.ChatID),
+			slog.String("reaction", mentionResponse.Reaction),
+		)
+	}
 
 	h.logger.InfoContext(ctx, "GPT response received",
 		slog.Int64("chat_id", event.ChatID),
 		slog.Bool("should_reply", mentionResponse.ShouldReply),
-		slog.String("reaction", mentionResponse.Reaction),
+		slog.String("reaction", reaction),
 	)
 
-	// Set reaction if provided
-	if mentionResponse.Reaction != "" {
-		if err := h.setReaction(ctx, event.ChatID, event.MessageID, mentionResponse.Reaction); err != nil {
-			h.logger.WarnContext(ctx, "Failed to set reaction", slog.Any("error", err),
-				slog.Int64("chat_id", event.ChatID),
-				slog.Int64("message_id", event.MessageID),
-				slog.String("reaction", mentionResponse.Reaction),
-			)
-			// Don't return error, continue with response if needed
-		}
+	// Set reaction if provided and the chat allows reactions
+	if reaction != "" {
+		h.react(ctx, event, reaction, h.reactionsEnabled(ctx, event.ChatID))
 	}
 
 	// Send text response only if should_reply is true
 	if mentionResponse.ShouldReply && mentionResponse.Response != "" {
-		if err := h.sendResponse(ctx, event.ChatID, event.TopicID, event.MessageID, mentionResponse.Response); err != nil {
+		if err := h.deliverResponse(ctx, event, stream, mentionResponse.Response); err != nil {
 			h.logger.ErrorContext(ctx, "Failed to send response", slog.Any("error", err),
 				slog.Int64("chat_id", event.ChatID),
 				slog.Int64("user_id", event.UserID),
@@ -166,6 +288,7 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 			slog.String("user_name", event.UserName),
 		)
 	} else {
+		stream.discard(ctx)
 		h.logger.InfoContext(ctx, "No text response needed",
 			slog.Int64("chat_id", event.ChatID),
 			slog.Int64("user_id", event.UserID),
@@ -176,6 +299,202 @@ func (h *Handlers) HandleMentionEvent(msg *message.Message) error {
 	return nil
 }
 
+// generateResponse asks the model for a mention response. With stream_responses enabled and a
+// streaming backend, partial text is shown in the chat as it arrives; the returned stream holds
+// that message (nil without streaming). A failed stream falls back to a regular completion.
+func (h *Handlers) generateResponse(ctx context.Context, event MentionEvent, req gpt.ContextRequest) (*gpt.MentionResponse, *responseStream, error) {
+	streamer, ok := h.gptClient.(gpt.StreamingCompleter)
+	if !ok || !h.config.App.App.StreamResponses || h.privateReplyRequested(event.Text) {
+		resp, err := h.gptClient.GenerateResponse(ctx, req)
+		return resp, nil, err
+	}
+
+	stream := h.newResponseStream(event)
+	resp, err := streamer.GenerateResponseStream(ctx, req, func(text string) {
+		stream.update(ctx, text)
+	})
+	if err != nil && !errors.Is(err, gpt.ErrEmptyCompletion) {
+		h.logger.WarnContext(ctx, "Streaming response failed, falling back to a regular completion", slog.Any("error", err),
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("user_id", event.UserID),
+		)
+		resp, err = h.gptClient.GenerateResponse(ctx, req)
+	}
+
+	return resp, stream, err
+}
+
+// deliverResponse sends the final response text, completing the streamed message if there is one
+func (h *Handlers) deliverResponse(ctx context.Context, event MentionEvent, stream *responseStream, response string) error {
+	if stream == nil {
+		if h.privateReplyRequested(event.Text) {
+			err := h.sendPrivateResponse(ctx, event.UserID, response)
+			if err == nil {
+				h.logger.InfoContext(ctx, "Response sent to private chat",
+					slog.Int64("chat_id", event.ChatID),
+					slog.Int64("user_id", event.UserID),
+				)
+				return nil
+			}
+			if !isPrivateChatUnavailable(err) {
+				return fmt.Errorf("failed to send private response: %w", err)
+			}
+			h.logger.WarnContext(ctx, "User has not started the bot, answering in the group", slog.Any("error", err),
+				slog.Int64("chat_id", event.ChatID),
+				slog.Int64("user_id", event.UserID),
+			)
+		}
+		return h.sendResponse(ctx, event.ChatID, event.TopicID, h.replyTarget(event), response)
+	}
+
+	sentMessage, topicID, err := stream.finish(ctx, response)
+	if err != nil {
+		return err
+	}
+
+	if err := h.saveBotMessage(ctx, sentMessage, topicID, response); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to save bot message to database", slog.Any("error", err),
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int("message_id", sentMessage.MessageID),
+		)
+	}
+
+	return nil
+}
+
+// privateReplyRequested reports whether the mention asks for the response in a private chat
+func (h *Handlers) privateReplyRequested(text string) bool {
+	appCfg := h.config.App.App
+	if !appCfg.PrivateReplyEnabled || appCfg.PrivateReplyKeyword == "" {
+		return false
+	}
+
+	for _, word := range strings.Fields(text) {
+		if strings.EqualFold(word, appCfg.PrivateReplyKeyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripPrivateReplyKeyword removes the private reply keyword from the mention text
+func (h *Handlers) stripPrivateReplyKeyword(text string) string {
+	if !h.privateReplyRequested(text) {
+		return text
+	}
+
+	words := strings.Fields(text)
+	kept := words[:0]
+	for _, word := range words {
+		if !strings.EqualFold(word, h.config.App.App.PrivateReplyKeyword) {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// sendPrivateResponse sends the response to the user's private chat with the bot. It is not
+// saved to the chat history since it is not part of the group conversation.
+func (h *Handlers) sendPrivateResponse(ctx context.Context, userID int64, response string) error {
+	_, err := h.sender.SendMessage(ctx, &telego.SendMessageParams{
+		ChatID: telego.ChatID{ID: userID},
+		Text:   response,
+	})
+	return err
+}
+
+// isPrivateChatUnavailable reports whether the bot can't write to the user, e.g. because
+// the user has never started the bot or has blocked it
+func isPrivateChatUnavailable(err error) bool {
+	var apiErr *ta.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode == 403 || strings.Contains(apiErr.Description, "chat not found")
+}
+
+// introReply returns the intro text if the mention query matches a configured trigger phrase
+func (h *Handlers) introReply(event MentionEvent) (string, bool) {
+	appCfg := h.config.App.App
+	if appCfg.IntroText == "" {
+		return "", false
+	}
+
+	query := normalizePhrase(strings.ReplaceAll(event.Text, appCfg.MentionUsername, ""))
+	for _, trigger := range appCfg.IntroTriggers {
+		if normalized := normalizePhrase(trigger); normalized != "" && normalized == query {
+			replacer := strings.NewReplacer(
+				"{first_name}", event.UserName,
+				"{bot_name}", appCfg.Name,
+				"{bot_username}", appCfg.MentionUsername,
+			)
+			return replacer.Replace(appCfg.IntroText), true
+		}
+	}
+
+	return "", false
+}
+
+// normalizePhrase lowercases text, drops punctuation and collapses whitespace
+func normalizePhrase(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// noContextReply returns the canned reply if context is required but the request has none.
+// The mention itself is stored before it is handled, so it doesn't count as context.
+func (h *Handlers) noContextReply(req *gpt.ContextRequest, mentionMessageID int64) (string, bool) {
+	if !h.config.App.App.RequireContext || req.ChatSummary != nil {
+		return "", false
+	}
+	for _, msg := range req.RecentMessages {
+		if msg.TelegramMsgID != mentionMessageID {
+			return "", false
+		}
+	}
+	return h.config.App.App.NoContextResponse, true
+}
+
+// scheduleSummaryRefresh publishes a summarize event for a chat topic with a stale summary,
+// at most once per summaryRefreshInterval so mentions in a busy chat don't queue a run each
+func (h *Handlers) scheduleSummaryRefresh(ctx context.Context, chatID int64, topicID *int64, age time.Duration) {
+	if !h.refreshes.Allow(chatID, topicID, time.Now()) {
+		return
+	}
+
+	h.logger.InfoContext(ctx, "Chat summary is stale, scheduling refresh",
+		slog.Int64("chat_id", chatID),
+		slog.Any("topic_id", topicID),
+		slog.Duration("summary_age", age),
+	)
+
+	if err := publishSummarizeEvent(h.publisher, chatID, topicID); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to publish summary refresh event", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+			slog.Any("topic_id", topicID),
+		)
+	}
+}
+
+// sendEmptyCompletionFallback replies with the configured fallback when GPT returned nothing
+func (h *Handlers) sendEmptyCompletionFallback(ctx context.Context, event MentionEvent) error {
+	fallback := h.config.App.App.EmptyCompletionResponse
+	if fallback == "" {
+		return nil
+	}
+
+	if err := h.sendResponse(ctx, event.ChatID, event.TopicID, h.replyTarget(event), fallback); err != nil {
+		return fmt.Errorf("failed to send fallback response: %w", err)
+	}
+
+	return nil
+}
+
+// handledCommandRetentionDays is how long handled commands are remembered to ignore redeliveries
+const handledCommandRetentionDays = 2
+
 // HandleMidnightEvent handles midnight summarization events
 func (h *Handlers) HandleMidnightEvent(msg *message.Message) error {
 	ctx := context.Background()
@@ -198,14 +517,55 @@ func (h *Handlers) HandleMidnightEvent(msg *message.Message) error {
 
 	h.logger.InfoContext(ctx, "Midnight summarization completed")
 
+	// Apply retention to persisted raw completions
+	if days := h.config.App.Log.RawCompletionRetentionDays; days > 0 {
+		deleted, err := h.repo.DeleteRawCompletionsBefore(ctx, event.TriggeredAt.AddDate(0, 0, -days))
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to delete old raw completions", slog.Any("error", err))
+		} else {
+			h.logger.InfoContext(ctx, "Deleted old raw completions", slog.Int64("count", deleted))
+		}
+	}
+
+	// Telegram keeps undelivered updates for a day, older handled commands can't be redelivered
+	deleted, err := h.repo.DeleteHandledCommandsBefore(ctx, event.TriggeredAt.AddDate(0, 0, -handledCommandRetentionDays))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to delete handled commands", slog.Any("error", err))
+	} else {
+		h.logger.InfoContext(ctx, "Deleted handled commands", slog.Int64("count", deleted))
+	}
+
+	// Prune old messages only after summarization so their content is kept in summaries
+	if days := h.config.App.Retention.MessageDays; days > 0 {
+		deleted, err := h.repo.DeleteMessagesOlderThan(ctx, event.TriggeredAt.AddDate(0, 0, -days))
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to delete old messages", slog.Any("error", err))
+		} else {
+			h.logger.InfoContext(ctx, "Deleted old messages", slog.Int64("count", deleted), slog.Int("retention_days", days))
+		}
+	}
+
 	return nil
 }
 
-// extractUserQuery removes @william mention from the text
+// saveTokenUsage records the tokens of a mention response completion
+func (h *Handlers) saveTokenUsage(ctx context.Context, chatID int64, usage gpt.Usage) {
+	williamcontext.SaveTokenUsage(ctx, h.repo, h.config.App.Usage.Prices, h.logger, chatID, usage)
+}
+
+// truncatedQueryNote tells the model that the user query was cut to the length limit
+const truncatedQueryNote = "[query truncated]"
+
+// extractUserQuery removes all bot mentions from the text, keeping mentions of other users.
+// Queries longer than limits.max_user_query_chars are truncated with a note.
 func (h *Handlers) extractUserQuery(text string) string {
-	// Remove bot mention
-	query := strings.ReplaceAll(text, h.config.App.App.MentionUsername, "")
-	query = strings.TrimSpace(query)
+	query := stripMentions(text, botMentionNames(h.config))
+
+	if maxChars := h.config.App.Limits.MaxUserQueryChars; maxChars > 0 {
+		if runes := []rune(query); len(runes) > maxChars {
+			query = strings.TrimSpace(string(runes[:maxChars])) + "… " + truncatedQueryNote
+		}
+	}
 
 	// If query is empty, provide default response
 	if query == "" {
@@ -217,6 +577,56 @@ func (h *Handlers) extractUserQuery(text string) string {
 
 // sendResponse sends response message to chat and saves it to database
 func (h *Handlers) sendResponse(ctx context.Context, chatID int64, topicID *int64, replyToMessageID int64, response string) error {
+	sentMessage, topicID, err := h.sendResponseMessage(ctx, chatID, topicID, replyToMessageID, response)
+	if err != nil {
+		return err
+	}
+
+	// Save bot message to database after successful sending
+	if err := h.saveBotMessage(ctx, sentMessage, topicID, response); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to save bot message to database", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+			slog.Int("message_id", sentMessage.MessageID),
+		)
+		// Don't return error here as the message was already sent successfully
+	}
+
+	return nil
+}
+
+// Reply styles of bot responses (app.reply_style)
+const (
+	ReplyStyleQuote  = "quote"
+	ReplyStylePlain  = "plain"
+	ReplyStyleThread = "thread"
+)
+
+// replyTarget returns the message a mention response refers to: the mention itself, or with
+// the thread style the message the user replied to, so the answer joins that reply thread
+func (h *Handlers) replyTarget(event MentionEvent) int64 {
+	if h.config.App.App.ReplyStyle == ReplyStyleThread && event.ReplyToMessageID != nil {
+		return *event.ReplyToMessageID
+	}
+	return event.MessageID
+}
+
+// responseReplyParameters returns how a response refers to the message it answers: quote replies
+// to it, plain posts without a reference and thread replies even if the message is gone
+func responseReplyParameters(style string, replyToMessageID int64) *telego.ReplyParameters {
+	if replyToMessageID <= 0 || style == ReplyStylePlain {
+		return nil
+	}
+
+	params := &telego.ReplyParameters{MessageID: int(replyToMessageID)}
+	if style == ReplyStyleThread {
+		params.AllowSendingWithoutReply = true
+	}
+	return params
+}
+
+// sendResponseMessage sends response message to the chat topic, falling back to the general chat
+// if the topic is gone. Returns the sent message and the topic it was sent to.
+func (h *Handlers) sendResponseMessage(ctx context.Context, chatID int64, topicID *int64, replyToMessageID int64, response string) (*telego.Message, *int64, error) {
 	h.logger.InfoContext(ctx, "Sending response",
 		slog.Int64("chat_id", chatID),
 		slog.Any("topic_id", topicID),
@@ -256,13 +666,9 @@ func (h *Handlers) sendResponse(ctx context.Context, chatID int64, topicID *int6
 		}
 	}
 
-	if replyToMessageID > 0 {
-		params.ReplyParameters = &telego.ReplyParameters{
-			MessageID: int(replyToMessageID),
-		}
-	}
+	params.ReplyParameters = responseReplyParameters(h.config.App.App.ReplyStyle, replyToMessageID)
 
-	sentMessage, err := h.bot.SendMessage(ctx, params)
+	sentMessage, err := h.sender.SendMessage(ctx, params)
 
 	// If topic message failed with "message thread not found", try sending to general chat
 	if err != nil && topicID != nil {
@@ -275,40 +681,26 @@ func (h *Handlers) sendResponse(ctx context.Context, chatID int64, topicID *int6
 
 			// Retry without topic
 			fallbackParams := &telego.SendMessageParams{
-				ChatID: telego.ChatID{ID: chatID},
-				Text:   response,
+				ChatID:          telego.ChatID{ID: chatID},
+				Text:            response,
+				ReplyParameters: responseReplyParameters(h.config.App.App.ReplyStyle, replyToMessageID),
 			}
 
-			if replyToMessageID > 0 {
-				fallbackParams.ReplyParameters = &telego.ReplyParameters{
-					MessageID: int(replyToMessageID),
-				}
-			}
-
-			sentMessage, err = h.bot.SendMessage(ctx, fallbackParams)
+			sentMessage, err = h.sender.SendMessage(ctx, fallbackParams)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 
 			// Update topicID to nil for database storage since we fell back to general chat
 			topicID = nil
 		} else {
-			return err
+			return nil, nil, err
 		}
 	} else if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	// Save bot message to database after successful sending
-	if err := h.saveBotMessage(ctx, sentMessage, topicID, response); err != nil {
-		h.logger.ErrorContext(ctx, "Failed to save bot message to database", slog.Any("error", err),
-			slog.Int64("chat_id", chatID),
-			slog.Int("message_id", sentMessage.MessageID),
-		)
-		// Don't return error here as the message was already sent successfully
-	}
-
-	return nil
+	return sentMessage, topicID, nil
 }
 
 // saveBotMessage saves bot message to database
@@ -335,23 +727,69 @@ func (h *Handlers) saveBotMessage(ctx context.Context, sentMessage *telego.Messa
 		UserLastName:  nil, // Bots typically don't have last names
 		Username:      botUsername,
 		Text:          &responseText,
-		CreatedAt:     time.Now(),
+		CreatedAt:     messageTime(sentMessage.Date),
+	}
+
+	if sentMessage.ReplyToMessage != nil {
+		replyToID := int64(sentMessage.ReplyToMessage.MessageID)
+		botMessage.ReplyToMsgID = &replyToID
 	}
 
-	return h.repo.SaveMessage(ctx, botMessage)
+	_, err = h.repo.SaveMessage(ctx, botMessage)
+	return err
+}
+
+// reactionsEnabled reports whether reactions are enabled in the chat; if the setting can't be
+// read reactions stay enabled
+func (h *Handlers) reactionsEnabled(ctx context.Context, chatID int64) bool {
+	enabled, err := h.repo.GetChatReactionsEnabled(ctx, chatID)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Failed to get chat reactions setting", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return true
+	}
+	return enabled
+}
+
+// react sets the reaction on the mention unless reactions are disabled in the chat.
+// A failed reaction is only logged so the text response is still sent.
+func (h *Handlers) react(ctx context.Context, event MentionEvent, reaction string, enabled bool) {
+	if !enabled {
+		h.logger.DebugContext(ctx, "Reactions are disabled in chat, skipping reaction",
+			slog.Int64("chat_id", event.ChatID),
+			slog.String("reaction", reaction),
+		)
+		return
+	}
+
+	if err := h.setReaction(ctx, event.ChatID, event.MessageID, reaction); err != nil {
+		h.logger.WarnContext(ctx, "Failed to set reaction", slog.Any("error", err),
+			slog.Int64("chat_id", event.ChatID),
+			slog.Int64("message_id", event.MessageID),
+			slog.String("reaction", reaction),
+		)
+	}
 }
 
 // setReaction sets an emoji reaction on a message
 func (h *Handlers) setReaction(ctx context.Context, chatID int64, messageID int64, emoji string) error {
-	return h.bot.SetMessageReaction(ctx, &telego.SetMessageReactionParams{
-		ChatID:    telego.ChatID{ID: chatID},
-		MessageID: int(messageID),
-		Reaction: []telego.ReactionType{
-			&telego.ReactionTypeEmoji{
-				Type:  "emoji",
-				Emoji: emoji,
+	return setReaction(ctx, h.sender, chatID, messageID, emoji)
+}
+
+// setReaction sets an emoji reaction on a message through the chat's send queue
+func setReaction(ctx context.Context, sender *Sender, chatID int64, messageID int64, emoji string) error {
+	return sender.Do(ctx, chatID, func(ctx context.Context) error {
+		return sender.bot.SetMessageReaction(ctx, &telego.SetMessageReactionParams{
+			ChatID:    telego.ChatID{ID: chatID},
+			MessageID: int(messageID),
+			Reaction: []telego.ReactionType{
+				&telego.ReactionTypeEmoji{
+					Type:  "emoji",
+					Emoji: emoji,
+				},
 			},
-		},
+		})
 	})
 }
 
@@ -386,6 +824,29 @@ func (h *Handlers) HandleWelcomeEvent(msg *message.Message) error {
 		return fmt.Errorf("failed to get welcome message: %w", err)
 	}
 
+	if !welcomeMsg.Enabled {
+		h.logger.DebugContext(ctx, "Welcome message disabled for chat",
+			slog.Int64("chat_id", event.ChatID),
+			slog.Any("topic_id", event.TopicID),
+		)
+		return nil
+	}
+
+	cooldown := h.config.App.Limits.WelcomeCooldownMinutes
+	if cooldown > 0 {
+		welcome, err := h.repo.MarkUserWelcomed(ctx, event.ChatID, event.UserID, time.Duration(cooldown)*time.Minute)
+		if err != nil {
+			return fmt.Errorf("failed to check welcome cooldown: %w", err)
+		}
+		if !welcome {
+			h.logger.InfoContext(ctx, "User was welcomed recently, skipping welcome message",
+				slog.Int64("chat_id", event.ChatID),
+				slog.Int64("user_id", event.UserID),
+			)
+			return nil
+		}
+	}
+
 	// Format welcome message with user info
 	formattedMessage := h.formatWelcomeMessage(welcomeMsg.Message, event)
 
@@ -395,6 +856,15 @@ func (h *Handlers) HandleWelcomeEvent(msg *message.Message) error {
 			slog.Int64("chat_id", event.ChatID),
 			slog.Int64("user_id", event.UserID),
 		)
+		// Forget the welcome so a retry or the next join is not skipped by the cooldown
+		if cooldown > 0 {
+			if err := h.repo.ClearUserWelcomed(ctx, event.ChatID, event.UserID); err != nil {
+				h.logger.ErrorContext(ctx, "Failed to clear user welcome", slog.Any("error", err),
+					slog.Int64("chat_id", event.ChatID),
+					slog.Int64("user_id", event.UserID),
+				)
+			}
+		}
 		return fmt.Errorf("failed to send welcome message: %w", err)
 	}
 
@@ -411,7 +881,8 @@ func (h *Handlers) HandleWelcomeEvent(msg *message.Message) error {
 func (h *Handlers) formatWelcomeMessage(template string, event WelcomeEvent) string {
 	result := template
 
-	// Replace {first_name} placeholder
+	// Replace {name} and {first_name} placeholders
+	result = strings.ReplaceAll(result, "{name}", event.FirstName)
 	result = strings.ReplaceAll(result, "{first_name}", event.FirstName)
 
 	// Replace {last_name} placeholder
@@ -450,6 +921,19 @@ func (h *Handlers) sendWelcomeMessage(ctx context.Context, chatID int64, topicID
 		params.MessageThreadID = int(*topicID)
 	}
 
-	_, err := h.bot.SendMessage(ctx, params)
+	_, err := h.sender.SendMessage(ctx, params)
+
+	// If the topic is gone, greet in the general chat instead
+	if err != nil && params.MessageThreadID > 0 && strings.Contains(err.Error(), "message thread not found") {
+		h.logger.WarnContext(ctx, "Topic not found, sending welcome message to general chat",
+			slog.Int64("chat_id", chatID),
+			slog.Any("topic_id", topicID),
+			slog.String("error", err.Error()),
+		)
+
+		params.MessageThreadID = 0
+		_, err = h.sender.SendMessage(ctx, params)
+	}
+
 	return err
 }
@@ -0,0 +1,309 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/gpt"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestScheduleSummaryRefreshPublishesSummarizeEvent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	messages, err := pubSub.Subscribe(ctx, "summarize")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	h := &Handlers{
+		publisher: pubSub,
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	topicID := int64(7)
+	h.scheduleSummaryRefresh(ctx, 42, &topicID, 30*time.Hour)
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		event, err := UnmarshalSummarizeEvent(msg.Payload)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal summarize event: %v", err)
+		}
+		if event.ChatID != 42 || event.TopicID == nil || *event.TopicID != 7 {
+			t.Errorf("Unexpected summarize event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected summarize event to be published")
+	}
+}
+
+func TestScheduleSummaryRefreshDebounced(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	messages, err := pubSub.Subscribe(ctx, "summarize")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	h := &Handlers{
+		publisher: pubSub,
+		refreshes: newRefreshDebounce(summaryRefreshInterval),
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// Every mention in a busy chat sees the stale summary; only one refresh is queued
+	topicID := int64(7)
+	for i := 0; i < 5; i++ {
+		h.scheduleSummaryRefresh(ctx, 42, &topicID, 30*time.Hour)
+	}
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+	case <-ctx.Done():
+		t.Fatal("Expected summarize event to be published")
+	}
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		t.Error("Expected a single summarize event per topic")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNoContextReply(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.NoContextResponse = "not enough context"
+	h := &Handlers{config: cfg}
+
+	const mentionID = 55
+	empty := &gpt.ContextRequest{}
+	if _, ok := h.noContextReply(empty, mentionID); ok {
+		t.Error("Expected no canned reply when context is not required")
+	}
+
+	cfg.App.App.RequireContext = true
+	reply, ok := h.noContextReply(empty, mentionID)
+	if !ok || reply != "not enough context" {
+		t.Errorf("Expected canned reply for empty context, got %q, %v", reply, ok)
+	}
+
+	// The listener stores the mention before publishing it, so it is always among recent messages
+	text := "@william_bot что тут обсуждают?"
+	onlyMention := &gpt.ContextRequest{RecentMessages: []*models.Message{{ID: 1, TelegramMsgID: mentionID, Text: &text}}}
+	if reply, ok := h.noContextReply(onlyMention, mentionID); !ok || reply != "not enough context" {
+		t.Errorf("Expected canned reply when the stored mention is the only message, got %q, %v", reply, ok)
+	}
+
+	withMessages := &gpt.ContextRequest{RecentMessages: []*models.Message{{ID: 1, TelegramMsgID: 54}, {ID: 2, TelegramMsgID: mentionID}}}
+	if _, ok := h.noContextReply(withMessages, mentionID); ok {
+		t.Error("Expected no canned reply when recent messages exist")
+	}
+
+	withSummary := &gpt.ContextRequest{ChatSummary: &models.ChatSummary{Summary: "s"}}
+	if _, ok := h.noContextReply(withSummary, mentionID); ok {
+		t.Error("Expected no canned reply when a chat summary exists")
+	}
+}
+
+func TestIntroReply(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.Name = "William"
+	cfg.App.App.MentionUsername = "@william_bot"
+	cfg.App.App.IntroTriggers = []string{"кто ты", "What can you do"}
+	cfg.App.App.IntroText = "{first_name}, I am {bot_name}, mention {bot_username}"
+	h := &Handlers{config: cfg}
+
+	reply, ok := h.introReply(MentionEvent{UserName: "Alice", Text: "@william_bot Кто ты?"})
+	if !ok {
+		t.Fatal("Expected intro trigger to match")
+	}
+	if reply != "Alice, I am William, mention @william_bot" {
+		t.Errorf("Unexpected intro reply: %q", reply)
+	}
+
+	if _, ok := h.introReply(MentionEvent{Text: "what  can you do!! @william_bot"}); !ok {
+		t.Error("Expected trigger to match regardless of case, punctuation and spacing")
+	}
+
+	// Other queries go to GPT as usual
+	if _, ok := h.introReply(MentionEvent{Text: "@william_bot кто ты по гороскопу?"}); ok {
+		t.Error("Expected longer query not to match intro trigger")
+	}
+
+	cfg.App.App.IntroText = ""
+	if _, ok := h.introReply(MentionEvent{Text: "@william_bot кто ты"}); ok {
+		t.Error("Expected intro to be disabled without intro text")
+	}
+}
+
+func TestFormatWelcomeMessage(t *testing.T) {
+	h := &Handlers{}
+	event := WelcomeEvent{UserID: 7, FirstName: "Анна", LastName: "Ким", Username: "anna"}
+
+	got := h.formatWelcomeMessage("Привет, {name}! {username}, {full_name}", event)
+	if got != "Привет, Анна! @anna, Анна Ким" {
+		t.Errorf("Unexpected welcome message %q", got)
+	}
+}
+
+func TestExtractUserQueryTruncatesLongQuery(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.MentionUsername = "@william_bot"
+	cfg.App.Limits.MaxUserQueryChars = 10
+	h := &Handlers{config: cfg}
+
+	query := h.extractUserQuery("@william_bot " + strings.Repeat("я", 50))
+	if want := strings.Repeat("я", 10) + "… " + truncatedQueryNote; query != want {
+		t.Errorf("Expected truncated query %q, got %q", want, query)
+	}
+
+	if query := h.extractUserQuery("@william_bot short"); query != "short" {
+		t.Errorf("Expected query within limit to be kept, got %q", query)
+	}
+}
+
+func TestExtractUserQueryStripsAliases(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.MentionUsername = "@william_bot"
+	cfg.App.App.MentionAliases = []string{"@old_william_bot"}
+	h := &Handlers{config: cfg}
+
+	if query := h.extractUserQuery("@OLD_William_Bot что нового у @William_bot"); query != "что нового у" {
+		t.Errorf("Expected aliases stripped regardless of case, got %q", query)
+	}
+}
+
+func TestResponseReplyStyles(t *testing.T) {
+	quoted := int64(7)
+	event := MentionEvent{MessageID: 42, ReplyToMessageID: &quoted}
+
+	tests := []struct {
+		style     string
+		messageID int
+		allowGone bool
+		noReply   bool
+	}{
+		{style: ReplyStyleQuote, messageID: 42},
+		{style: ReplyStylePlain, noReply: true},
+		{style: ReplyStyleThread, messageID: 7, allowGone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.App.App.ReplyStyle = tt.style
+			h := &Handlers{config: cfg}
+
+			params := responseReplyParameters(tt.style, h.replyTarget(event))
+			if tt.noReply {
+				if params != nil {
+					t.Fatalf("Expected no reply parameters, got %+v", params)
+				}
+				return
+			}
+			if params == nil {
+				t.Fatal("Expected reply parameters")
+			}
+			if params.MessageID != tt.messageID {
+				t.Errorf("Expected reply to message %d, got %d", tt.messageID, params.MessageID)
+			}
+			if params.AllowSendingWithoutReply != tt.allowGone {
+				t.Errorf("Expected AllowSendingWithoutReply %v, got %v", tt.allowGone, params.AllowSendingWithoutReply)
+			}
+		})
+	}
+
+	if params := responseReplyParameters(ReplyStyleQuote, 0); params != nil {
+		t.Errorf("Expected no reply parameters without a message, got %+v", params)
+	}
+}
+
+func TestDeliverResponseRoutesToPrivateChat(t *testing.T) {
+	fake := &fakeCaller{responses: []*ta.Response{
+		{Ok: true, Result: []byte(`{"message_id":1,"date":0,"chat":{"id":42,"type":"private"}}`)},
+		{Ok: false, Error: &ta.Error{ErrorCode: 403, Description: "Forbidden: bot can't initiate conversation with a user"}},
+	}}
+	tg, err := telego.NewBot("123456:"+strings.Repeat("a", 35), telego.WithAPICaller(fake), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.App.PrivateReplyEnabled = true
+	cfg.App.App.PrivateReplyKeyword = "#private"
+	h := &Handlers{
+		config: cfg,
+		sender: NewSender(tg, 0),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	event := MentionEvent{ChatID: -100, UserID: 42, MessageID: 7, Text: "@william_bot #PRIVATE what is my salary?"}
+	if err := h.deliverResponse(context.Background(), event, nil, "secret"); err != nil {
+		t.Fatalf("deliverResponse returned error: %v", err)
+	}
+	if len(fake.bodies) != 1 || !strings.Contains(fake.bodies[0], `"chat_id":42`) || strings.Contains(fake.bodies[0], "reply_parameters") {
+		t.Errorf("Expected a single message to the private chat, got %v", fake.bodies)
+	}
+
+	// A user who hasn't started the bot is unavailable, so the response goes to the group
+	err = h.sendPrivateResponse(context.Background(), 42, "secret")
+	if !isPrivateChatUnavailable(err) {
+		t.Errorf("Expected private chat to be unavailable, got %v", err)
+	}
+
+	if got := h.stripPrivateReplyKeyword(event.Text); got != "@william_bot what is my salary?" {
+		t.Errorf("Expected keyword to be stripped, got %q", got)
+	}
+
+	cfg.App.App.PrivateReplyEnabled = false
+	if h.privateReplyRequested(event.Text) {
+		t.Error("Expected private replies to be off when disabled")
+	}
+}
+
+func TestReactSkipsDisabledChats(t *testing.T) {
+	fake := &fakeCaller{}
+	tg, err := telego.NewBot("123456:"+strings.Repeat("a", 35), telego.WithAPICaller(fake), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	h := &Handlers{
+		bot:    tg,
+		config: &config.Config{},
+		sender: NewSender(tg, 0),
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	event := MentionEvent{ChatID: -100, MessageID: 7}
+
+	h.react(context.Background(), event, "👍", false)
+	if fake.calls != 0 {
+		t.Fatalf("Expected no SetMessageReaction call in a reactions-disabled chat, got %v", fake.bodies)
+	}
+
+	h.react(context.Background(), event, "👍", true)
+	if fake.calls != 1 || !strings.Contains(fake.bodies[0], `"reaction"`) {
+		t.Errorf("Expected a single SetMessageReaction call, got %v", fake.bodies)
+	}
+}
@@ -0,0 +1,299 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// UI languages for command responses
+const (
+	LanguageRussian = "ru"
+	LanguageEnglish = "en"
+
+	defaultLanguage = LanguageRussian
+)
+
+// messageCatalog holds command response texts per language. Plural words are stored as
+// "one|few|many" forms. Missing keys fall back to the default language.
+var messageCatalog = map[string]map[string]string{
+	LanguageRussian: {
+		"error.admin_only":       "Команда доступна только администратору бота",
+		"error.moderators_only":  "Команда доступна только администраторам и модераторам",
+		"error.no_summary":       "Сводка для этого чата ещё не составлена",
+		"error.stats":            "Не удалось получить статистику",
+		"error.config":           "Не удалось получить конфигурацию",
+		"error.summarize":        "Не удалось запустить суммаризацию",
+		"error.pin":              "Не удалось закрепить сводку",
+		"error.pin_no_summary":   "Сводка для этого чата ещё не составлена, запустите /summarize",
+		"error.bot_cannot_pin":   "У бота нет прав на закрепление сообщений — назначьте его администратором с правом закреплять сообщения",
+		"error.topics":           "Не удалось получить темы",
+		"error.summary":          "Не удалось получить сводку",
+		"error.recent_replies":   "Не удалось получить ответы бота",
+		"error.events":           "Не удалось получить события",
+		"error.events_update":    "Не удалось обновить события",
+		"error.event_not_found":  "Нет события с таким номером",
+		"error.find":             "Не удалось выполнить поиск",
+		"error.language":         "Не удалось сохранить язык",
+		"error.expert":           "Не удалось найти экспертов",
+		"error.refresh_profile":  "Не удалось запустить обновление профиля",
+		"error.user_not_found":   "Участник %s не найден в этом чате",
+		"error.throttled":        "Слишком часто, подождите несколько секунд",
+		"error.buffer":           "Не удалось сохранить размер буфера",
+		"error.rank":             "Не удалось получить место в рейтинге",
+		"config.title":           "⚙️ Текущая конфигурация",
+		"uptime.title":           "⏱ Состояние бота",
+		"uptime.body":            "Время работы: %s\nОбработано сообщений: %d\nЗапросов к OpenAI: %d\nГорутин: %d",
+		"summarize.started":      "🔄 Суммаризация запущена",
+		"pin.title":              "📌 Сводка чата",
+		"summary.title":          "📝 Сводка чата",
+		"summary.topics":         "🏷 Темы",
+		"summary.empty":          "📝 Сводки пока нет — в чате ещё недостаточно сообщений. Загляните попозже!",
+		"digest.title":           "🗓 Итоги недели",
+		"digest.active_users":    "🏆 Самые активные участники",
+		"recent_replies.title":   "🤖 Последние ответы бота (%d)",
+		"recent_replies.empty":   "🤖 Бот ещё ничего не писал в этом чате.",
+		"topics.empty":           "Темы пока недоступны — сводка ещё не составлена.",
+		"topics.title":           "🏷 Популярные темы (топ-%d)",
+		"stats.empty":            "Статистика пока недоступна — нет данных о сообщениях.",
+		"stats.questions_empty":  "Статистика пока недоступна — никто ещё не задавал вопросов.",
+		"stats.links_empty":      "Статистика пока недоступна — никто ещё не делился ссылками.",
+		"stats.messages_top":     "📊 Самые активные участники (топ-%d)",
+		"stats.messages_bottom":  "📊 Наименее активные участники (топ-%d)",
+		"stats.chars_top":        "📊 Самые активные по символам (топ-%d)",
+		"stats.chars_bottom":     "📊 Наименее активные по символам (топ-%d)",
+		"stats.lastmsg_top":      "📊 Последние отписавшиеся (топ-%d)",
+		"stats.lastmsg_bottom":   "📊 Давно не писали (топ-%d)",
+		"stats.questions_top":    "📊 Чаще всех задают вопросы (топ-%d)",
+		"stats.questions_bottom": "📊 Реже всех задают вопросы (топ-%d)",
+		"stats.links_top":        "📊 Чаще всех делятся ссылками (топ-%d)",
+		"stats.links_bottom":     "📊 Реже всех делятся ссылками (топ-%d)",
+		"stats.unknown_users":    "Неизвестные участники",
+		"time.just_now":          "только что",
+		"time.ago":               "%d %s назад",
+		"time.yesterday":         "вчера в %s",
+		"events.empty":           "📅 Запланированных событий нет.",
+		"events.title":           "📅 Запланированные события",
+		"events.add_usage":       "Использование: /addevent <дата в ISO-8601> <название>, например /addevent 2025-12-31 Новогодний созвон",
+		"events.added":           "📅 Событие добавлено: %s — %s",
+		"events.remove_usage":    "Использование: /removeevent <номер из /events>",
+		"events.removed":         "🗑 Событие удалено: %s",
+		"find.usage":             "Использование: /find <ключевое слово>",
+		"find.empty":             "🔍 По запросу «%s» ничего не найдено.",
+		"find.title":             "🔍 Найдено по запросу «%s»",
+		"find.topic":             "💬 Тема #%d",
+		"find.topics":            "Темы: %s",
+		"search.usage":           "Использование: /search <запрос>",
+		"search.empty":           "🔎 Сообщений по запросу «%s» не найдено.",
+		"search.title":           "🔎 Сообщения по запросу «%s»",
+		"help.title":             "📖 Доступные команды",
+		"help.help":              "список команд",
+		"help.stats":             "статистика участников",
+		"help.stats_args":        "    аргументы: top | bottom, msgs | chars | lastmsg | questions | links, число (до 50), например /stats bottom chars 5",
+		"help.toptopics":         "популярные темы чата",
+		"help.summary":           "текущая сводка чата",
+		"help.find":              "поиск по сводкам: /find <ключевое слово>",
+		"help.search":            "поиск по сообщениям: /search <запрос>",
+		"help.expert":            "кто разбирается в теме: /expert <тема>",
+		"help.events":            "запланированные события",
+		"help.addevent":          "добавить событие: /addevent <дата> <название> (модераторы)",
+		"help.removeevent":       "удалить событие: /removeevent <номер> (модераторы)",
+		"help.summarize":         "составить сводку чата сейчас (модераторы)",
+		"help.pinsummary":        "закрепить свежую сводку (модераторы)",
+		"help.language":          "язык ответов: /language <ru|en> (модераторы)",
+		"help.config":            "текущая конфигурация бота (администратор)",
+		"help.uptime":            "время работы и счётчики бота (администратор)",
+		"help.recentreplies":     "последние ответы бота: /recentreplies [число] (модераторы)",
+		"help.refreshprofile":    "пересобрать профиль участника: /refreshprofile <@username> (администратор)",
+		"help.setbuffer":         "сообщений до сводки: /setbuffer <число|0> (администратор)",
+		"help.rank":              "место в рейтинге по сообщениям: /rank [@username]",
+		"help.mention":           "💬 Упомяните %s или ответьте на его сообщение, чтобы задать вопрос.",
+		"expert.usage":           "Использование: /expert <тема>",
+		"expert.empty":           "🎓 Экспертов по теме «%s» пока нет.",
+		"expert.title":           "🎓 Эксперты по теме «%s»",
+		"language.usage":         "Использование: /language <ru|en>",
+		"language.set":           "🌐 Язык ответов: русский",
+		"refresh_profile.usage":  "Использование: /refreshprofile <@username или id>, или ответьте командой на сообщение участника",
+		"refresh_profile.queued": "🔄 Обновление профиля участника запущено",
+		"buffer.usage":           "Использование: /setbuffer <число от %d до %d>, 0 — значение по умолчанию",
+		"buffer.set":             "🧮 Размер буфера для сводки: %d",
+		"buffer.reset":           "🧮 Размер буфера для сводки сброшен до %d",
+		"rank.position":          "🏅 %s: #%d из %d (%d %s)",
+		"rank.no_messages":       "🏅 У %s пока нет сообщений в этом чате",
+		"word.message":           "сообщение|сообщения|сообщений",
+		"word.char":              "символ|символа|символов",
+		"word.question":          "вопрос|вопроса|вопросов",
+		"word.link":              "ссылка|ссылки|ссылок",
+		"word.minute":            "минуту|минуты|минут",
+		"word.hour":              "час|часа|часов",
+		"word.day":               "день|дня|дней",
+	},
+	LanguageEnglish: {
+		"error.admin_only":       "This command is only available to the bot administrator",
+		"error.moderators_only":  "This command is only available to admins and moderators",
+		"error.no_summary":       "There is no summary for this chat yet",
+		"error.stats":            "Failed to get statistics",
+		"error.config":           "Failed to get configuration",
+		"error.summarize":        "Failed to start summarization",
+		"error.pin":              "Failed to pin the summary",
+		"error.pin_no_summary":   "There is no summary for this chat yet, run /summarize",
+		"error.bot_cannot_pin":   "The bot can't pin messages here — make it an admin with the right to pin messages",
+		"error.topics":           "Failed to get topics",
+		"error.summary":          "Failed to get the summary",
+		"error.recent_replies":   "Failed to get the bot replies",
+		"error.events":           "Failed to get events",
+		"error.events_update":    "Failed to update events",
+		"error.event_not_found":  "There is no event with this number",
+		"error.find":             "Search failed",
+		"error.language":         "Failed to save the language",
+		"error.expert":           "Failed to find experts",
+		"error.refresh_profile":  "Failed to start the profile refresh",
+		"error.user_not_found":   "User %s was not found in this chat",
+		"error.throttled":        "Too many requests, please wait a few seconds",
+		"error.buffer":           "Failed to save the buffer size",
+		"error.rank":             "Failed to get the leaderboard position",
+		"config.title":           "⚙️ Current configuration",
+		"uptime.title":           "⏱ Bot status",
+		"uptime.body":            "Uptime: %s\nMessages processed: %d\nOpenAI calls: %d\nGoroutines: %d",
+		"summarize.started":      "🔄 Summarization started",
+		"pin.title":              "📌 Chat summary",
+		"summary.title":          "📝 Chat summary",
+		"summary.topics":         "🏷 Topics",
+		"summary.empty":          "📝 No summary yet — there are not enough messages in the chat. Check back later!",
+		"digest.title":           "🗓 Weekly digest",
+		"digest.active_users":    "🏆 Most active members",
+		"recent_replies.title":   "🤖 Recent bot replies (%d)",
+		"recent_replies.empty":   "🤖 The bot has not written anything in this chat yet.",
+		"topics.empty":           "Topics are not available yet — no summary has been made.",
+		"topics.title":           "🏷 Top topics (top %d)",
+		"stats.empty":            "Statistics are not available yet — no messages recorded.",
+		"stats.questions_empty":  "Statistics are not available yet — nobody has asked questions.",
+		"stats.links_empty":      "Statistics are not available yet — nobody has shared links.",
+		"stats.messages_top":     "📊 Most active members (top %d)",
+		"stats.messages_bottom":  "📊 Least active members (top %d)",
+		"stats.chars_top":        "📊 Most active by characters (top %d)",
+		"stats.chars_bottom":     "📊 Least active by characters (top %d)",
+		"stats.lastmsg_top":      "📊 Most recently active (top %d)",
+		"stats.lastmsg_bottom":   "📊 Silent the longest (top %d)",
+		"stats.questions_top":    "📊 Ask the most questions (top %d)",
+		"stats.questions_bottom": "📊 Ask the fewest questions (top %d)",
+		"stats.links_top":        "📊 Share the most links (top %d)",
+		"stats.links_bottom":     "📊 Share the fewest links (top %d)",
+		"stats.unknown_users":    "Unknown members",
+		"time.just_now":          "just now",
+		"time.ago":               "%d %s ago",
+		"time.yesterday":         "yesterday at %s",
+		"events.empty":           "📅 No upcoming events.",
+		"events.title":           "📅 Upcoming events",
+		"events.add_usage":       "Usage: /addevent <ISO-8601 date> <title>, e.g. /addevent 2025-12-31 New Year call",
+		"events.added":           "📅 Event added: %s — %s",
+		"events.remove_usage":    "Usage: /removeevent <number from /events>",
+		"events.removed":         "🗑 Event removed: %s",
+		"find.usage":             "Usage: /find <keyword>",
+		"find.empty":             "🔍 Nothing found for “%s”.",
+		"find.title":             "🔍 Results for “%s”",
+		"find.topic":             "💬 Topic #%d",
+		"find.topics":            "Topics: %s",
+		"search.usage":           "Usage: /search <query>",
+		"search.empty":           "🔎 No messages found for “%s”.",
+		"search.title":           "🔎 Messages matching “%s”",
+		"help.title":             "📖 Available commands",
+		"help.help":              "list commands",
+		"help.stats":             "member statistics",
+		"help.stats_args":        "    arguments: top | bottom, msgs | chars | lastmsg | questions | links, a number (up to 50), e.g. /stats bottom chars 5",
+		"help.toptopics":         "popular chat topics",
+		"help.summary":           "current chat summary",
+		"help.find":              "search summaries: /find <keyword>",
+		"help.search":            "search messages: /search <query>",
+		"help.expert":            "who knows a topic: /expert <topic>",
+		"help.events":            "upcoming events",
+		"help.addevent":          "add an event: /addevent <date> <title> (moderators)",
+		"help.removeevent":       "remove an event: /removeevent <number> (moderators)",
+		"help.summarize":         "summarize the chat now (moderators)",
+		"help.pinsummary":        "pin the latest summary (moderators)",
+		"help.language":          "response language: /language <ru|en> (moderators)",
+		"help.config":            "current bot configuration (administrator)",
+		"help.uptime":            "bot uptime and counters (administrator)",
+		"help.recentreplies":     "recent bot replies: /recentreplies [number] (moderators)",
+		"help.refreshprofile":    "rebuild a member's profile: /refreshprofile <@username> (admin)",
+		"help.setbuffer":         "messages before a summary: /setbuffer <number|0> (admin)",
+		"help.rank":              "message leaderboard position: /rank [@username]",
+		"help.mention":           "💬 Mention %s or reply to its message to ask a question.",
+		"expert.usage":           "Usage: /expert <topic>",
+		"expert.empty":           "🎓 No experts on “%s” yet.",
+		"expert.title":           "🎓 Experts on “%s”",
+		"language.usage":         "Usage: /language <ru|en>",
+		"language.set":           "🌐 Response language: English",
+		"refresh_profile.usage":  "Usage: /refreshprofile <@username or id>, or reply with the command to the member's message",
+		"refresh_profile.queued": "🔄 Profile refresh started",
+		"buffer.usage":           "Usage: /setbuffer <number from %d to %d>, 0 resets to the default",
+		"buffer.set":             "🧮 Summary buffer size: %d",
+		"buffer.reset":           "🧮 Summary buffer size reset to %d",
+		"rank.position":          "🏅 %s: #%d of %d (%d %s)",
+		"rank.no_messages":       "🏅 %s has no messages in this chat yet",
+		"word.message":           "message|messages|messages",
+		"word.char":              "character|characters|characters",
+		"word.question":          "question|questions|questions",
+		"word.link":              "link|links|links",
+		"word.minute":            "minute|minutes|minutes",
+		"word.hour":              "hour|hours|hours",
+		"word.day":               "day|days|days",
+	},
+}
+
+// isSupportedLanguage reports whether the catalog has the language
+func isSupportedLanguage(lang string) bool {
+	_, ok := messageCatalog[lang]
+	return ok
+}
+
+// translate returns the catalog text for key in lang formatted with args.
+// Unknown languages and missing keys fall back to the default language.
+func translate(lang, key string, args ...any) string {
+	text, ok := messageCatalog[lang][key]
+	if !ok {
+		text, ok = messageCatalog[defaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// pluralWord returns the plural form of a catalog word for n
+func pluralWord(lang, key string, n int64) string {
+	forms := strings.SplitN(translate(lang, key), "|", 3)
+	if len(forms) != 3 {
+		return forms[0]
+	}
+
+	if lang == LanguageEnglish {
+		if n == 1 || n == -1 {
+			return forms[0]
+		}
+		return forms[2]
+	}
+
+	return russianPlural(n, forms[0], forms[1], forms[2])
+}
+
+// chatLanguage returns the UI language configured for the chat, or the default on errors
+func (l *Listener) chatLanguage(ctx context.Context, chatID int64) string {
+	lang, err := l.repo.GetChatUILanguage(ctx, chatID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get chat UI language", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return defaultLanguage
+	}
+
+	if !isSupportedLanguage(lang) {
+		return defaultLanguage
+	}
+	return lang
+}
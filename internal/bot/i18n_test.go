@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+func TestFormatStatsResponseEnglish(t *testing.T) {
+	l := &Listener{config: &config.Config{}}
+	username := "alice"
+	stats := []*repo.UserMessageStats{
+		{UserID: 1, Username: &username, FirstName: "Alice", MessageCount: 5},
+		{UserID: 2, FirstName: "Bob", MessageCount: 1},
+	}
+
+	got := l.formatStatsResponse(stats, false, 10, LanguageEnglish)
+	want := "📊 Most active members (top 2)\n\n1. alice (Alice) — 5 messages\n2. Bob — 1 message\n"
+	if got != want {
+		t.Errorf("formatStatsResponse() = %q, want %q", got, want)
+	}
+
+	got = l.formatCountStatsResponse(stats, "stats.links_bottom", "word.link", LanguageEnglish)
+	want = "📊 Share the fewest links (top 2)\n\n1. alice (Alice) — 5 links\n2. Bob — 1 link\n"
+	if got != want {
+		t.Errorf("formatCountStatsResponse() = %q, want %q", got, want)
+	}
+}
+
+func TestTranslateFallback(t *testing.T) {
+	if got := translate("de", "stats.messages_top", 3); got != "📊 Самые активные участники (топ-3)" {
+		t.Errorf("Expected unknown language to fall back to Russian, got %q", got)
+	}
+	if got := translate(LanguageEnglish, "missing.key"); got != "missing.key" {
+		t.Errorf("Expected missing key to be returned as is, got %q", got)
+	}
+}
+
+func TestPluralWord(t *testing.T) {
+	tests := []struct {
+		lang     string
+		n        int64
+		expected string
+	}{
+		{LanguageRussian, 1, "сообщение"},
+		{LanguageRussian, 3, "сообщения"},
+		{LanguageRussian, 11, "сообщений"},
+		{LanguageRussian, 21, "сообщение"},
+		{LanguageEnglish, 1, "message"},
+		{LanguageEnglish, 21, "messages"},
+	}
+
+	for _, tt := range tests {
+		if got := pluralWord(tt.lang, "word.message", tt.n); got != tt.expected {
+			t.Errorf("pluralWord(%s, %d) = %q, want %q", tt.lang, tt.n, got, tt.expected)
+		}
+	}
+
+	// Every catalog in every language must have the same keys
+	for lang, messages := range messageCatalog {
+		for key := range messageCatalog[defaultLanguage] {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("Language %s is missing key %s", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// identityKey identifies a user within a chat
+type identityKey struct {
+	chatID int64
+	userID int64
+}
+
+// identityStore persists user identities
+type identityStore interface {
+	UpdateUserIdentity(ctx context.Context, identity models.UserIdentity) error
+}
+
+// identityTracker remembers the last seen identity of each chat member and queues changed
+// identities for the database. The first message of a user after startup counts as a change.
+type identityTracker struct {
+	repo identityStore
+
+	mu      sync.Mutex
+	known   map[identityKey]models.UserIdentity
+	pending map[identityKey]models.UserIdentity
+}
+
+func newIdentityTracker(repo identityStore) *identityTracker {
+	return &identityTracker{
+		repo:    repo,
+		known:   make(map[identityKey]models.UserIdentity),
+		pending: make(map[identityKey]models.UserIdentity),
+	}
+}
+
+// Observe records the identity seen on a message and reports whether it differs from the last one
+func (t *identityTracker) Observe(identity models.UserIdentity) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := identityKey{chatID: identity.ChatID, userID: identity.UserID}
+	if known, ok := t.known[key]; ok && sameIdentity(known, identity) {
+		return false
+	}
+
+	t.known[key] = identity
+	t.pending[key] = identity
+	return true
+}
+
+// Flush writes changed identities to the database. Identities that fail to write are
+// queued again for the next flush.
+func (t *identityTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[identityKey]models.UserIdentity)
+	t.mu.Unlock()
+
+	var errs []error
+	for key, identity := range pending {
+		if err := t.repo.UpdateUserIdentity(ctx, identity); err != nil {
+			// Requeue unless a newer identity arrived meanwhile
+			t.mu.Lock()
+			if _, ok := t.pending[key]; !ok {
+				t.pending[key] = identity
+			}
+			t.mu.Unlock()
+			errs = append(errs, fmt.Errorf("failed to flush user identity for user %d: %w", identity.UserID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sameIdentity compares display fields of two identities
+func sameIdentity(a, b models.UserIdentity) bool {
+	return optionalString(a.Username) == optionalString(b.Username) &&
+		a.FirstName == b.FirstName &&
+		optionalString(a.LastName) == optionalString(b.LastName)
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
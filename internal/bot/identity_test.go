@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestIdentityTrackerObserve(t *testing.T) {
+	tracker := newIdentityTracker(nil)
+	oldUsername := "old_name"
+	newUsername := "new_name"
+
+	if !tracker.Observe(models.UserIdentity{ChatID: 10, UserID: 1, Username: &oldUsername, FirstName: "Alice"}) {
+		t.Error("Expected first sighting to count as a change")
+	}
+	if tracker.Observe(models.UserIdentity{ChatID: 10, UserID: 1, Username: &oldUsername, FirstName: "Alice"}) {
+		t.Error("Expected unchanged identity not to count as a change")
+	}
+	if !tracker.Observe(models.UserIdentity{ChatID: 10, UserID: 1, Username: &newUsername, FirstName: "Alice"}) {
+		t.Error("Expected username change to count as a change")
+	}
+	if !tracker.Observe(models.UserIdentity{ChatID: 20, UserID: 1, Username: &newUsername, FirstName: "Alice"}) {
+		t.Error("Expected the same user in another chat to be tracked separately")
+	}
+
+	pending, ok := tracker.pending[identityKey{chatID: 10, userID: 1}]
+	if len(tracker.pending) != 2 || !ok || *pending.Username != newUsername {
+		t.Errorf("Expected the latest identity per chat to be pending, got %+v", tracker.pending)
+	}
+}
+
+// fakeIdentityStore records written identities and fails for users in fail
+type fakeIdentityStore struct {
+	fail    map[int64]bool
+	written []int64
+}
+
+func (s *fakeIdentityStore) UpdateUserIdentity(ctx context.Context, identity models.UserIdentity) error {
+	if s.fail[identity.UserID] {
+		return errors.New("connection reset")
+	}
+	s.written = append(s.written, identity.UserID)
+	return nil
+}
+
+func TestIdentityTrackerFlushRequeuesFailedIdentities(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeIdentityStore{fail: map[int64]bool{1: true, 2: true}}
+	tracker := newIdentityTracker(store)
+
+	for userID := int64(1); userID <= 3; userID++ {
+		tracker.Observe(models.UserIdentity{ChatID: 10, UserID: userID, FirstName: "User"})
+	}
+
+	if err := tracker.Flush(ctx); err == nil {
+		t.Fatal("Expected flush error for failed identities")
+	}
+	if !reflect.DeepEqual(store.written, []int64{3}) {
+		t.Fatalf("Expected the remaining identity written despite earlier failures, got %v", store.written)
+	}
+
+	store.fail = nil
+	store.written = nil
+	if err := tracker.Flush(ctx); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	slices.Sort(store.written)
+	if !reflect.DeepEqual(store.written, []int64{1, 2}) {
+		t.Errorf("Expected every failed identity retried, got %v", store.written)
+	}
+}
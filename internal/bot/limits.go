@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// chatLimitsTTL is how long the summarization thresholds of a chat are reused before reading
+// them again, so changes made outside the bot are picked up
+const chatLimitsTTL = time.Minute
+
+// chatLimitsCache keeps the summarization thresholds of chats, so counting a message doesn't
+// read them from the database every time
+type chatLimitsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[int64]cachedChatLimits
+}
+
+// cachedChatLimits are the thresholds of a chat and when they were read
+type cachedChatLimits struct {
+	limits    repo.ChatLimits
+	fetchedAt time.Time
+}
+
+func newChatLimitsCache(ttl time.Duration) *chatLimitsCache {
+	return &chatLimitsCache{
+		ttl:     ttl,
+		entries: make(map[int64]cachedChatLimits),
+	}
+}
+
+// Get returns the cached thresholds of a chat unless they expired
+func (c *chatLimitsCache) Get(chatID int64, now time.Time) (repo.ChatLimits, bool) {
+	if c == nil {
+		return repo.ChatLimits{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[chatID]
+	if !ok || now.Sub(entry.fetchedAt) >= c.ttl {
+		delete(c.entries, chatID)
+		return repo.ChatLimits{}, false
+	}
+	return entry.limits, true
+}
+
+// Set caches the thresholds of a chat
+func (c *chatLimitsCache) Set(chatID int64, limits repo.ChatLimits, now time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[chatID] = cachedChatLimits{limits: limits, fetchedAt: now}
+}
+
+// Invalidate drops the cached thresholds of a chat after they were changed
+func (c *chatLimitsCache) Invalidate(chatID int64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, chatID)
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/internal/repo"
+)
+
+func TestChatLimitsCache(t *testing.T) {
+	cache := newChatLimitsCache(time.Minute)
+	now := time.Now()
+	limits := repo.ChatLimits{MaxMsgBuffer: 5, SummarizeMaxMessages: 50}
+
+	if _, ok := cache.Get(1, now); ok {
+		t.Fatal("Expected no cached limits for an unknown chat")
+	}
+
+	cache.Set(1, limits, now)
+	if got, ok := cache.Get(1, now.Add(30*time.Second)); !ok || got != limits {
+		t.Errorf("Expected cached limits within the TTL, got %+v, %v", got, ok)
+	}
+	if _, ok := cache.Get(2, now); ok {
+		t.Error("Expected limits to be cached per chat")
+	}
+	if _, ok := cache.Get(1, now.Add(time.Minute)); ok {
+		t.Error("Expected limits to expire after the TTL")
+	}
+
+	// Changing the limits drops them before the TTL
+	cache.Set(1, limits, now)
+	cache.Invalidate(1)
+	if _, ok := cache.Get(1, now); ok {
+		t.Error("Expected invalidated limits to be read again")
+	}
+}
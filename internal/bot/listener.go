@@ -2,35 +2,66 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mymmrac/telego"
 	"github.com/xdefrag/william/internal/config"
 	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/internal/runtimestats"
 	"github.com/xdefrag/william/pkg/models"
 )
 
 // Listener handles Telegram updates
 type Listener struct {
-	bot       *telego.Bot
-	repo      *repo.Repository
-	config    *config.Config
-	publisher message.Publisher
-	logger    *slog.Logger
+	bot         *telego.Bot
+	repo        *repo.Repository
+	config      *config.Config
+	publisher   message.Publisher
+	sender      *Sender
+	counter     messageCounter
+	throttle    *ingestThrottle
+	cooldown    *commandCooldown
+	mentions    *mentionLimiter
+	identities  *identityTracker
+	botRights   *botRightsTracker
+	limits      *chatLimitsCache
+	transcriber Transcriber
+	stats       *runtimestats.Stats
+	logger      *slog.Logger
 }
 
 // New creates a new bot listener
-func New(bot *telego.Bot, repo *repo.Repository, cfg *config.Config, publisher message.Publisher, logger *slog.Logger) *Listener {
+func New(bot *telego.Bot, repo *repo.Repository, cfg *config.Config, publisher message.Publisher, sender *Sender, transcriber Transcriber, stats *runtimestats.Stats, logger *slog.Logger) *Listener {
+	var counter messageCounter = &dbCounter{repo: repo}
+	if cfg.App.Limits.CounterMode == CounterModeMemory {
+		counter = newMemoryCounter(repo)
+	}
+
 	return &Listener{
-		bot:       bot,
-		repo:      repo,
-		config:    cfg,
-		publisher: publisher,
-		logger:    logger.WithGroup("bot.listener"),
+		bot:         bot,
+		repo:        repo,
+		config:      cfg,
+		publisher:   publisher,
+		sender:      sender,
+		counter:     counter,
+		throttle:    newIngestThrottle(cfg.App.Limits.IngestMaxPerSecond),
+		cooldown:    newCommandCooldown(time.Duration(cfg.App.Commands.CooldownSeconds) * time.Second),
+		mentions:    newMentionLimiter(cfg.App.Limits.MentionRateLimit, time.Duration(cfg.App.Limits.MentionRateWindowSeconds)*time.Second, time.Now),
+		identities:  newIdentityTracker(repo),
+		botRights:   newBotRightsTracker(),
+		limits:      newChatLimitsCache(chatLimitsTTL),
+		transcriber: transcriber,
+		stats:       stats,
+		logger:      logger.WithGroup("bot.listener"),
 	}
 }
 
@@ -40,27 +71,163 @@ func (l *Listener) Start(ctx context.Context) error {
 		slog.Bool("bot_ready", true),
 	)
 
-	updates, err := l.bot.UpdatesViaLongPolling(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to get updates channel: %w", err)
+	if counter, ok := l.counter.(*memoryCounter); ok {
+		go l.runCounterFlusher(ctx, counter)
+	}
+
+	if l.config.App.Limits.IdentityFlushSeconds > 0 {
+		go l.runIdentityFlusher(ctx)
+	}
+
+	if l.config.App.Limits.MentionRateLimit > 0 {
+		go l.runMentionLimiterCleanup(ctx)
+	}
+
+	l.poll(ctx, l.longPolling, l.handleUpdate)
+	return nil
+}
+
+// longPollingTimeoutSeconds is the getUpdates long polling timeout
+const longPollingTimeoutSeconds = 8
+
+// errUpdatesClosed reports that the updates channel closed before shutdown
+var errUpdatesClosed = errors.New("updates channel closed")
+
+// updatesSource opens a channel of updates starting at offset. The channel closes when
+// receiving updates fails.
+type updatesSource func(ctx context.Context, offset int) (<-chan telego.Update, error)
+
+// longPolling receives updates via long polling. Telego's fixed-interval retries are disabled so
+// a polling error closes the channel and poll reconnects with backoff.
+func (l *Listener) longPolling(ctx context.Context, offset int) (<-chan telego.Update, error) {
+	return l.bot.UpdatesViaLongPolling(ctx,
+		&telego.GetUpdatesParams{Offset: offset, Timeout: longPollingTimeoutSeconds},
+		telego.WithLongPollingRetryTimeout(0),
+	)
+}
+
+// poll hands updates from source to handle until ctx is cancelled, reconnecting with jittered
+// exponential backoff when the updates channel closes or can't be opened. On shutdown it waits
+// up to the shutdown timeout for in-flight handlers.
+func (l *Listener) poll(ctx context.Context, source updatesSource, handle func(context.Context, telego.Update)) {
+	var inflight sync.WaitGroup
+	baseDelay := time.Duration(l.config.App.Telegram.ReconnectBaseDelayMs) * time.Millisecond
+	maxDelay := time.Duration(l.config.App.Telegram.ReconnectMaxDelayMs) * time.Millisecond
+
+	offset := 0
+	for attempt := 0; ; attempt++ {
+		updates, err := source(ctx, offset)
+		if err == nil {
+			next := l.listen(ctx, updates, offset, &inflight, handle)
+			if next != offset {
+				// Updates were received, so the connection worked; start the backoff over
+				attempt = 0
+			}
+			offset = next
+			err = errUpdatesClosed
+		}
+
+		if ctx.Err() != nil {
+			l.logger.InfoContext(ctx, "Stopping bot listener")
+			l.waitInflight(ctx, &inflight)
+			return
+		}
+
+		delay := reconnectDelay(baseDelay, maxDelay, attempt)
+		l.logger.WarnContext(ctx, "Receiving updates stopped, reconnecting",
+			slog.Any("error", err),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+		)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
 	}
+}
+
+// listen hands each update to handle in its own goroutine tracked by inflight until ctx is
+// cancelled or updates closes, and returns the offset after the last received update. Handlers
+// run detached from ctx cancellation so their database writes complete; updates received after
+// shutdown began are dropped.
+func (l *Listener) listen(ctx context.Context, updates <-chan telego.Update, offset int, inflight *sync.WaitGroup, handle func(context.Context, telego.Update)) int {
+	handlerCtx := context.WithoutCancel(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
-			l.logger.InfoContext(ctx, "Stopping bot listener")
-			return nil
-		case update := <-updates:
-			if update.Message != nil {
-				go l.handleMessage(ctx, update.Message)
+			return offset
+		case update, ok := <-updates:
+			if !ok {
+				return offset
 			}
+			if ctx.Err() != nil {
+				l.logger.DebugContext(ctx, "Update received during shutdown dropped", slog.Int("update_id", update.UpdateID))
+				continue
+			}
+			offset = update.UpdateID + 1
+			inflight.Add(1)
+			go func() {
+				defer inflight.Done()
+				handle(handlerCtx, update)
+			}()
 		}
 	}
 }
 
-// getMessageText extracts text from a message, checking both Text and Caption fields
+// reconnectDelay doubles the base delay per attempt up to maxDelay, randomized to between half
+// and the full delay so reconnects after an outage are spread out
+func reconnectDelay(base, maxDelay time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// waitInflight waits for in-flight update handlers for at most the shutdown timeout
+func (l *Listener) waitInflight(ctx context.Context, inflight *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Duration(l.config.App.App.ShutdownTimeoutSeconds) * time.Second):
+		l.logger.WarnContext(ctx, "Shutdown timeout exceeded with updates still being handled")
+	}
+}
+
+// handleUpdate dispatches an update to its handler
+func (l *Listener) handleUpdate(ctx context.Context, update telego.Update) {
+	if update.Message != nil {
+		l.handleMessage(ctx, update.Message)
+	}
+	if update.EditedMessage != nil {
+		l.handleEditedMessage(ctx, update.EditedMessage)
+	}
+	if update.MyChatMember != nil {
+		l.handleMyChatMember(ctx, update.MyChatMember)
+	}
+}
+
+// getMessageText extracts text from a message, checking both Text and Caption fields.
+// When emoji signals are enabled, stickers and emoji-only texts become short markers.
 func (l *Listener) getMessageText(msg *telego.Message) string {
+	storeEmoji := l.config.App.App.StoreEmojiSignals
+
 	if msg.Text != "" {
+		if storeEmoji && isEmojiOnly(msg.Text) {
+			return fmt.Sprintf("[emoji: %s]", strings.TrimSpace(msg.Text))
+		}
 		return msg.Text
 	}
 
@@ -69,9 +236,112 @@ func (l *Listener) getMessageText(msg *telego.Message) string {
 		return msg.Caption
 	}
 
+	if storeEmoji && msg.Sticker != nil && msg.Sticker.Emoji != "" {
+		return fmt.Sprintf("[sticker: %s]", msg.Sticker.Emoji)
+	}
+
 	return ""
 }
 
+// emojiRanges are the pictographic emoji code points. Other symbols such as © ® ™ °, arrows and
+// box drawing characters are left out.
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x203c, Hi: 0x203c, Stride: 1},
+		{Lo: 0x2049, Hi: 0x2049, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2328, Stride: 1},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25b6, Stride: 1},
+		{Lo: 0x25c0, Hi: 0x25c0, Stride: 1},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x2775, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27b0, Stride: 1},
+		{Lo: 0x27bf, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x3030, Hi: 0x3030, Stride: 1},
+		{Lo: 0x303d, Hi: 0x303d, Stride: 1},
+		{Lo: 0x3297, Hi: 0x3297, Stride: 1},
+		{Lo: 0x3299, Hi: 0x3299, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f170, Hi: 0x1f171, Stride: 1},
+		{Lo: 0x1f17e, Hi: 0x1f17f, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f201, Hi: 0x1f202, Stride: 1},
+		{Lo: 0x1f21a, Hi: 0x1f21a, Stride: 1},
+		{Lo: 0x1f22f, Hi: 0x1f22f, Stride: 1},
+		{Lo: 0x1f232, Hi: 0x1f23a, Stride: 1},
+		{Lo: 0x1f250, Hi: 0x1f251, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f7e0, Hi: 0x1f7eb, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+	},
+}
+
+// isEmojiOnly reports whether text consists only of emoji and whitespace
+func isEmojiOnly(text string) bool {
+	hasEmoji := false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+		case r == '\u200d' || r == '\ufe0f' || (r >= 0xe0020 && r <= 0xe007f):
+			// Zero-width joiner, emoji presentation selector and tags glue emoji sequences
+		case r >= 0x1f3fb && r <= 0x1f3ff:
+			// Skin tone modifiers
+		case unicode.Is(emojiRanges, r) || (r >= 0x1f1e6 && r <= 0x1f1ff):
+			// Pictographs and regional indicators of flags
+			hasEmoji = true
+		default:
+			return false
+		}
+	}
+	return hasEmoji
+}
+
+// forwardOriginName describes the original sender of a forwarded message, or returns empty string
+func forwardOriginName(origin telego.MessageOrigin) string {
+	switch o := origin.(type) {
+	case *telego.MessageOriginUser:
+		name := strings.TrimSpace(o.SenderUser.FirstName + " " + o.SenderUser.LastName)
+		if o.SenderUser.Username != "" {
+			name += fmt.Sprintf(" (@%s)", o.SenderUser.Username)
+		}
+		return name
+	case *telego.MessageOriginHiddenUser:
+		return o.SenderUserName
+	case *telego.MessageOriginChat:
+		return chatOriginName(o.SenderChat, o.AuthorSignature)
+	case *telego.MessageOriginChannel:
+		return chatOriginName(o.Chat, o.AuthorSignature)
+	}
+	return ""
+}
+
+// chatOriginName formats a chat or channel origin with optional username and author signature
+func chatOriginName(chat telego.Chat, signature string) string {
+	name := chat.Title
+	if chat.Username != "" {
+		name += fmt.Sprintf(" (@%s)", chat.Username)
+	}
+	if signature != "" {
+		name += fmt.Sprintf(", %s", signature)
+	}
+	return strings.TrimSpace(name)
+}
+
 // getTopicID extracts topic ID from message using MessageThreadID
 func (l *Listener) getTopicID(msg *telego.Message) *int64 {
 	// For now, always return MessageThreadID value (0 or topic ID)
@@ -91,8 +361,13 @@ func (l *Listener) handleMessage(ctx context.Context, msg *telego.Message) {
 	// Get text from either Text or Caption field
 	messageText := l.getMessageText(msg)
 
+	var note *voiceNote
+	if messageText == "" && l.config.App.App.StoreVoiceNotes {
+		note = getVoiceNote(msg)
+	}
+
 	// Skip messages without text or from bots
-	if messageText == "" || msg.From.IsBot {
+	if (messageText == "" && note == nil) || msg.From.IsBot {
 		return
 	}
 
@@ -122,7 +397,64 @@ func (l *Listener) handleMessage(ctx context.Context, msg *telego.Message) {
 		return
 	}
 
-	// Create message model
+	// Transcribe only after the chat is known to be allowed
+	if note != nil {
+		messageText = l.voiceNoteText(ctx, msg, note)
+	}
+
+	message := l.buildMessage(msg, messageText)
+
+	// Save message to database
+	isNew, err := l.repo.SaveMessage(ctx, message)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to save message", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("user_id", msg.From.ID),
+		)
+		return
+	}
+
+	// Redelivered updates are already stored, answered and counted
+	if !isNew {
+		l.logger.DebugContext(ctx, "Duplicate message ignored",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+		return
+	}
+	l.stats.IncMessages()
+
+	// Under a flood keep storing, counting and answering messages but skip the per-message
+	// profile and identity updates
+	if l.throttle.Allow(msg.Chat.ID, time.Now()) {
+		// Seed user profile on first message so identity is known before summarization
+		if l.config.App.App.SeedUserProfiles {
+			l.seedUserProfile(ctx, message)
+		}
+
+		l.trackIdentity(ctx, message)
+	} else {
+		l.logger.DebugContext(ctx, "Chat ingestion throttled, skipping profile updates",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("user_id", msg.From.ID),
+		)
+	}
+
+	// Check if message is a mention or reply to bot
+	isMention := l.isMentionOrReply(msg)
+
+	if isMention {
+		// Handle mention/reply in separate goroutine
+		go l.handleMention(ctx, msg, messageText)
+	}
+
+	// Increment message counter and check if we need to summarize
+	limits := l.chatLimits(ctx, msg.Chat.ID)
+	l.countMessage(ctx, msg.Chat.ID, l.getTopicID(msg), limits.MaxMsgBuffer)
+}
+
+// buildMessage creates the stored model of an incoming message with the given text
+func (l *Listener) buildMessage(msg *telego.Message, text string) *models.Message {
 	var lastName *string
 	if msg.From.LastName != "" {
 		lastName = &msg.From.LastName
@@ -141,85 +473,200 @@ func (l *Listener) handleMessage(ctx context.Context, msg *telego.Message) {
 		UserFirstName: msg.From.FirstName,
 		UserLastName:  lastName,
 		Username:      username,
-		Text:          &messageText,
-		CreatedAt:     time.Now(),
+		Text:          &text,
+		CreatedAt:     messageTime(msg.Date),
 	}
 
-	// Save message to database
-	if err := l.repo.SaveMessage(ctx, message); err != nil {
-		l.logger.ErrorContext(ctx, "Failed to save message", slog.Any("error", err),
+	if l.config.App.App.StoreForwardOrigin {
+		if origin := forwardOriginName(msg.ForwardOrigin); origin != "" {
+			message.ForwardOrigin = &origin
+		}
+	}
+
+	if msg.ReplyToMessage != nil {
+		replyToID := int64(msg.ReplyToMessage.MessageID)
+		message.ReplyToMsgID = &replyToID
+		message.ReplyToBot = l.isFromBot(msg.ReplyToMessage)
+	}
+
+	return message
+}
+
+// messageTime converts a Telegram message date to UTC, falling back to the current time for
+// messages without one
+func messageTime(date int64) time.Time {
+	if date == 0 {
+		return time.Now().UTC()
+	}
+	return time.Unix(date, 0).UTC()
+}
+
+// handleEditedMessage keeps the stored text of an edited message current. Messages that were
+// never stored are saved as new ones. Edits are not counted towards summarization and
+// neither commands nor mentions are handled again.
+func (l *Listener) handleEditedMessage(ctx context.Context, msg *telego.Message) {
+	if msg.From == nil || msg.From.IsBot {
+		return
+	}
+
+	messageText := l.getMessageText(msg)
+	if messageText == "" || l.isCommand(messageText) {
+		return
+	}
+
+	isAllowed, err := l.repo.IsAllowedChat(ctx, msg.Chat.ID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to check allowed chat", slog.Any("error", err),
 			slog.Int64("chat_id", msg.Chat.ID),
-			slog.Int64("user_id", msg.From.ID),
 		)
 		return
 	}
+	if !isAllowed {
+		return
+	}
 
-	// Check if message is a mention or reply to bot
-	isMention := l.isMentionOrReply(msg)
+	editedAt := messageTime(msg.EditDate)
 
-	if isMention {
-		// Handle mention/reply in separate goroutine
-		go l.handleMention(ctx, msg)
+	updated, err := l.repo.UpdateMessageText(ctx, msg.Chat.ID, int64(msg.MessageID), messageText, editedAt)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to update edited message", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+		return
+	}
+	if updated {
+		return
 	}
 
-	// Increment message counter and check if we need to summarize
-	topicID := l.getTopicID(msg)
-	count, err := l.repo.IncrementMessageCounter(ctx, msg.Chat.ID, topicID)
+	message := l.buildMessage(msg, messageText)
+	message.EditedAt = &editedAt
+
+	if _, err := l.repo.SaveMessage(ctx, message); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to save edited message", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+	}
+}
+
+// countMessage increments the chat/topic counter and triggers summarization when it reaches maxMsgBuffer
+func (l *Listener) countMessage(ctx context.Context, chatID int64, topicID *int64, maxMsgBuffer int) {
+	count, err := l.counter.Increment(ctx, chatID, topicID)
 	if err != nil {
 		l.logger.ErrorContext(ctx, "Failed to increment message counter", slog.Any("error", err),
-			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("chat_id", chatID),
 			slog.Any("topic_id", topicID),
 		)
 		return
 	}
 
 	l.logger.InfoContext(ctx, "Message counter incremented",
-		slog.Int64("chat_id", msg.Chat.ID),
+		slog.Int64("chat_id", chatID),
 		slog.Any("topic_id", topicID),
 		slog.Int("count", count),
-		slog.Int("limit", l.config.App.Limits.MaxMsgBuffer),
+		slog.Int("limit", maxMsgBuffer),
 	)
 
-	if count >= l.config.App.Limits.MaxMsgBuffer {
+	if count >= maxMsgBuffer {
 		// Reset counter and trigger summarization for this specific topic
-		if err := l.repo.ResetMessageCounter(ctx, msg.Chat.ID, topicID); err != nil {
+		if err := l.counter.Reset(ctx, chatID, topicID); err != nil {
 			l.logger.ErrorContext(ctx, "Failed to reset message counter", slog.Any("error", err),
-				slog.Int64("chat_id", msg.Chat.ID),
+				slog.Int64("chat_id", chatID),
 				slog.Any("topic_id", topicID),
 			)
 			return
 		}
 
 		l.logger.InfoContext(ctx, "Triggering topic-specific summarization",
-			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("chat_id", chatID),
 			slog.Any("topic_id", topicID),
 		)
 
 		// Publish summarization event for specific topic
-		if err := l.publishSummarizeEvent(ctx, msg.Chat.ID, topicID); err != nil {
+		if err := l.publishSummarizeEvent(ctx, chatID, topicID); err != nil {
 			l.logger.ErrorContext(ctx, "Failed to publish summarize event", slog.Any("error", err),
-				slog.Int64("chat_id", msg.Chat.ID),
+				slog.Int64("chat_id", chatID),
 				slog.Any("topic_id", topicID),
 			)
 		} else {
 			l.logger.InfoContext(ctx, "Summarize event published successfully",
-				slog.Int64("chat_id", msg.Chat.ID),
+				slog.Int64("chat_id", chatID),
 				slog.Any("topic_id", topicID),
 			)
 		}
 	}
 }
 
+// chatLimits returns the summarization thresholds of the chat, cached for chatLimitsTTL, or the
+// global ones on errors
+func (l *Listener) chatLimits(ctx context.Context, chatID int64) repo.ChatLimits {
+	now := time.Now()
+	if limits, ok := l.limits.Get(chatID, now); ok {
+		return limits
+	}
+
+	limits, err := l.repo.GetChatLimits(ctx, chatID, defaultChatLimits(l.config))
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get chat limits", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return limits
+	}
+
+	l.limits.Set(chatID, limits, now)
+	return limits
+}
+
+// defaultChatLimits returns the global summarization thresholds
+func defaultChatLimits(cfg *config.Config) repo.ChatLimits {
+	return repo.ChatLimits{
+		MaxMsgBuffer:         cfg.App.Limits.MaxMsgBuffer,
+		SummarizeMaxMessages: cfg.App.Limits.SummarizeMaxMessages,
+	}
+}
+
+// seedUserProfile creates a minimal user summary if the user has none yet
+func (l *Listener) seedUserProfile(ctx context.Context, msg *models.Message) {
+	created, err := l.repo.SeedUserSummary(ctx, msg.ChatID, msg.UserID, msg.Username, msg.UserFirstName, msg.UserLastName)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to seed user profile", slog.Any("error", err),
+			slog.Int64("chat_id", msg.ChatID),
+			slog.Int64("user_id", msg.UserID),
+		)
+		return
+	}
+
+	if created {
+		l.logger.InfoContext(ctx, "Seeded user profile from first message",
+			slog.Int64("chat_id", msg.ChatID),
+			slog.Int64("user_id", msg.UserID),
+		)
+	}
+}
+
+// isFromBot reports whether the message was sent by this bot, as configured by mention_username
+func (l *Listener) isFromBot(msg *telego.Message) bool {
+	if msg.From == nil || !msg.From.IsBot {
+		return false
+	}
+	return strings.EqualFold(msg.From.Username, strings.TrimPrefix(l.config.App.App.MentionUsername, "@"))
+}
+
+// botID returns the Telegram user ID of the bot (0 if unknown)
+func (l *Listener) botID() int64 {
+	if l.bot == nil {
+		return 0
+	}
+	return l.bot.ID()
+}
+
 // isMentionOrReply checks if message mentions the bot or is a reply to bot
 func (l *Listener) isMentionOrReply(msg *telego.Message) bool {
-	// Check for bot mention
-	for _, entity := range msg.Entities {
-		if entity.Type == "mention" {
-			mentionText := msg.Text[entity.Offset : entity.Offset+entity.Length]
-			if mentionText == l.config.App.App.MentionUsername {
-				return true
-			}
-		}
+	// Check for bot mention; with other users mentioned too, answer only if configured
+	botMentioned, othersMentioned := classifyMentions(msg, botMentionNames(l.config), l.botID())
+	if botMentioned && (!othersMentioned || l.config.App.App.ReplyWithOtherMentions) {
+		return true
 	}
 
 	// Check if it's a reply to bot message
@@ -240,8 +687,8 @@ func (l *Listener) isMentionOrReply(msg *telego.Message) bool {
 	return false
 }
 
-// handleMention handles mentions and replies to the bot
-func (l *Listener) handleMention(ctx context.Context, msg *telego.Message) {
+// handleMention handles mentions and replies to the bot; text is the stored message text
+func (l *Listener) handleMention(ctx context.Context, msg *telego.Message, text string) {
 	topicID := l.getTopicID(msg)
 	l.logger.InfoContext(ctx, "Handling mention",
 		slog.Int64("chat_id", msg.Chat.ID),
@@ -250,8 +697,17 @@ func (l *Listener) handleMention(ctx context.Context, msg *telego.Message) {
 		slog.Int("message_thread_id", msg.MessageThreadID),
 	)
 
+	if !l.mentions.Allow(msg.Chat.ID, msg.From.ID) {
+		l.logger.InfoContext(ctx, "Mention rate limit exceeded, skipping",
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int64("user_id", msg.From.ID),
+		)
+		l.reactRateLimited(ctx, msg)
+		return
+	}
+
 	// Publish mention event for handler to process
-	if err := l.publishMentionEvent(ctx, msg); err != nil {
+	if err := l.publishMentionEvent(ctx, msg, text); err != nil {
 		l.logger.ErrorContext(ctx, "Failed to publish mention event", slog.Any("error", err),
 			slog.Int64("chat_id", msg.Chat.ID),
 			slog.Int64("user_id", msg.From.ID),
@@ -260,8 +716,43 @@ func (l *Listener) handleMention(ctx context.Context, msg *telego.Message) {
 	}
 }
 
+// reactRateLimited sets the configured reaction on a mention over the rate limit
+func (l *Listener) reactRateLimited(ctx context.Context, msg *telego.Message) {
+	reaction := l.config.App.Limits.MentionRateReaction
+	if reaction == "" || !l.reactionsEnabled(ctx, msg.Chat.ID) {
+		return
+	}
+
+	if err := setReaction(ctx, l.sender, msg.Chat.ID, int64(msg.MessageID), reaction); err != nil {
+		l.logger.WarnContext(ctx, "Failed to set rate limit reaction", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+	}
+}
+
+// runMentionLimiterCleanup periodically drops idle users from the mention limiter
+func (l *Listener) runMentionLimiterCleanup(ctx context.Context) {
+	ticker := time.NewTicker(max(time.Duration(l.config.App.Limits.MentionRateWindowSeconds)*time.Second, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mentions.Cleanup()
+		}
+	}
+}
+
 // publishSummarizeEvent publishes event to trigger summarization
 func (l *Listener) publishSummarizeEvent(ctx context.Context, chatID int64, topicID *int64) error {
+	return publishSummarizeEvent(l.publisher, chatID, topicID)
+}
+
+// publishSummarizeEvent publishes a summarize event for the chat topic
+func publishSummarizeEvent(publisher message.Publisher, chatID int64, topicID *int64) error {
 	event := SummarizeEvent{
 		ChatID:    chatID,
 		TopicID:   topicID,
@@ -274,11 +765,12 @@ func (l *Listener) publishSummarizeEvent(ctx context.Context, chatID int64, topi
 	}
 
 	msg := message.NewMessage(watermill.NewUUID(), msgData)
-	return l.publisher.Publish("summarize", msg)
+	return publisher.Publish("summarize", msg)
 }
 
-// publishMentionEvent publishes event to handle mention
-func (l *Listener) publishMentionEvent(ctx context.Context, msg *telego.Message) error {
+// publishMentionEvent publishes event to handle mention. text is the message text as stored:
+// the text, the caption or the voice transcript.
+func (l *Listener) publishMentionEvent(ctx context.Context, msg *telego.Message, text string) error {
 	// Build username string
 	username := ""
 	if msg.From.Username != "" {
@@ -299,7 +791,7 @@ func (l *Listener) publishMentionEvent(ctx context.Context, msg *telego.Message)
 		Username:  username,
 		LastName:  lastName,
 		MessageID: int64(msg.MessageID),
-		Text:      msg.Text,
+		Text:      stripBotTextMentions(text, textEntities(msg, text), l.botID()),
 		Timestamp: time.Now(),
 	}
 
@@ -330,16 +822,136 @@ func (l *Listener) publishMentionEvent(ctx context.Context, msg *telego.Message)
 	return l.publisher.Publish("mention", msgWatermill)
 }
 
-// ResetCountersForAllChats resets message counters for all chats (used at midnight)
-func (l *Listener) ResetCountersForAllChats() {
-	ctx := context.Background()
+// runCounterFlusher periodically flushes in-memory message counters to the database
+func (l *Listener) runCounterFlusher(ctx context.Context, counter *memoryCounter) {
+	ticker := time.NewTicker(time.Duration(l.config.App.Limits.CounterFlushSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Final flush so a clean shutdown keeps counts
+			if err := counter.Flush(context.Background()); err != nil {
+				l.logger.ErrorContext(ctx, "Failed to flush message counters on shutdown", slog.Any("error", err))
+			}
+			return
+		case <-ticker.C:
+			if err := counter.Flush(ctx); err != nil {
+				l.logger.ErrorContext(ctx, "Failed to flush message counters", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// trackIdentity records the sender identity in the chat and writes it right away
+// when no flush interval is set
+func (l *Listener) trackIdentity(ctx context.Context, message *models.Message) {
+	changed := l.identities.Observe(models.UserIdentity{
+		ChatID:    message.ChatID,
+		UserID:    message.UserID,
+		Username:  message.Username,
+		FirstName: message.UserFirstName,
+		LastName:  message.UserLastName,
+	})
+	if !changed || l.config.App.Limits.IdentityFlushSeconds > 0 {
+		return
+	}
+
+	if err := l.identities.Flush(ctx); err != nil {
+		l.logger.ErrorContext(ctx, "Failed to update user identity", slog.Any("error", err),
+			slog.Int64("chat_id", message.ChatID),
+			slog.Int64("user_id", message.UserID),
+		)
+	}
+}
+
+// runIdentityFlusher periodically writes changed user identities to the database
+func (l *Listener) runIdentityFlusher(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(l.config.App.Limits.IdentityFlushSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := l.identities.Flush(context.Background()); err != nil {
+				l.logger.ErrorContext(ctx, "Failed to flush user identities on shutdown", slog.Any("error", err))
+			}
+			return
+		case <-ticker.C:
+			if err := l.identities.Flush(ctx); err != nil {
+				l.logger.ErrorContext(ctx, "Failed to flush user identities", slog.Any("error", err))
+			}
+		}
+	}
+}
 
-	if err := l.repo.ResetAllMessageCounters(ctx); err != nil {
-		l.logger.ErrorContext(ctx, "Failed to reset all message counters", slog.Any("error", err))
+// SummarizeBacklog publishes summarize events for chat topics that collected a full buffer of
+// messages since their last summary, e.g. while the bot was down. The router must be running.
+func (l *Listener) SummarizeBacklog(ctx context.Context) {
+	if !l.config.App.Limits.SummarizeOnStartup {
+		return
+	}
+
+	backlog, err := l.repo.GetSummarizeBacklog(ctx, l.config.App.Limits.MaxMsgBuffer, l.config.App.Limits.SummarizeOnStartupMaxChats)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to get summarize backlog", slog.Any("error", err))
 		return
 	}
 
-	l.logger.InfoContext(ctx, "Reset message counters for all chats")
+	published := l.publishBacklogSummaries(ctx, backlog)
+
+	l.logger.InfoContext(ctx, "Published startup summarization for backlogged chats",
+		slog.Int("backlogged", len(backlog)),
+		slog.Int("published", published),
+	)
+}
+
+// publishBacklogSummaries resets counters and publishes a summarize event for each backlogged
+// chat topic, returning the number of published events
+func (l *Listener) publishBacklogSummaries(ctx context.Context, backlog []*repo.ChatBacklog) int {
+	published := 0
+	for _, b := range backlog {
+		if err := l.counter.Reset(ctx, b.ChatID, b.TopicID); err != nil {
+			l.logger.ErrorContext(ctx, "Failed to reset message counter", slog.Any("error", err),
+				slog.Int64("chat_id", b.ChatID),
+				slog.Any("topic_id", b.TopicID),
+			)
+		}
+
+		if err := l.publishSummarizeEvent(ctx, b.ChatID, b.TopicID); err != nil {
+			l.logger.ErrorContext(ctx, "Failed to publish summarize event", slog.Any("error", err),
+				slog.Int64("chat_id", b.ChatID),
+				slog.Any("topic_id", b.TopicID),
+			)
+			continue
+		}
+		published++
+	}
+
+	return published
+}
+
+// ResetCountersForAllChats resets message counters for all chats (used at midnight) following
+// the configured reset policy
+func (l *Listener) ResetCountersForAllChats() {
+	ctx := context.Background()
+
+	switch l.config.App.Limits.MidnightCounterReset {
+	case CounterResetNone:
+		l.logger.InfoContext(ctx, "Message counters kept at midnight")
+	case CounterResetOnlyGeneral:
+		if err := l.counter.ResetGeneral(ctx); err != nil {
+			l.logger.ErrorContext(ctx, "Failed to reset general message counters", slog.Any("error", err))
+			return
+		}
+		l.logger.InfoContext(ctx, "Reset general topic message counters for all chats")
+	default:
+		if err := l.counter.ResetAll(ctx); err != nil {
+			l.logger.ErrorContext(ctx, "Failed to reset all message counters", slog.Any("error", err))
+			return
+		}
+		l.logger.InfoContext(ctx, "Reset message counters for all chats")
+	}
 }
 
 // handleNewMembers processes new chat member events
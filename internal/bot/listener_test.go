@@ -0,0 +1,474 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/migrations"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+func TestGetMessageTextSticker(t *testing.T) {
+	cfg := &config.Config{}
+	l := &Listener{config: cfg}
+	msg := &telego.Message{Sticker: &telego.Sticker{Emoji: "😂"}}
+
+	if text := l.getMessageText(msg); text != "" {
+		t.Errorf("Expected sticker to be skipped when disabled, got %q", text)
+	}
+
+	cfg.App.App.StoreEmojiSignals = true
+	if text := l.getMessageText(msg); text != "[sticker: 😂]" {
+		t.Errorf("Expected sticker marker when enabled, got %q", text)
+	}
+}
+
+func TestGetMessageTextEmojiOnly(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.StoreEmojiSignals = true
+	l := &Listener{config: cfg}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"😂😂", "[emoji: 😂😂]"},
+		{" 👍🏽 ", "[emoji: 👍🏽]"},
+		{"❤️", "[emoji: ❤️]"},
+		{"🇷🇸", "[emoji: 🇷🇸]"},
+		{"👨‍👩‍👧", "[emoji: 👨‍👩‍👧]"},
+		{"⭐☀️", "[emoji: ⭐☀️]"},
+		{"ok 👍", "ok 👍"},
+		{"^_^", "^_^"},
+		// Symbols that are not emoji
+		{"©", "©"},
+		{"® ™", "® ™"},
+		{"°", "°"},
+		{"→", "→"},
+		{"↔", "↔"},
+		{"─┼─", "─┼─"},
+	}
+
+	for _, tt := range tests {
+		if got := l.getMessageText(&telego.Message{Text: tt.text}); got != tt.want {
+			t.Errorf("getMessageText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestForwardOriginName(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin telego.MessageOrigin
+		want   string
+	}{
+		{"none", nil, ""},
+		{"user", &telego.MessageOriginUser{SenderUser: telego.User{FirstName: "Ann", LastName: "Lee", Username: "ann"}}, "Ann Lee (@ann)"},
+		{"hidden user", &telego.MessageOriginHiddenUser{SenderUserName: "Secret Sam"}, "Secret Sam"},
+		{"chat", &telego.MessageOriginChat{SenderChat: telego.Chat{Title: "Go Devs"}, AuthorSignature: "admin"}, "Go Devs, admin"},
+		{"channel", &telego.MessageOriginChannel{Chat: telego.Chat{Title: "Tech News", Username: "technews"}}, "Tech News (@technews)"},
+	}
+
+	for _, tt := range tests {
+		if got := forwardOriginName(tt.origin); got != tt.want {
+			t.Errorf("%s: forwardOriginName() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPublishBacklogSummaries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+
+	messages, err := pubSub.Subscribe(ctx, "summarize")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	counter := newMemoryCounter(&fakeCounterStore{})
+	topicID := int64(5)
+	for i := 0; i < 4; i++ {
+		_, _ = counter.Increment(ctx, 42, &topicID)
+	}
+
+	l := &Listener{
+		config:    &config.Config{},
+		publisher: pubSub,
+		counter:   counter,
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	backlog := []*repo.ChatBacklog{{ChatID: 42, TopicID: &topicID, MessageCount: 120}}
+	if published := l.publishBacklogSummaries(ctx, backlog); published != 1 {
+		t.Fatalf("Expected 1 published event, got %d", published)
+	}
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		event, err := UnmarshalSummarizeEvent(msg.Payload)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal summarize event: %v", err)
+		}
+		if event.ChatID != 42 || event.TopicID == nil || *event.TopicID != topicID {
+			t.Errorf("Unexpected summarize event: %+v", event)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected a summarize event for the backlogged chat")
+	}
+
+	if count, _ := counter.Increment(ctx, 42, &topicID); count != 1 {
+		t.Errorf("Expected counter to be reset before summarization, got %d", count)
+	}
+}
+
+func TestIsFromBot(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.MentionUsername = "@william_bot"
+	l := &Listener{config: cfg}
+
+	if !l.isFromBot(&telego.Message{From: &telego.User{IsBot: true, Username: "William_Bot"}}) {
+		t.Error("Expected a message from the configured bot to match")
+	}
+	if l.isFromBot(&telego.Message{From: &telego.User{IsBot: true, Username: "other_bot"}}) {
+		t.Error("Expected a message from another bot not to match")
+	}
+	if l.isFromBot(&telego.Message{From: &telego.User{Username: "william_bot"}}) {
+		t.Error("Expected a user account not to match")
+	}
+}
+
+func TestPollWaitsForInflightHandlers(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.ShutdownTimeoutSeconds = 5
+	l := &Listener{config: cfg, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan telego.Update)
+	started := make(chan struct{})
+	var handled atomic.Int32
+	handle := func(ctx context.Context, update telego.Update) {
+		close(started)
+		// Simulate a database write still running when shutdown begins
+		time.Sleep(50 * time.Millisecond)
+		if ctx.Err() == nil {
+			handled.Add(1)
+		}
+	}
+
+	source := func(ctx context.Context, offset int) (<-chan telego.Update, error) {
+		return updates, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.poll(ctx, source, handle)
+		close(done)
+	}()
+
+	updates <- telego.Update{UpdateID: 1}
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected poll to return after in-flight handlers finished")
+	}
+	if handled.Load() != 1 {
+		t.Error("Expected the in-flight handler to complete with a live context before poll returned")
+	}
+}
+
+func TestBuildMessageUsesTelegramDate(t *testing.T) {
+	l := &Listener{config: &config.Config{}}
+	sent := time.Date(2025, 3, 2, 18, 30, 0, 0, time.UTC)
+	msg := &telego.Message{
+		MessageID: 1,
+		Date:      sent.Unix(),
+		Chat:      telego.Chat{ID: -100},
+		From:      &telego.User{ID: 7, FirstName: "Ann"},
+	}
+
+	message := l.buildMessage(msg, "hello")
+	if !message.CreatedAt.Equal(sent) || message.CreatedAt.Location() != time.UTC {
+		t.Errorf("Expected the Telegram message time %v in UTC, got %v", sent, message.CreatedAt)
+	}
+
+	// Messages without a date fall back to the current time
+	msg.Date = 0
+	if message := l.buildMessage(msg, "hello"); time.Since(message.CreatedAt) > time.Minute {
+		t.Errorf("Expected the current time for a message without date, got %v", message.CreatedAt)
+	}
+}
+
+func TestPollReconnectsAfterUpdatesClose(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.ShutdownTimeoutSeconds = 5
+	cfg.App.Telegram.ReconnectBaseDelayMs = 1
+	cfg.App.Telegram.ReconnectMaxDelayMs = 10
+	l := &Listener{config: cfg, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first connection delivers an update then fails, the second one fails to open,
+	// the third resumes after the last received update
+	var offsets []int
+	source := func(ctx context.Context, offset int) (<-chan telego.Update, error) {
+		offsets = append(offsets, offset)
+		updates := make(chan telego.Update, 1)
+		switch len(offsets) {
+		case 1:
+			updates <- telego.Update{UpdateID: 41}
+			close(updates)
+		case 2:
+			return nil, errors.New("connection refused")
+		default:
+			updates <- telego.Update{UpdateID: 42}
+		}
+		return updates, nil
+	}
+
+	handled := make(chan int, 2)
+	handle := func(ctx context.Context, update telego.Update) {
+		handled <- update.UpdateID
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.poll(ctx, source, handle)
+		close(done)
+	}()
+
+	for _, want := range []int{41, 42} {
+		select {
+		case got := <-handled:
+			if got != want {
+				t.Errorf("Expected update %d, got %d", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected update %d after reconnecting", want)
+		}
+	}
+
+	cancel()
+	<-done
+	if !reflect.DeepEqual(offsets, []int{0, 42, 42}) {
+		t.Errorf("Expected reconnects to resume from offset 42, got %v", offsets)
+	}
+}
+
+func TestReconnectDelay(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, time.Second
+
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := reconnectDelay(base, maxDelay, attempt)
+		if delay < want/2 || delay > want {
+			t.Errorf("Attempt %d: expected delay within [%v, %v], got %v", attempt, want/2, want, delay)
+		}
+	}
+
+	if delay := reconnectDelay(base, maxDelay, 1000); delay > maxDelay {
+		t.Errorf("Expected delay capped at %v, got %v", maxDelay, delay)
+	}
+}
+
+// newTestListener connects to TEST_PG_DSN, applies migrations and builds a listener for an allowed
+// chat, skipping the test if unset. Rows of the chat are deleted on cleanup.
+func newTestListener(t *testing.T, cfg *config.Config, chatID int64, logger *slog.Logger) *Listener {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PG_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+	if err := migrations.Run(ctx, sqlDB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	r := repo.New(pool, nil)
+	if err := r.AddAllowedChat(ctx, chatID, "test"); err != nil {
+		t.Fatalf("AddAllowedChat failed: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, table := range []string{"messages", "message_counters", "users", "user_summaries", "handled_commands", "chat_settings", "allowed_chats"} {
+			_, _ = pool.Exec(ctx, "DELETE FROM "+table+" WHERE chat_id = $1", chatID)
+		}
+	})
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	t.Cleanup(func() { _ = pubSub.Close() })
+
+	return New(nil, r, cfg, pubSub, nil, nil, nil, logger)
+}
+
+func TestHandleMessageIgnoresDuplicateUpdate(t *testing.T) {
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	cfg := &config.Config{}
+	cfg.App.Limits.MaxMsgBuffer = 100
+	l := newTestListener(t, cfg, chatID, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	msg := &telego.Message{
+		MessageID: 10,
+		Date:      time.Now().Unix(),
+		Chat:      telego.Chat{ID: chatID, Type: "supergroup"},
+		From:      &telego.User{ID: 1, FirstName: "Alice"},
+		Text:      "hello",
+	}
+
+	l.handleMessage(ctx, msg)
+	l.handleMessage(ctx, msg)
+
+	messages, err := l.repo.GetLatestMessagesByChatID(ctx, chatID, 10)
+	if err != nil {
+		t.Fatalf("GetLatestMessagesByChatID failed: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("Expected one stored message, got %d", len(messages))
+	}
+
+	count, err := l.repo.GetMessageCounter(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetMessageCounter failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected counter incremented once, got %d", count)
+	}
+}
+
+func TestHandleMessageThrottledStillCountsAndAnswers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	chatID := -time.Now().UnixNano()
+	cfg := &config.Config{}
+	cfg.App.Limits.MaxMsgBuffer = 100
+	cfg.App.Limits.IngestMaxPerSecond = 1
+	cfg.App.App.MentionUsername = "@william_bot"
+	l := newTestListener(t, cfg, chatID, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	mentions, err := l.publisher.(*gochannel.GoChannel).Subscribe(ctx, "mention")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// A flood: every message after the first one in the second is throttled
+	text := "@william_bot hi"
+	for id := 1; id <= 3; id++ {
+		l.handleMessage(ctx, &telego.Message{
+			MessageID: id,
+			Date:      time.Now().Unix(),
+			Chat:      telego.Chat{ID: chatID, Type: "supergroup"},
+			From:      &telego.User{ID: 1, FirstName: "Alice"},
+			Text:      text,
+			Entities:  []telego.MessageEntity{{Type: "mention", Offset: 0, Length: len("@william_bot")}},
+		})
+	}
+
+	if count, err := l.repo.GetMessageCounter(ctx, chatID); err != nil || count != 3 {
+		t.Errorf("Expected throttled messages to be counted, got %d (%v)", count, err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-mentions:
+			msg.Ack()
+		case <-ctx.Done():
+			t.Fatalf("Expected every mention to be published under a flood, got %d", i)
+		}
+	}
+}
+
+func TestHandleMessageIgnoresDuplicateCommand(t *testing.T) {
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	var logs bytes.Buffer
+	l := newTestListener(t, &config.Config{}, chatID, slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	// A disabled command is handled without calling Telegram
+	if err := l.repo.SetChatDisabledCommands(ctx, chatID, []string{"/help"}); err != nil {
+		t.Fatalf("SetChatDisabledCommands failed: %v", err)
+	}
+
+	msg := &telego.Message{
+		MessageID: 11,
+		Date:      time.Now().Unix(),
+		Chat:      telego.Chat{ID: chatID, Type: "supergroup"},
+		From:      &telego.User{ID: 1, FirstName: "Alice"},
+		Text:      "/help",
+	}
+
+	l.handleMessage(ctx, msg)
+	l.handleMessage(ctx, msg)
+
+	if n := strings.Count(logs.String(), "Command disabled in chat"); n != 1 {
+		t.Errorf("Expected command handled once, got %d", n)
+	}
+	if n := strings.Count(logs.String(), "Duplicate command ignored"); n != 1 {
+		t.Errorf("Expected redelivered command ignored once, got %d", n)
+	}
+}
+
+func TestHandleMessageSeedsUserProfile(t *testing.T) {
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	cfg := &config.Config{}
+	cfg.App.App.SeedUserProfiles = true
+	cfg.App.Limits.MaxMsgBuffer = 100
+	l := newTestListener(t, cfg, chatID, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	l.handleMessage(ctx, &telego.Message{
+		MessageID: 12,
+		Date:      time.Now().Unix(),
+		Chat:      telego.Chat{ID: chatID, Type: "supergroup"},
+		From:      &telego.User{ID: 7, FirstName: "Alice", LastName: "Smith", Username: "alice"},
+		Text:      "hello",
+	})
+
+	summary, err := l.repo.GetLatestUserSummary(ctx, chatID, 7)
+	if err != nil {
+		t.Fatalf("GetLatestUserSummary failed: %v", err)
+	}
+	if summary == nil {
+		t.Fatal("Expected a seeded user profile after the first message")
+	}
+	if summary.FirstName == nil || *summary.FirstName != "Alice" || summary.Username == nil || *summary.Username != "alice" {
+		t.Errorf("Expected identity fields in the seeded profile, got %+v", summary)
+	}
+
+	identity, err := l.repo.GetUserIdentity(ctx, chatID, 7)
+	if err != nil {
+		t.Fatalf("GetUserIdentity failed: %v", err)
+	}
+	if identity == nil || identity.FirstName != "Alice" {
+		t.Errorf("Expected the user identity stored after the first message, got %+v", identity)
+	}
+}
@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+)
+
+// botMentionNames returns the @usernames the bot answers to: mention_username and its aliases
+func botMentionNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.App.App.MentionAliases)+1)
+	for _, name := range append([]string{cfg.App.App.MentionUsername}, cfg.App.App.MentionAliases...) {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isBotMentionName reports whether the @username is one of the bot names (case-insensitive)
+func isBotMentionName(mention string, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(mention, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityText returns the text covered by a message entity; entity offsets are in UTF-16 code units
+func entityText(text string, entity telego.MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	if entity.Offset < 0 || entity.Length < 0 || entity.Offset+entity.Length > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[entity.Offset : entity.Offset+entity.Length]))
+}
+
+// isBotTextMention reports whether the entity is a text_mention of the bot user (botID 0 = unknown)
+func isBotTextMention(entity telego.MessageEntity, botID int64) bool {
+	return entity.Type == "text_mention" && entity.User != nil && botID != 0 && entity.User.ID == botID
+}
+
+// classifyMentions reports whether the message mentions the bot and whether it mentions anyone else.
+// @username mentions match the bot names; text_mention entities match the bot user ID.
+func classifyMentions(msg *telego.Message, names []string, botID int64) (botMentioned, othersMentioned bool) {
+	// Media messages carry their mentions in the caption
+	text, entities := msg.Text, msg.Entities
+	if text == "" {
+		text, entities = msg.Caption, msg.CaptionEntities
+	}
+
+	for _, entity := range entities {
+		switch entity.Type {
+		case "mention":
+			if isBotMentionName(entityText(text, entity), names) {
+				botMentioned = true
+			} else {
+				othersMentioned = true
+			}
+		case "text_mention":
+			if isBotTextMention(entity, botID) {
+				botMentioned = true
+			} else {
+				othersMentioned = true
+			}
+		}
+	}
+	return botMentioned, othersMentioned
+}
+
+// stripBotTextMentions removes the text of text_mention entities referencing the bot, which
+// carry a display name rather than an @username stripMentions could match
+func stripBotTextMentions(text string, entities []telego.MessageEntity, botID int64) string {
+	units := utf16.Encode([]rune(text))
+	stripped := false
+	// Remove from the end so earlier offsets stay valid
+	for i := len(entities) - 1; i >= 0; i-- {
+		entity := entities[i]
+		if !isBotTextMention(entity, botID) || entity.Offset < 0 || entity.Length < 0 || entity.Offset+entity.Length > len(units) {
+			continue
+		}
+		units = append(units[:entity.Offset], units[entity.Offset+entity.Length:]...)
+		stripped = true
+	}
+	if !stripped {
+		return text
+	}
+	return strings.TrimSpace(extraSpaces.ReplaceAllString(string(utf16.Decode(units)), " "))
+}
+
+// textEntities returns the entities of the message text or caption the text was taken from,
+// nil for a text of another origin such as a voice transcript
+func textEntities(msg *telego.Message, text string) []telego.MessageEntity {
+	switch {
+	case msg.Text != "" && text == msg.Text:
+		return msg.Entities
+	case msg.Caption != "" && text == msg.Caption:
+		return msg.CaptionEntities
+	default:
+		return nil
+	}
+}
+
+// extraSpaces matches runs of spaces left behind by removed mentions
+var extraSpaces = regexp.MustCompile(`[ \t]{2,}`)
+
+// stripMentions removes every occurrence of the bot names from the text, keeping other mentions
+func stripMentions(text string, names []string) string {
+	for _, name := range names {
+		// Only whole names: @william must not cut the prefix of @william_fan
+		re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(name) + `($|[^\p{L}\p{N}_])`)
+		text = re.ReplaceAllString(text, "$1")
+	}
+	return strings.TrimSpace(extraSpaces.ReplaceAllString(text, " "))
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+)
+
+// mentionMessage builds a message with mention entities for the given @usernames in the text
+func mentionMessage(text string, mentions ...string) *telego.Message {
+	msg := &telego.Message{Text: text, Chat: telego.Chat{ID: 1}, From: &telego.User{ID: 2}}
+	for _, mention := range mentions {
+		offset := len(utf16.Encode([]rune(text[:strings.Index(text, mention)])))
+		msg.Entities = append(msg.Entities, telego.MessageEntity{
+			Type: "mention", Offset: offset, Length: len(utf16.Encode([]rune(mention))),
+		})
+	}
+	return msg
+}
+
+func TestStripMentionsKeepsOtherUsers(t *testing.T) {
+	names := []string{"@william_bot", "@old_william_bot"}
+
+	got := stripMentions("@William_bot спроси @alice и @old_william_bot про @william_bot_fan", names)
+	want := "спроси @alice и про @william_bot_fan"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestIsMentionOrReplyMultipleMentions(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.App.MentionUsername = "@william_bot"
+	cfg.App.App.MentionAliases = []string{"@old_william_bot"}
+	l := &Listener{config: cfg, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	// Cyrillic text before the mentions checks UTF-16 entity offsets
+	multi := mentionMessage("Привет @alice и @old_william_bot", "@alice", "@old_william_bot")
+
+	cfg.App.App.ReplyWithOtherMentions = true
+	if !l.isMentionOrReply(multi) {
+		t.Error("Expected a reply to a message mentioning the bot among others")
+	}
+
+	cfg.App.App.ReplyWithOtherMentions = false
+	if l.isMentionOrReply(multi) {
+		t.Error("Expected no reply to a message mentioning other users when disabled")
+	}
+	if !l.isMentionOrReply(mentionMessage("Привет @william_bot", "@william_bot")) {
+		t.Error("Expected a reply to a message mentioning only the bot")
+	}
+}
+
+func TestClassifyMentionsEntityTypes(t *testing.T) {
+	names := []string{"@william_bot", "@old_william_bot"}
+	const botID = 100
+
+	textMention := func(text, name string, userID int64) *telego.Message {
+		msg := &telego.Message{Text: text}
+		msg.Entities = append(msg.Entities, telego.MessageEntity{
+			Type:   "text_mention",
+			Offset: len(utf16.Encode([]rune(text[:strings.Index(text, name)]))),
+			Length: len(utf16.Encode([]rune(name))),
+			User:   &telego.User{ID: userID},
+		})
+		return msg
+	}
+
+	tests := []struct {
+		name       string
+		msg        *telego.Message
+		wantBot    bool
+		wantOthers bool
+	}{
+		{"mention", mentionMessage("@william_bot привет", "@william_bot"), true, false},
+		{"mention different case", mentionMessage("@William_Bot привет", "@William_Bot"), true, false},
+		{"mention alias", mentionMessage("@OLD_william_bot привет", "@OLD_william_bot"), true, false},
+		{"mention other user", mentionMessage("@alice привет", "@alice"), false, true},
+		{"text_mention bot", textMention("Лемур-тян, привет", "Лемур-тян", botID), true, false},
+		{"text_mention other user", textMention("Алиса, привет", "Алиса", 7), false, true},
+		{"no entities", &telego.Message{Text: "william_bot привет"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, others := classifyMentions(tt.msg, names, botID)
+			if bot != tt.wantBot || others != tt.wantOthers {
+				t.Errorf("Expected bot=%v others=%v, got bot=%v others=%v", tt.wantBot, tt.wantOthers, bot, others)
+			}
+		})
+	}
+
+	// Without a known bot ID a text_mention can't be matched to the bot
+	if bot, _ := classifyMentions(textMention("Лемур-тян, привет", "Лемур-тян", botID), names, 0); bot {
+		t.Error("Expected no bot mention with an unknown bot ID")
+	}
+}
+
+func TestStripBotTextMentions(t *testing.T) {
+	text := "Спроси Лемур-тян и Алису про релиз"
+	msg := &telego.Message{Text: text}
+	for _, mention := range []struct {
+		name   string
+		userID int64
+	}{{"Лемур-тян", 100}, {"Алису", 7}} {
+		msg.Entities = append(msg.Entities, telego.MessageEntity{
+			Type:   "text_mention",
+			Offset: len(utf16.Encode([]rune(text[:strings.Index(text, mention.name)]))),
+			Length: len(utf16.Encode([]rune(mention.name))),
+			User:   &telego.User{ID: mention.userID},
+		})
+	}
+
+	if got, want := stripBotTextMentions(text, msg.Entities, 100), "Спроси и Алису про релиз"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := stripBotTextMentions(text, msg.Entities, 0); got != text {
+		t.Errorf("Expected text unchanged with an unknown bot ID, got %q", got)
+	}
+}
+
+func TestPublishMentionEventUsesStoredText(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	defer pubSub.Close()
+	mentions, err := pubSub.Subscribe(ctx, "mention")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.App.App.MentionUsername = "@william_bot"
+	l := &Listener{config: cfg, publisher: pubSub, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	// A photo mentioning the bot in its caption
+	photo := mentionMessage("@william_bot what is this?", "@william_bot")
+	photo.Caption, photo.CaptionEntities, photo.Text, photo.Entities = photo.Text, photo.Entities, "", nil
+	if !l.isMentionOrReply(photo) {
+		t.Error("Expected a caption mention to be detected")
+	}
+
+	// A voice reply is published with its transcript
+	voice := &telego.Message{Chat: telego.Chat{ID: 1}, From: &telego.User{ID: 2}, Voice: &telego.Voice{FileID: "voice"}}
+
+	for _, tt := range []struct {
+		msg  *telego.Message
+		text string
+	}{
+		{photo, l.getMessageText(photo)},
+		{voice, "[voice: 0:03] what is this?"},
+	} {
+		if err := l.publishMentionEvent(ctx, tt.msg, tt.text); err != nil {
+			t.Fatalf("publishMentionEvent failed: %v", err)
+		}
+		select {
+		case msg := <-mentions:
+			msg.Ack()
+			event, err := UnmarshalMentionEvent(msg.Payload)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal mention event: %v", err)
+			}
+			if event.Text != tt.text {
+				t.Errorf("Expected mention text %q, got %q", tt.text, event.Text)
+			}
+		case <-ctx.Done():
+			t.Fatal("Expected a mention event")
+		}
+	}
+}
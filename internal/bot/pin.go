@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// messagePinner pins and unpins chat messages (implemented by *telego.Bot)
+type messagePinner interface {
+	PinChatMessage(ctx context.Context, params *telego.PinChatMessageParams) error
+	UnpinChatMessage(ctx context.Context, params *telego.UnpinChatMessageParams) error
+}
+
+// postPinnedSummary posts the latest summary of the chat topic, pins it in place of
+// the previously pinned summary and records the new message id
+func postPinnedSummary(ctx context.Context, r *repo.Repository, sender *Sender, pinner messagePinner, chatID int64, topicID *int64) error {
+	summary, err := r.GetLatestChatSummaryByTopic(ctx, chatID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat summary: %w", err)
+	}
+	if summary == nil {
+		return repo.ErrChatSummaryNotFound
+	}
+
+	settings, err := r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	lang := settings.UILanguage
+	if !isSupportedLanguage(lang) {
+		lang = defaultLanguage
+	}
+
+	params := &telego.SendMessageParams{
+		ChatID: telego.ChatID{ID: chatID},
+		Text:   formatPinnedSummary(summary, lang),
+	}
+	if topicID != nil {
+		params.MessageThreadID = int(*topicID)
+	}
+
+	sent, err := sender.SendMessage(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to send summary: %w", err)
+	}
+
+	var previousID int
+	if settings.PinnedSummaryMessageID != nil {
+		previousID = int(*settings.PinnedSummaryMessageID)
+	}
+
+	if err := replacePinnedMessage(ctx, sender, pinner, chatID, sent.MessageID, previousID); err != nil {
+		return err
+	}
+
+	messageID := int64(sent.MessageID)
+	if err := r.SetChatPinnedSummary(ctx, chatID, topicID, &messageID); err != nil {
+		return fmt.Errorf("failed to save pinned summary: %w", err)
+	}
+
+	return nil
+}
+
+// replacePinnedMessage pins the new message and then unpins the previous one (0 = none).
+// A failed unpin is ignored since the old message may have been unpinned or deleted manually.
+func replacePinnedMessage(ctx context.Context, sender *Sender, pinner messagePinner, chatID int64, messageID, previousID int) error {
+	err := sender.Do(ctx, chatID, func(ctx context.Context) error {
+		return pinner.PinChatMessage(ctx, &telego.PinChatMessageParams{
+			ChatID:              telego.ChatID{ID: chatID},
+			MessageID:           messageID,
+			DisableNotification: true,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pin summary: %w", err)
+	}
+
+	if previousID == 0 || previousID == messageID {
+		return nil
+	}
+
+	_ = sender.Do(ctx, chatID, func(ctx context.Context) error {
+		return pinner.UnpinChatMessage(ctx, &telego.UnpinChatMessageParams{
+			ChatID:    telego.ChatID{ID: chatID},
+			MessageID: previousID,
+		})
+	})
+
+	return nil
+}
+
+// formatPinnedSummary formats a chat summary with its upcoming events for pinning
+func formatPinnedSummary(summary *models.ChatSummary, lang string) string {
+	var sb strings.Builder
+	sb.WriteString(translate(lang, "pin.title") + "\n\n")
+	sb.WriteString(summary.Summary)
+
+	if len(summary.NextEventsJSON) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(formatEventsResponse(summary.NextEventsJSON, lang))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mymmrac/telego"
+)
+
+// fakePinner records pin/unpin calls in order
+type fakePinner struct {
+	calls  []string
+	pinErr error
+}
+
+func (p *fakePinner) PinChatMessage(ctx context.Context, params *telego.PinChatMessageParams) error {
+	p.calls = append(p.calls, fmt.Sprintf("pin %d/%d", params.ChatID.ID, params.MessageID))
+	return p.pinErr
+}
+
+func (p *fakePinner) UnpinChatMessage(ctx context.Context, params *telego.UnpinChatMessageParams) error {
+	p.calls = append(p.calls, fmt.Sprintf("unpin %d/%d", params.ChatID.ID, params.MessageID))
+	return errors.New("message to unpin not found")
+}
+
+func TestReplacePinnedMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		previousID int
+		pinErr     error
+		expected   []string
+		wantErr    bool
+	}{
+		{"pins new then unpins previous", 10, nil, []string{"pin 42/20", "unpin 42/10"}, false},
+		{"no previous pin", 0, nil, []string{"pin 42/20"}, false},
+		{"same message", 20, nil, []string{"pin 42/20"}, false},
+		{"pin failure keeps previous", 10, errors.New("not enough rights"), []string{"pin 42/20"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinner := &fakePinner{pinErr: tt.pinErr}
+
+			err := replacePinnedMessage(context.Background(), NewSender(nil, 0), pinner, 42, 20, tt.previousID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("replacePinnedMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(pinner.calls) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected calls %v, got %v", tt.expected, pinner.calls)
+			}
+		})
+	}
+}
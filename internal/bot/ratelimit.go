@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// mentionLimiter limits mentions answered per user in a chat with a sliding window
+type mentionLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	hits map[mentionKey][]time.Time
+}
+
+// mentionKey identifies a user in a chat
+type mentionKey struct {
+	chatID int64
+	userID int64
+}
+
+// newMentionLimiter creates a limiter of limit mentions per window (limit <= 0 disables it)
+// reading the time from now
+func newMentionLimiter(limit int, window time.Duration, now func() time.Time) *mentionLimiter {
+	return &mentionLimiter{
+		limit:  limit,
+		window: window,
+		now:    now,
+		hits:   make(map[mentionKey][]time.Time),
+	}
+}
+
+// Allow reports whether the user's mention may be answered and records it.
+// Mentions over the limit are not recorded, so they don't extend the wait.
+func (m *mentionLimiter) Allow(chatID, userID int64) bool {
+	if m == nil || m.limit <= 0 {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	key := mentionKey{chatID: chatID, userID: userID}
+	hits := m.recentHits(key, now)
+	if len(hits) >= m.limit {
+		m.hits[key] = hits
+		return false
+	}
+
+	m.hits[key] = append(hits, now)
+	return true
+}
+
+// Cleanup drops users without mentions in the current window
+func (m *mentionLimiter) Cleanup() {
+	if m == nil || m.limit <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key := range m.hits {
+		if hits := m.recentHits(key, now); len(hits) > 0 {
+			m.hits[key] = hits
+		} else {
+			delete(m.hits, key)
+		}
+	}
+}
+
+// recentHits returns the user's mentions within the window ending at now
+func (m *mentionLimiter) recentHits(key mentionKey, now time.Time) []time.Time {
+	hits := m.hits[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= m.window {
+		i++
+	}
+	return hits[i:]
+}
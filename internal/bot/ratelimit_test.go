@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMentionLimiterSlidingWindow(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	limiter := newMentionLimiter(2, time.Minute, func() time.Time { return now })
+
+	if !limiter.Allow(1, 10) {
+		t.Fatal("Expected the first mention to be allowed")
+	}
+	now = now.Add(10 * time.Second)
+	if !limiter.Allow(1, 10) {
+		t.Fatal("Expected mentions within the limit to be allowed")
+	}
+	if limiter.Allow(1, 10) {
+		t.Error("Expected a mention over the limit to be rejected")
+	}
+	if !limiter.Allow(1, 11) || !limiter.Allow(2, 10) {
+		t.Error("Expected other users and chats not to be limited")
+	}
+
+	// The window slides: the first mention expires, the rejected one didn't count
+	now = now.Add(20 * time.Second)
+	if limiter.Allow(1, 10) {
+		t.Error("Expected the user to stay limited within the window")
+	}
+	now = now.Add(30 * time.Second)
+	if !limiter.Allow(1, 10) {
+		t.Error("Expected a mention to be allowed once the oldest one left the window")
+	}
+	if limiter.Allow(1, 10) {
+		t.Error("Expected the window to be full again")
+	}
+
+	now = now.Add(2 * time.Minute)
+	limiter.Cleanup()
+	if len(limiter.hits) != 0 {
+		t.Errorf("Expected idle users to be cleaned up, got %v", limiter.hits)
+	}
+}
+
+func TestMentionLimiterDisabled(t *testing.T) {
+	limiter := newMentionLimiter(0, time.Minute, time.Now)
+	for i := 0; i < 10; i++ {
+		if !limiter.Allow(1, 10) {
+			t.Fatal("Expected no limit when disabled")
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+// Sender serializes outbound messages per chat so they are delivered in
+// enqueue order and spaced by a minimum interval to respect Telegram limits
+type Sender struct {
+	bot      *telego.Bot
+	interval time.Duration
+
+	mu     sync.Mutex
+	queues map[int64]*chatQueue
+}
+
+// chatQueue holds pending sends for a single chat
+type chatQueue struct {
+	jobs     []sendJob
+	running  bool
+	lastSent time.Time
+}
+
+// sendJob is a single queued send operation
+type sendJob struct {
+	ctx  context.Context
+	send func(ctx context.Context) error
+	done chan error
+}
+
+// NewSender creates a new per-chat outbound message queue
+func NewSender(bot *telego.Bot, interval time.Duration) *Sender {
+	return &Sender{
+		bot:      bot,
+		interval: interval,
+		queues:   make(map[int64]*chatQueue),
+	}
+}
+
+// SendMessage queues a message for the chat and waits until it is sent
+func (s *Sender) SendMessage(ctx context.Context, params *telego.SendMessageParams) (*telego.Message, error) {
+	var sent *telego.Message
+	err := s.Do(ctx, params.ChatID.ID, func(ctx context.Context) error {
+		var err error
+		sent, err = s.bot.SendMessage(ctx, params)
+		return err
+	})
+	return sent, err
+}
+
+// Do queues an arbitrary send operation for the chat and waits for its result
+func (s *Sender) Do(ctx context.Context, chatID int64, send func(ctx context.Context) error) error {
+	done := s.enqueue(ctx, chatID, send)
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue adds a send operation to the chat queue and starts draining it if idle
+func (s *Sender) enqueue(ctx context.Context, chatID int64, send func(ctx context.Context) error) <-chan error {
+	job := sendJob{ctx: ctx, send: send, done: make(chan error, 1)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.queues[chatID]
+	if !ok {
+		q = &chatQueue{}
+		s.queues[chatID] = q
+	}
+
+	q.jobs = append(q.jobs, job)
+	if !q.running {
+		q.running = true
+		go s.drain(chatID, q)
+	}
+
+	return job.done
+}
+
+// drain processes queued sends for a chat one by one until the queue is empty. The drained
+// queue is removed once the interval since its last send has passed, so idle chats don't
+// keep a queue and a send right after the last one is still spaced.
+func (s *Sender) drain(chatID int64, q *chatQueue) {
+	for {
+		s.mu.Lock()
+		if len(q.jobs) == 0 {
+			if wait := s.interval - time.Since(q.lastSent); wait > 0 {
+				s.mu.Unlock()
+				time.Sleep(wait)
+				continue
+			}
+			q.running = false
+			delete(s.queues, chatID)
+			s.mu.Unlock()
+			return
+		}
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		wait := s.interval - time.Since(q.lastSent)
+		s.mu.Unlock()
+
+		if job.ctx.Err() != nil {
+			job.done <- job.ctx.Err()
+			continue
+		}
+
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+
+		err := job.send(job.ctx)
+
+		s.mu.Lock()
+		q.lastSent = time.Now()
+		s.mu.Unlock()
+
+		job.done <- err
+	}
+}
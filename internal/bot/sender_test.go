@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSenderPreservesOrderWithSpacing(t *testing.T) {
+	interval := 20 * time.Millisecond
+	s := NewSender(nil, interval)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var order []int
+	var times []time.Time
+
+	var results []<-chan error
+	for i := 0; i < 5; i++ {
+		i := i
+		results = append(results, s.enqueue(ctx, 42, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, i)
+			times = append(times, time.Now())
+			return nil
+		}))
+	}
+
+	for _, done := range results {
+		if err := <-done; err != nil {
+			t.Fatalf("Unexpected send error: %v", err)
+		}
+	}
+
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("Expected messages in enqueue order, got %v", order)
+		}
+	}
+
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < interval {
+			t.Errorf("Expected at least %v between sends, got %v", interval, gap)
+		}
+	}
+}
+
+func TestSenderRemovesDrainedQueues(t *testing.T) {
+	interval := 20 * time.Millisecond
+	s := NewSender(nil, interval)
+	ctx := context.Background()
+
+	var sent []time.Time
+	send := func(ctx context.Context) error {
+		sent = append(sent, time.Now())
+		return nil
+	}
+
+	if err := s.Do(ctx, 42, send); err != nil {
+		t.Fatalf("Unexpected send error: %v", err)
+	}
+
+	// A send right after the queue drained is still spaced by the interval
+	if err := s.Do(ctx, 42, send); err != nil {
+		t.Fatalf("Unexpected send error: %v", err)
+	}
+	if gap := sent[1].Sub(sent[0]); gap < interval {
+		t.Errorf("Expected at least %v between sends, got %v", interval, gap)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		queues := len(s.queues)
+		s.mu.Unlock()
+		if queues == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the drained queue to be removed, got %d queues", queues)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
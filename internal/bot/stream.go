@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+// responseStream shows a streamed mention response as one Telegram message that is sent with the
+// first partial text and then edited as more text arrives, at most once per interval
+type responseStream struct {
+	interval time.Duration
+	now      func() time.Time
+	send     func(ctx context.Context, text string) (*telego.Message, *int64, error)
+	edit     func(ctx context.Context, messageID int, text string) error
+	remove   func(ctx context.Context, messageID int) error
+	logger   *slog.Logger
+
+	sent       *telego.Message
+	topicID    *int64
+	shown      string
+	lastUpdate time.Time
+	failed     bool
+}
+
+// newResponseStream creates a stream replying to the mention event
+func (h *Handlers) newResponseStream(event MentionEvent) *responseStream {
+	return &responseStream{
+		interval: time.Duration(h.config.App.Telegram.StreamEditIntervalMs) * time.Millisecond,
+		now:      time.Now,
+		send: func(ctx context.Context, text string) (*telego.Message, *int64, error) {
+			return h.sendResponseMessage(ctx, event.ChatID, event.TopicID, h.replyTarget(event), text)
+		},
+		edit: func(ctx context.Context, messageID int, text string) error {
+			return h.sender.Do(ctx, event.ChatID, func(ctx context.Context) error {
+				_, err := h.bot.EditMessageText(ctx, &telego.EditMessageTextParams{
+					ChatID:    telego.ChatID{ID: event.ChatID},
+					MessageID: messageID,
+					Text:      text,
+				})
+				return err
+			})
+		},
+		remove: func(ctx context.Context, messageID int) error {
+			return h.sender.Do(ctx, event.ChatID, func(ctx context.Context) error {
+				return h.bot.DeleteMessage(ctx, &telego.DeleteMessageParams{
+					ChatID:    telego.ChatID{ID: event.ChatID},
+					MessageID: messageID,
+				})
+			})
+		},
+		logger: h.logger,
+	}
+}
+
+// update shows the partial text, skipping updates that come sooner than the interval after the last one
+func (s *responseStream) update(ctx context.Context, text string) {
+	if s.failed || text == s.shown || (s.sent != nil && s.now().Sub(s.lastUpdate) < s.interval) {
+		return
+	}
+
+	if s.sent == nil {
+		sent, topicID, err := s.send(ctx, text)
+		if err != nil {
+			// Give up streaming; the complete response is sent as a new message
+			s.logger.WarnContext(ctx, "Failed to send streamed response", slog.Any("error", err))
+			s.failed = true
+			return
+		}
+		s.sent, s.topicID = sent, topicID
+	} else if err := s.edit(ctx, s.sent.MessageID, text); err != nil && !isMessageNotModified(err) {
+		s.logger.WarnContext(ctx, "Failed to edit streamed response", slog.Any("error", err),
+			slog.Int("message_id", s.sent.MessageID),
+		)
+		return
+	}
+
+	s.shown = text
+	s.lastUpdate = s.now()
+}
+
+// finish shows the complete text, sending a new message if nothing was streamed.
+// Returns the message holding the response and its topic.
+func (s *responseStream) finish(ctx context.Context, text string) (*telego.Message, *int64, error) {
+	if s.sent == nil {
+		return s.send(ctx, text)
+	}
+
+	if text != s.shown {
+		if err := s.edit(ctx, s.sent.MessageID, text); err != nil && !isMessageNotModified(err) {
+			return nil, nil, err
+		}
+	}
+	return s.sent, s.topicID, nil
+}
+
+// discard deletes the partially streamed message when no response is sent in the end
+func (s *responseStream) discard(ctx context.Context) {
+	if s == nil || s.sent == nil {
+		return
+	}
+
+	if err := s.remove(ctx, s.sent.MessageID); err != nil {
+		s.logger.WarnContext(ctx, "Failed to delete streamed response", slog.Any("error", err),
+			slog.Int("message_id", s.sent.MessageID),
+		)
+	}
+	s.sent = nil
+}
+
+// isMessageNotModified reports whether Telegram rejected an edit that would not change the text
+func isMessageNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
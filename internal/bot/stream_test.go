@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+// fakeStream records the Telegram calls of a response stream driven by a manual clock
+type fakeStream struct {
+	now     time.Time
+	sends   []string
+	edits   []string
+	removed []int
+	sendErr error
+}
+
+func (f *fakeStream) stream(interval time.Duration) *responseStream {
+	return &responseStream{
+		interval: interval,
+		now:      func() time.Time { return f.now },
+		send: func(ctx context.Context, text string) (*telego.Message, *int64, error) {
+			if f.sendErr != nil {
+				return nil, nil, f.sendErr
+			}
+			f.sends = append(f.sends, text)
+			return &telego.Message{MessageID: 10}, nil, nil
+		},
+		edit: func(ctx context.Context, messageID int, text string) error {
+			f.edits = append(f.edits, text)
+			return nil
+		},
+		remove: func(ctx context.Context, messageID int) error {
+			f.removed = append(f.removed, messageID)
+			return nil
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestResponseStreamThrottlesEdits(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeStream{now: time.Unix(0, 0)}
+	s := f.stream(1500 * time.Millisecond)
+
+	s.update(ctx, "He")
+	f.now = f.now.Add(500 * time.Millisecond)
+	s.update(ctx, "Hello")
+	f.now = f.now.Add(time.Second)
+	s.update(ctx, "Hello th")
+
+	if len(f.sends) != 1 || f.sends[0] != "He" {
+		t.Errorf("Expected the first partial to be sent, got %q", f.sends)
+	}
+	if len(f.edits) != 1 || f.edits[0] != "Hello th" {
+		t.Errorf("Expected one throttled edit, got %q", f.edits)
+	}
+
+	sent, _, err := s.finish(ctx, "Hello there")
+	if err != nil {
+		t.Fatalf("finish returned error: %v", err)
+	}
+	if sent.MessageID != 10 || f.edits[len(f.edits)-1] != "Hello there" {
+		t.Errorf("Expected final edit with the full text, got %q", f.edits)
+	}
+}
+
+func TestResponseStreamFailedSendFallsBackToNewMessage(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeStream{now: time.Unix(0, 0), sendErr: errors.New("boom")}
+	s := f.stream(time.Second)
+
+	s.update(ctx, "partial")
+	f.sendErr = nil
+	s.update(ctx, "partial text")
+
+	if len(f.sends) != 0 {
+		t.Errorf("Expected streaming to stop after a failed send, got %q", f.sends)
+	}
+
+	if _, _, err := s.finish(ctx, "full text"); err != nil {
+		t.Fatalf("finish returned error: %v", err)
+	}
+	if len(f.sends) != 1 || f.sends[0] != "full text" {
+		t.Errorf("Expected the full response as a new message, got %q", f.sends)
+	}
+}
+
+func TestResponseStreamDiscard(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeStream{now: time.Unix(0, 0)}
+	s := f.stream(time.Second)
+
+	s.update(ctx, "partial")
+	s.discard(ctx)
+	if len(f.removed) != 1 || f.removed[0] != 10 {
+		t.Errorf("Expected the streamed message to be deleted, got %v", f.removed)
+	}
+
+	var none *responseStream
+	none.discard(ctx)
+}
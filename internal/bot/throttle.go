@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// ingestThrottle limits per-chat message processing to a fixed number of messages per second
+type ingestThrottle struct {
+	limit int
+
+	mu      sync.Mutex
+	windows map[int64]*rateWindow
+}
+
+// rateWindow counts messages seen in the current one-second window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newIngestThrottle(limit int) *ingestThrottle {
+	return &ingestThrottle{
+		limit:   limit,
+		windows: make(map[int64]*rateWindow),
+	}
+}
+
+// Allow reports whether a message in the chat may be processed (limit <= 0 disables throttling)
+func (t *ingestThrottle) Allow(chatID int64, now time.Time) bool {
+	if t == nil || t.limit <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window, ok := t.windows[chatID]
+	if !ok || now.Sub(window.start) >= time.Second {
+		t.windows[chatID] = &rateWindow{start: now, count: 1}
+		return true
+	}
+
+	window.count++
+	return window.count <= t.limit
+}
+
+// commandCooldown limits how often a user may run the same command in a chat
+type commandCooldown struct {
+	cooldown time.Duration
+
+	mu      sync.Mutex
+	lastRun map[commandKey]time.Time
+}
+
+// commandKey identifies a user's command in a chat
+type commandKey struct {
+	chatID  int64
+	userID  int64
+	command string
+}
+
+// cooldownPruneSize is the number of tracked commands above which expired ones are dropped
+const cooldownPruneSize = 1000
+
+func newCommandCooldown(cooldown time.Duration) *commandCooldown {
+	return &commandCooldown{
+		cooldown: cooldown,
+		lastRun:  make(map[commandKey]time.Time),
+	}
+}
+
+// Allow reports whether the user may run the command now and records the run
+// (cooldown <= 0 disables the limit). Throttled attempts don't extend the cooldown.
+func (c *commandCooldown) Allow(chatID, userID int64, command string, now time.Time) bool {
+	if c == nil || c.cooldown <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := commandKey{chatID: chatID, userID: userID, command: command}
+	if last, ok := c.lastRun[key]; ok && now.Sub(last) < c.cooldown {
+		return false
+	}
+
+	if len(c.lastRun) >= cooldownPruneSize {
+		for k, last := range c.lastRun {
+			if now.Sub(last) >= c.cooldown {
+				delete(c.lastRun, k)
+			}
+		}
+	}
+	c.lastRun[key] = now
+	return true
+}
+
+// summaryRefreshInterval is the minimum time between stale summary refreshes of a chat topic,
+// long enough for the scheduled summarization to finish
+const summaryRefreshInterval = 10 * time.Minute
+
+// refreshDebounce limits stale summary refreshes to one per chat topic within an interval
+type refreshDebounce struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	scheduled map[counterKey]time.Time
+}
+
+func newRefreshDebounce(interval time.Duration) *refreshDebounce {
+	return &refreshDebounce{
+		interval:  interval,
+		scheduled: make(map[counterKey]time.Time),
+	}
+}
+
+// Allow reports whether a refresh of the chat topic may be scheduled now and records it
+func (d *refreshDebounce) Allow(chatID int64, topicID *int64, now time.Time) bool {
+	if d == nil {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := newCounterKey(chatID, topicID)
+	if last, ok := d.scheduled[key]; ok && now.Sub(last) < d.interval {
+		return false
+	}
+
+	if len(d.scheduled) >= cooldownPruneSize {
+		for k, last := range d.scheduled {
+			if now.Sub(last) >= d.interval {
+				delete(d.scheduled, k)
+			}
+		}
+	}
+	d.scheduled[key] = now
+	return true
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestIngestThrottleFlood(t *testing.T) {
+	throttle := newIngestThrottle(5)
+	now := time.Now()
+
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		if throttle.Allow(1, now.Add(time.Duration(i)*time.Millisecond)) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("Expected 5 messages processed during flood, got %d", allowed)
+	}
+
+	// Other chats are not affected by the flood
+	if !throttle.Allow(2, now) {
+		t.Error("Expected other chat to be processed")
+	}
+
+	// Processing resumes in the next window
+	if !throttle.Allow(1, now.Add(time.Second)) {
+		t.Error("Expected processing to resume after one second")
+	}
+}
+
+func TestIngestThrottleDisabled(t *testing.T) {
+	throttle := newIngestThrottle(0)
+	now := time.Now()
+
+	for i := 0; i < 100; i++ {
+		if !throttle.Allow(1, now) {
+			t.Fatal("Expected disabled throttle to allow all messages")
+		}
+	}
+}
+
+func TestCommandCooldown(t *testing.T) {
+	cooldown := newCommandCooldown(5 * time.Second)
+	now := time.Now()
+
+	if !cooldown.Allow(1, 10, "/stats", now) {
+		t.Fatal("Expected the first run to be allowed")
+	}
+	if cooldown.Allow(1, 10, "/stats", now.Add(time.Second)) {
+		t.Error("Expected a repeated command on cooldown to be throttled")
+	}
+	if !cooldown.Allow(1, 10, "/help", now.Add(time.Second)) || !cooldown.Allow(1, 11, "/stats", now.Add(time.Second)) {
+		t.Error("Expected other commands and users not to be throttled")
+	}
+	if !cooldown.Allow(1, 10, "/stats", now.Add(5*time.Second)) {
+		t.Error("Expected the command to be allowed after the cooldown")
+	}
+	if !newCommandCooldown(0).Allow(1, 10, "/stats", now) || !newCommandCooldown(0).Allow(1, 10, "/stats", now) {
+		t.Error("Expected no limit with zero cooldown")
+	}
+}
+
+func TestRefreshDebounce(t *testing.T) {
+	debounce := newRefreshDebounce(10 * time.Minute)
+	now := time.Now()
+	topicID := int64(7)
+
+	if !debounce.Allow(1, &topicID, now) {
+		t.Fatal("Expected the first refresh to be scheduled")
+	}
+	if debounce.Allow(1, &topicID, now.Add(time.Minute)) {
+		t.Error("Expected a repeated refresh of the same topic to be debounced")
+	}
+	if !debounce.Allow(1, nil, now.Add(time.Minute)) || !debounce.Allow(2, &topicID, now.Add(time.Minute)) {
+		t.Error("Expected other topics and chats not to be debounced")
+	}
+	if !debounce.Allow(1, &topicID, now.Add(10*time.Minute)) {
+		t.Error("Expected a refresh after the interval")
+	}
+}
+
+func TestThrottleFeedback(t *testing.T) {
+	msg := &telego.Message{MessageID: 7, Chat: telego.Chat{ID: -100}, From: &telego.User{ID: 1}}
+
+	tests := []struct {
+		feedback         string
+		reactionsEnabled bool
+		want             string // substring of the single API call body, "" = no call
+	}{
+		{"reaction", true, `"emoji":"🥱"`},
+		{"reaction", false, ""},
+		{"text", true, translate(LanguageEnglish, "error.throttled")},
+		{"silent", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.feedback, func(t *testing.T) {
+			fake := &fakeCaller{}
+			tg, err := telego.NewBot("123456:"+strings.Repeat("a", 35), telego.WithAPICaller(fake), telego.WithDiscardLogger())
+			if err != nil {
+				t.Fatalf("Failed to create bot: %v", err)
+			}
+
+			cfg := &config.Config{}
+			cfg.App.Commands.ThrottleFeedback = tt.feedback
+			cfg.App.Commands.ThrottleReaction = "🥱"
+			l := &Listener{
+				bot:    tg,
+				config: cfg,
+				sender: NewSender(tg, 0),
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			l.sendThrottleFeedback(context.Background(), msg, LanguageEnglish, tt.reactionsEnabled)
+
+			if tt.want == "" {
+				if fake.calls != 0 {
+					t.Errorf("Expected no API calls, got %v", fake.bodies)
+				}
+				return
+			}
+			if fake.calls != 1 || !strings.Contains(fake.bodies[0], tt.want) {
+				t.Errorf("Expected a single call with %q, got %v", tt.want, fake.bodies)
+			}
+			if tt.feedback == "reaction" && strings.Contains(fake.bodies[0], `"text"`) {
+				t.Errorf("Expected a reaction instead of a text message, got %v", fake.bodies)
+			}
+		})
+	}
+}
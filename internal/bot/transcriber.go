@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mymmrac/telego"
+)
+
+// Transcriber converts the audio of a voice or video note into text
+type Transcriber interface {
+	// Transcribe returns the text spoken in the Telegram file ("" if not available)
+	Transcribe(ctx context.Context, fileID string) (string, error)
+}
+
+// NoopTranscriber is the default transcriber; it never produces text,
+// so voice and video notes are stored as placeholders only
+type NoopTranscriber struct{}
+
+// Transcribe always returns an empty transcript
+func (NoopTranscriber) Transcribe(context.Context, string) (string, error) {
+	return "", nil
+}
+
+// voiceNote describes the audio attached to a message
+type voiceNote struct {
+	kind     string
+	fileID   string
+	duration int
+}
+
+// getVoiceNote returns the voice message or video note of the message, or nil
+func getVoiceNote(msg *telego.Message) *voiceNote {
+	switch {
+	case msg.Voice != nil:
+		return &voiceNote{kind: "voice", fileID: msg.Voice.FileID, duration: msg.Voice.Duration}
+	case msg.VideoNote != nil:
+		return &voiceNote{kind: "video note", fileID: msg.VideoNote.FileID, duration: msg.VideoNote.Duration}
+	default:
+		return nil
+	}
+}
+
+// voiceNoteText returns the text stored for a voice or video note: a placeholder with the duration,
+// followed by the transcript when the transcriber provides one. Transcription errors keep the placeholder.
+func (l *Listener) voiceNoteText(ctx context.Context, msg *telego.Message, note *voiceNote) string {
+	text := fmt.Sprintf("[%s: %d:%02d]", note.kind, note.duration/60, note.duration%60)
+
+	if l.transcriber == nil {
+		return text
+	}
+
+	transcript, err := l.transcriber.Transcribe(ctx, note.fileID)
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Failed to transcribe voice note", slog.Any("error", err),
+			slog.Int64("chat_id", msg.Chat.ID),
+			slog.Int("message_id", msg.MessageID),
+		)
+		return text
+	}
+
+	if transcript != "" {
+		text += " " + transcript
+	}
+	return text
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mymmrac/telego"
+	"github.com/xdefrag/william/internal/config"
+)
+
+// fakeTranscriber returns a fixed transcript and records requested files
+type fakeTranscriber struct {
+	transcript string
+	err        error
+	fileIDs    []string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, fileID string) (string, error) {
+	f.fileIDs = append(f.fileIDs, fileID)
+	return f.transcript, f.err
+}
+
+func TestVoiceNoteTextStoresTranscript(t *testing.T) {
+	transcriber := &fakeTranscriber{transcript: "Созвон переносим на пятницу"}
+	l := &Listener{
+		config:      &config.Config{},
+		transcriber: transcriber,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	msg := &telego.Message{Chat: telego.Chat{ID: 42}, Voice: &telego.Voice{FileID: "voice-1", Duration: 75}}
+
+	note := getVoiceNote(msg)
+	if note == nil {
+		t.Fatal("Expected a voice note")
+	}
+	if text := l.voiceNoteText(context.Background(), msg, note); text != "[voice: 1:15] Созвон переносим на пятницу" {
+		t.Errorf("Unexpected stored text %q", text)
+	}
+	if len(transcriber.fileIDs) != 1 || transcriber.fileIDs[0] != "voice-1" {
+		t.Errorf("Expected the voice file to be transcribed, got %v", transcriber.fileIDs)
+	}
+}
+
+func TestVoiceNoteTextPlaceholder(t *testing.T) {
+	l := &Listener{
+		config:      &config.Config{},
+		transcriber: NoopTranscriber{},
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	msg := &telego.Message{Chat: telego.Chat{ID: 42}, VideoNote: &telego.VideoNote{FileID: "video-1", Duration: 9}}
+
+	if text := l.voiceNoteText(context.Background(), msg, getVoiceNote(msg)); text != "[video note: 0:09]" {
+		t.Errorf("Unexpected placeholder %q", text)
+	}
+
+	l.transcriber = &fakeTranscriber{err: errors.New("backend unavailable")}
+	if text := l.voiceNoteText(context.Background(), msg, getVoiceNote(msg)); text != "[video note: 0:09]" {
+		t.Errorf("Expected placeholder on transcription error, got %q", text)
+	}
+
+	if getVoiceNote(&telego.Message{Text: "hi"}) != nil {
+		t.Error("Expected no voice note for a text message")
+	}
+}
@@ -3,11 +3,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/pelletier/go-toml/v2"
+	"github.com/xdefrag/william/internal/reactions"
 )
 
 // AppConfig holds application settings from TOML file
@@ -17,6 +20,56 @@ type AppConfig struct {
 		Description     string `toml:"description"`
 		MentionUsername string `toml:"mention_username"`
 		DefaultResponse string `toml:"default_response"`
+		// MentionAliases lists other @usernames the bot answers to, e.g. a previous bot username;
+		// mentions match mention_username and aliases case-insensitively
+		MentionAliases []string `toml:"mention_aliases"`
+		// ReplyWithOtherMentions answers messages that mention other users besides the bot.
+		// When off, such messages are left to the people mentioned; replies to the bot still count.
+		ReplyWithOtherMentions bool `toml:"reply_with_other_mentions"`
+		// ReplyStyle sets how responses refer to the mention: "quote" replies to it (default),
+		// "plain" posts without a reply and "thread" replies to the message the user replied to
+		ReplyStyle string `toml:"reply_style"`
+		// PrivateReplyEnabled sends the response to the user's private chat when the mention
+		// contains PrivateReplyKeyword; users who haven't started the bot are answered in the group
+		PrivateReplyEnabled bool   `toml:"private_reply_enabled"`
+		PrivateReplyKeyword string `toml:"private_reply_keyword"`
+		// StreamResponses shows mention responses while they are generated by editing the reply
+		// message as text arrives (OpenAI backend only)
+		StreamResponses bool `toml:"stream_responses"`
+		// SeedUserProfiles creates a minimal user summary with identity fields
+		// on a user's first message, before any summarization has run
+		SeedUserProfiles bool `toml:"seed_user_profiles"`
+		// AdminUserID is the Telegram user ID of the global bot administrator (0 = none)
+		AdminUserID int64 `toml:"admin_user_id"`
+		// EmptyCompletionResponse is sent when OpenAI returns empty content (empty = stay silent)
+		EmptyCompletionResponse string `toml:"empty_completion_response"`
+		// StoreEmojiSignals stores stickers and emoji-only messages as short text markers
+		StoreEmojiSignals bool `toml:"store_emoji_signals"`
+		// StoreForwardOrigin stores the original sender or channel of forwarded messages
+		StoreForwardOrigin bool `toml:"store_forward_origin"`
+		// HighlightBotReplies labels replies to the bot and its answers as Q&A with the assistant
+		// in the summarize prompt so these conversations get more weight in summaries
+		HighlightBotReplies bool `toml:"highlight_bot_replies"`
+		// StoreVoiceNotes stores voice messages and video notes as a placeholder with their duration,
+		// followed by the transcript when a transcriber is configured
+		StoreVoiceNotes bool `toml:"store_voice_notes"`
+		// RequireContext replies with NoContextResponse instead of asking the model
+		// when there is no chat summary and no recent messages yet
+		RequireContext bool `toml:"require_context"`
+		// NoContextResponse is the canned reply used when RequireContext is set
+		NoContextResponse string `toml:"no_context_response"`
+		// IntroTriggers are phrases that get IntroText instead of a GPT answer (empty = disabled)
+		IntroTriggers []string `toml:"intro_triggers"`
+		// IntroText supports {first_name}, {bot_name} and {bot_username} placeholders
+		IntroText string `toml:"intro_text"`
+		// AutoRepinSummary reposts and repins the summary after each summarization
+		// in chats where /pinsummary was used
+		AutoRepinSummary bool `toml:"auto_repin_summary"`
+		// CheckBotRights declines commands that need admin rights (e.g. /pinsummary) in chats where
+		// the bot lacks them, as reported on promotion or demotion or looked up on first use
+		CheckBotRights bool `toml:"check_bot_rights"`
+		// ShutdownTimeoutSeconds bounds how long shutdown waits for in-flight updates and services
+		ShutdownTimeoutSeconds int `toml:"shutdown_timeout_seconds"`
 	} `toml:"app"`
 
 	OpenAI struct {
@@ -24,26 +77,223 @@ type AppConfig struct {
 		Temperature        float64 `toml:"temperature"`
 		MaxTokensSummarize int     `toml:"max_tokens_summarize"`
 		MaxTokensResponse  int     `toml:"max_tokens_response"`
+		// MaxTokensSummarizeLimit caps the doubled max tokens a summary truncated at
+		// max_tokens_summarize is retried with (0 = no retry)
+		MaxTokensSummarizeLimit int `toml:"max_tokens_summarize_limit"`
+		// MaxRetries retries completions failed with rate-limit or 5xx errors (0 = no retries).
+		// The retry policy also applies to the Anthropic backend.
+		MaxRetries int `toml:"max_retries"`
+		// RetryBaseDelayMs is the first retry delay; it doubles per attempt plus random jitter
+		RetryBaseDelayMs int `toml:"retry_base_delay_ms"`
+		// StructuredOutput enforces the mention response JSON schema; models without structured
+		// outputs fall back to plain text parsing
+		StructuredOutput bool `toml:"structured_output"`
 	} `toml:"openai"`
 
+	LLM struct {
+		// Provider selects the completion backend: "openai" (default) or "anthropic"
+		Provider string `toml:"provider"`
+	} `toml:"llm"`
+
+	Anthropic struct {
+		Model              string  `toml:"model"`
+		Temperature        float64 `toml:"temperature"`
+		MaxTokensSummarize int     `toml:"max_tokens_summarize"`
+		MaxTokensResponse  int     `toml:"max_tokens_response"`
+	} `toml:"anthropic"`
+
 	Limits struct {
 		MaxMsgBuffer         int `toml:"max_msg_buffer"`
 		CtxMaxTokens         int `toml:"ctx_max_tokens"`
 		RecentMessagesLimit  int `toml:"recent_messages_limit"`
 		SummarizeMaxMessages int `toml:"summarize_max_messages"`
+		// MinTopicMessages skips summarizing a chat topic with fewer messages, keeping
+		// its previous summary (0 = always summarize)
+		MinTopicMessages int `toml:"min_topic_messages"`
+		// MessagesScanLimit caps messages loaded since the last summary when building
+		// response context; only the most recent ones are kept (0 = no limit)
+		MessagesScanLimit int `toml:"messages_scan_limit"`
+		// ContextMaxAgeMinutes excludes older messages from response context (0 = no limit)
+		ContextMaxAgeMinutes int `toml:"context_max_age_minutes"`
+		// MaxTopics keeps only the top-N topics by count in chat summaries (0 = no cap)
+		MaxTopics int `toml:"max_topics"`
+		// MergeStrategy combines stored and new topic/profile counts: sum, max or ema
+		MergeStrategy string `toml:"merge_strategy"`
+		// MergeEMAAlpha is the weight of new counts for the ema merge strategy (0..1)
+		MergeEMAAlpha float64 `toml:"merge_ema_alpha"`
+		// SignalDecay is the daily multiplier applied to stale topic/profile counts (0 = no decay)
+		SignalDecay float64 `toml:"signal_decay"`
+		// SummaryStaleHours marks chat summaries older than this as stale in responses
+		// and triggers a background refresh (0 = disabled)
+		SummaryStaleHours int `toml:"summary_stale_hours"`
+		// CounterMode selects where message counters live: db or memory
+		// (memory is faster but loses unflushed counts on crash)
+		CounterMode string `toml:"counter_mode"`
+		// CounterFlushSeconds is how often in-memory counters are flushed to the database
+		CounterFlushSeconds int `toml:"counter_flush_seconds"`
+		// MidnightCounterReset selects which counters the midnight job resets: all (default),
+		// only_general (forum topics keep their partial buffers) or none
+		MidnightCounterReset string `toml:"midnight_counter_reset"`
+		// IngestMaxPerSecond caps per-chat messages that seed user profiles and track
+		// identities each second; excess messages are still stored, counted and
+		// answered (0 = no limit)
+		IngestMaxPerSecond int `toml:"ingest_max_per_second"`
+		// MentionRateLimit caps mentions answered per user in a chat within
+		// MentionRateWindowSeconds (0 = no limit); excess mentions are not answered
+		MentionRateLimit         int `toml:"mention_rate_limit"`
+		MentionRateWindowSeconds int `toml:"mention_rate_window_seconds"`
+		// MentionRateReaction is set on mentions over the limit ("" = none)
+		MentionRateReaction string `toml:"mention_rate_reaction"`
+		// PrunePastEvents drops next events dated in the past when saving summaries
+		PrunePastEvents bool `toml:"prune_past_events"`
+		// StoreChatEvents also stores next events extracted by summarization in chat_events,
+		// deduplicated by title and date; /events then lists upcoming events from there
+		StoreChatEvents bool `toml:"store_chat_events"`
+		// IdentityFlushSeconds is how often changed user identities are written to the
+		// database (0 = write on every change)
+		IdentityFlushSeconds int `toml:"identity_flush_seconds"`
+		// SummarizeOnStartup publishes summarize events at boot for chats that collected
+		// at least max_msg_buffer messages since their last summary
+		SummarizeOnStartup bool `toml:"summarize_on_startup"`
+		// SummarizeOnStartupMaxChats caps how many chat topics are summarized at boot
+		SummarizeOnStartupMaxChats int `toml:"summarize_on_startup_max_chats"`
+		// MaxUserQueryChars truncates the user query of a mention sent to the model (0 = no limit)
+		MaxUserQueryChars int `toml:"max_user_query_chars"`
+		// WelcomeCooldownMinutes skips welcoming a user who was already welcomed in the chat
+		// within this window, e.g. after a quick rejoin (0 = welcome on every join)
+		WelcomeCooldownMinutes int `toml:"welcome_cooldown_minutes"`
+		// MinDailyMessages skips midnight summarization of chats with fewer messages since the
+		// last run; their message counters are still reset (0 = summarize every active chat)
+		MinDailyMessages int `toml:"min_daily_messages"`
+		// SummarizeSampling selects a subset of a summarization batch larger than
+		// SummarizeSamplingThreshold messages: all (no sampling), head_tail or uniform
+		SummarizeSampling          string `toml:"summarize_sampling"`
+		SummarizeSamplingThreshold int    `toml:"summarize_sampling_threshold"`
+		// SummarizeWindows sizes the midnight summarization window by chat volume: chats with at
+		// least MediumChatMessages/LargeChatMessages messages since the last run summarize up to
+		// MediumMaxMessages/LargeMaxMessages, others use summarize_max_messages (0 = bucket off)
+		SummarizeWindows struct {
+			MediumChatMessages int `toml:"medium_chat_messages"`
+			MediumMaxMessages  int `toml:"medium_max_messages"`
+			LargeChatMessages  int `toml:"large_chat_messages"`
+			LargeMaxMessages   int `toml:"large_max_messages"`
+		} `toml:"summarize_windows"`
 	} `toml:"limits"`
 
+	Telegram struct {
+		// SendIntervalMs is the minimum delay between outbound messages to the same chat
+		SendIntervalMs int `toml:"send_interval_ms"`
+		// MaxConcurrentCalls caps Telegram API calls in flight across the whole bot (0 = unlimited)
+		MaxConcurrentCalls int `toml:"max_concurrent_calls"`
+		// StreamEditIntervalMs is the minimum time between edits of a streamed response
+		StreamEditIntervalMs int `toml:"stream_edit_interval_ms"`
+		// ReconnectBaseDelayMs is the first delay before reconnecting after long polling failed;
+		// it doubles per failed attempt up to ReconnectMaxDelayMs, with random jitter
+		ReconnectBaseDelayMs int `toml:"reconnect_base_delay_ms"`
+		ReconnectMaxDelayMs  int `toml:"reconnect_max_delay_ms"`
+	} `toml:"telegram"`
+
+	Commands struct {
+		// Aliases maps alternative command names to canonical ones, e.g. "/стата" = "/stats"
+		Aliases map[string]string `toml:"aliases"`
+		// FindMaxResults caps the number of summaries returned by /find and messages returned by /search
+		FindMaxResults int `toml:"find_max_results"`
+		// ExpertMaxResults caps the number of users named by /expert
+		ExpertMaxResults int `toml:"expert_max_results"`
+		// ExpertMentions mentions experts by @username instead of listing their names
+		ExpertMentions bool `toml:"expert_mentions"`
+		// RecentRepliesLimit is the default number of bot replies listed by /recentreplies
+		RecentRepliesLimit int `toml:"recent_replies_limit"`
+		// RefreshProfileMessages is the number of a user's latest messages /refreshprofile rebuilds the profile from
+		RefreshProfileMessages int `toml:"refresh_profile_messages"`
+		// CooldownSeconds is the minimum interval between runs of the same command by a user (0 = no limit)
+		CooldownSeconds int `toml:"cooldown_seconds"`
+		// ThrottleFeedback is how a command on cooldown is answered: "text" (default), "reaction"
+		// (ThrottleReaction on the command message) or "silent"
+		ThrottleFeedback string `toml:"throttle_feedback"`
+		ThrottleReaction string `toml:"throttle_reaction"`
+	} `toml:"commands"`
+
+	Search struct {
+		// Language is the full-text search configuration for /search: russian or english
+		Language string `toml:"language"`
+	} `toml:"search"`
+
+	Stats struct {
+		// UnknownUserLabel replaces "User <id>" for users without username or name (empty = show id)
+		UnknownUserLabel string `toml:"unknown_user_label"`
+		// MergeUnknownUsers combines all users without username or name into a single stats entry
+		MergeUnknownUsers bool `toml:"merge_unknown_users"`
+	} `toml:"stats"`
+
+	Log struct {
+		// PersistRawCompletions stores raw summarization completions for debugging.
+		// Off by default: completions may contain personal data.
+		PersistRawCompletions bool `toml:"persist_raw_completions"`
+		// RawCompletionRetentionDays deletes stored completions older than this at midnight (0 = keep)
+		RawCompletionRetentionDays int `toml:"raw_completion_retention_days"`
+		// PersistSummarizationRuns records chat, message count, model, tokens, duration and status
+		// of each summarization run
+		PersistSummarizationRuns bool `toml:"persist_summarization_runs"`
+	} `toml:"log"`
+
+	Events struct {
+		// MaxAttempts is how many times an event handler runs before the event is moved to
+		// the "failed" topic and recorded in failed_events
+		MaxAttempts int `toml:"max_attempts"`
+		// RetryDelayMs is the delay between handler attempts
+		RetryDelayMs int `toml:"retry_delay_ms"`
+	} `toml:"events"`
+
+	Retention struct {
+		// MessageDays deletes stored messages older than this many days after the daily
+		// summarization, keeping messages not yet covered by a summary (0 = keep forever)
+		MessageDays int `toml:"message_days"`
+	} `toml:"retention"`
+
+	Usage struct {
+		// Prices maps model names to prices per 1k tokens for cost estimates of recorded
+		// token usage; versioned model names match by prefix (e.g. "gpt-4o-mini")
+		Prices map[string]TokenPrice `toml:"prices"`
+	} `toml:"usage"`
+
 	Scheduler struct {
 		CheckIntervalMinutes int    `toml:"check_interval_minutes"`
 		Timezone             string `toml:"timezone"`
+		// DailySummaryTime is the local time of day ("HH:MM") of the daily summarization (default "00:00")
+		DailySummaryTime string `toml:"daily_summary_time"`
+		// Jobs publish events on cron schedules to one of ScheduledJobTopics. The midnight job
+		// (daily at DailySummaryTime) and the weekly_digest job always run; a configured job
+		// with the same name overrides their schedule.
+		Jobs []ScheduledJob `toml:"jobs"`
 	} `toml:"scheduler"`
 
 	Prompts struct {
 		SummarizeSystem string `toml:"summarize_system"`
 		ResponseSystem  string `toml:"response_system"`
+		// OmitLegacyFields leaves the legacy text next_events and traits out of prompts. Otherwise
+		// they are only added when the JSON fields that replaced them are empty.
+		OmitLegacyFields bool `toml:"omit_legacy_fields"`
 	} `toml:"prompts"`
 }
 
+// ScheduledJobTopics are the topics whose handlers accept events published by scheduled jobs
+var ScheduledJobTopics = []string{"midnight", "digest"}
+
+// ScheduledJob publishes an event to Topic on a cron schedule ("0 18 * * 0" or "@daily")
+// in the scheduler timezone. Name keys the job's persisted last run.
+type ScheduledJob struct {
+	Name  string `toml:"name"`
+	Cron  string `toml:"cron"`
+	Topic string `toml:"topic"`
+}
+
+// TokenPrice is the price of 1k prompt and completion tokens of a model
+type TokenPrice struct {
+	Prompt     float64 `toml:"prompt"`
+	Completion float64 `toml:"completion"`
+}
+
 // Config holds all configuration for the application
 type Config struct {
 	// Environment variables (secrets)
@@ -51,11 +301,19 @@ type Config struct {
 	OpenAIAPIKey     string
 	PostgresDSN      string
 
+	// Required only with the anthropic LLM provider
+	AnthropicAPIKey string
+
+	// Optional key for encrypting sensitive chat settings at rest
+	SettingsEncryptionKey string
+
 	// Application settings from TOML
 	App AppConfig
 
 	// Derived fields
 	Location *time.Location
+	// DailySummaryTime is the offset of the daily summarization from local midnight
+	DailySummaryTime time.Duration
 }
 
 // Load reads configuration from environment variables and TOML file
@@ -74,6 +332,9 @@ func Load() (*Config, error) {
 		OpenAIAPIKey:     os.Getenv("OPENAI_API_KEY"),
 		PostgresDSN:      os.Getenv("PG_DSN"),
 		App:              *appCfg,
+
+		SettingsEncryptionKey: os.Getenv("SETTINGS_ENCRYPTION_KEY"),
+		AnthropicAPIKey:       os.Getenv("ANTHROPIC_API_KEY"),
 	}
 
 	// Allow environment variable overrides for some settings
@@ -101,13 +362,97 @@ func Load() (*Config, error) {
 	if cfg.TelegramBotToken == "" {
 		return nil, fmt.Errorf("TG_BOT_TOKEN is required")
 	}
-	if cfg.OpenAIAPIKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY is required")
-	}
 	if cfg.PostgresDSN == "" {
 		return nil, fmt.Errorf("PG_DSN is required")
 	}
 
+	// Validate LLM provider and its API key
+	switch cfg.App.LLM.Provider {
+	case "":
+		cfg.App.LLM.Provider = "openai"
+		fallthrough
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required")
+		}
+	case "anthropic":
+		if cfg.AnthropicAPIKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY is required for anthropic provider")
+		}
+	default:
+		return nil, fmt.Errorf("invalid LLM provider %s", cfg.App.LLM.Provider)
+	}
+
+	// Validate merge strategy
+	switch cfg.App.Limits.MergeStrategy {
+	case "":
+		cfg.App.Limits.MergeStrategy = "sum"
+	case "sum", "max":
+	case "ema":
+		if cfg.App.Limits.MergeEMAAlpha <= 0 || cfg.App.Limits.MergeEMAAlpha > 1 {
+			return nil, fmt.Errorf("merge_ema_alpha must be in (0, 1] for ema merge strategy")
+		}
+	default:
+		return nil, fmt.Errorf("invalid merge strategy %s", cfg.App.Limits.MergeStrategy)
+	}
+
+	// Validate summarize sampling
+	switch cfg.App.Limits.SummarizeSampling {
+	case "":
+		cfg.App.Limits.SummarizeSampling = "all"
+	case "all":
+	case "head_tail", "uniform":
+		if cfg.App.Limits.SummarizeSamplingThreshold <= 0 {
+			return nil, fmt.Errorf("summarize_sampling_threshold must be positive for %s summarize sampling", cfg.App.Limits.SummarizeSampling)
+		}
+	default:
+		return nil, fmt.Errorf("invalid summarize sampling %s", cfg.App.Limits.SummarizeSampling)
+	}
+
+	// Validate reply style
+	switch cfg.App.App.ReplyStyle {
+	case "":
+		cfg.App.App.ReplyStyle = "quote"
+	case "quote", "plain", "thread":
+	default:
+		return nil, fmt.Errorf("invalid reply style %s", cfg.App.App.ReplyStyle)
+	}
+
+	// Validate search language
+	switch cfg.App.Search.Language {
+	case "":
+		cfg.App.Search.Language = "russian"
+	case "russian", "english":
+	default:
+		return nil, fmt.Errorf("invalid search language %s", cfg.App.Search.Language)
+	}
+
+	// Validate counter mode
+	switch cfg.App.Limits.CounterMode {
+	case "":
+		cfg.App.Limits.CounterMode = "db"
+	case "db":
+	case "memory":
+		if cfg.App.Limits.CounterFlushSeconds <= 0 {
+			return nil, fmt.Errorf("counter_flush_seconds must be positive for memory counter mode")
+		}
+	default:
+		return nil, fmt.Errorf("invalid counter mode %s", cfg.App.Limits.CounterMode)
+	}
+
+	// Validate midnight counter reset policy
+	switch cfg.App.Limits.MidnightCounterReset {
+	case "":
+		cfg.App.Limits.MidnightCounterReset = "all"
+	case "all", "only_general", "none":
+	default:
+		return nil, fmt.Errorf("invalid midnight counter reset %s", cfg.App.Limits.MidnightCounterReset)
+	}
+
+	if cfg.App.Limits.SummarizeOnStartup && cfg.App.Limits.SummarizeOnStartupMaxChats <= 0 {
+		return nil, fmt.Errorf("summarize_on_startup_max_chats must be positive when summarize_on_startup is enabled")
+	}
+
 	// Parse timezone
 	location, err := time.LoadLocation(cfg.App.Scheduler.Timezone)
 	if err != nil {
@@ -115,6 +460,75 @@ func Load() (*Config, error) {
 	}
 	cfg.Location = location
 
+	// Parse daily summary time
+	if cfg.App.Scheduler.DailySummaryTime == "" {
+		cfg.App.Scheduler.DailySummaryTime = "00:00"
+	}
+	dailyTime, err := time.Parse("15:04", cfg.App.Scheduler.DailySummaryTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid daily summary time %s: %w", cfg.App.Scheduler.DailySummaryTime, err)
+	}
+	cfg.DailySummaryTime = time.Duration(dailyTime.Hour())*time.Hour + time.Duration(dailyTime.Minute())*time.Minute
+
+	if cfg.App.Telegram.ReconnectBaseDelayMs <= 0 {
+		cfg.App.Telegram.ReconnectBaseDelayMs = 1000
+	}
+	if cfg.App.Telegram.ReconnectMaxDelayMs < cfg.App.Telegram.ReconnectBaseDelayMs {
+		cfg.App.Telegram.ReconnectMaxDelayMs = max(60000, cfg.App.Telegram.ReconnectBaseDelayMs)
+	}
+
+	if cfg.App.Events.MaxAttempts <= 0 {
+		cfg.App.Events.MaxAttempts = 3
+	}
+
+	if cfg.App.App.ShutdownTimeoutSeconds <= 0 {
+		cfg.App.App.ShutdownTimeoutSeconds = 30
+	}
+
+	if cfg.App.Limits.MentionRateLimit > 0 && cfg.App.Limits.MentionRateWindowSeconds <= 0 {
+		cfg.App.Limits.MentionRateWindowSeconds = 60
+	}
+
+	// Validate command throttle feedback
+	switch cfg.App.Commands.ThrottleFeedback {
+	case "":
+		cfg.App.Commands.ThrottleFeedback = "text"
+	case "text", "reaction", "silent":
+	default:
+		return nil, fmt.Errorf("invalid command throttle feedback %s", cfg.App.Commands.ThrottleFeedback)
+	}
+	if cfg.App.Commands.ThrottleReaction == "" {
+		cfg.App.Commands.ThrottleReaction = "🥱"
+	}
+	// Telegram rejects reactions outside its fixed set, which would silently drop the feedback
+	reaction := reactions.Emoji(cfg.App.Commands.ThrottleReaction)
+	if reaction == "" {
+		return nil, fmt.Errorf("throttle_reaction %q is not a Telegram reaction emoji", cfg.App.Commands.ThrottleReaction)
+	}
+	cfg.App.Commands.ThrottleReaction = reaction
+	if cfg.App.Limits.MentionRateReaction != "" {
+		reaction := reactions.Emoji(cfg.App.Limits.MentionRateReaction)
+		if reaction == "" {
+			return nil, fmt.Errorf("mention_rate_reaction %q is not a Telegram reaction emoji", cfg.App.Limits.MentionRateReaction)
+		}
+		cfg.App.Limits.MentionRateReaction = reaction
+	}
+
+	// Validate scheduled jobs; cron specs are parsed by the scheduler
+	jobNames := make(map[string]bool, len(cfg.App.Scheduler.Jobs))
+	for _, job := range cfg.App.Scheduler.Jobs {
+		if job.Name == "" || job.Cron == "" || job.Topic == "" {
+			return nil, fmt.Errorf("scheduled job %q requires name, cron and topic", job.Name)
+		}
+		if jobNames[job.Name] {
+			return nil, fmt.Errorf("duplicate scheduled job %s", job.Name)
+		}
+		jobNames[job.Name] = true
+		if !slices.Contains(ScheduledJobTopics, job.Topic) {
+			return nil, fmt.Errorf("scheduled job %s has unknown topic %s (expected one of %s)", job.Name, job.Topic, strings.Join(ScheduledJobTopics, ", "))
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -135,6 +549,47 @@ func loadAppConfig() (*AppConfig, error) {
 	return &config, nil
 }
 
+// redacted replaces secret values in the sanitized configuration
+const redacted = "[redacted]"
+
+// Sanitized renders the effective configuration as TOML with secrets redacted.
+// Prompts are replaced by their length to keep the output compact.
+func (c *Config) Sanitized() (string, error) {
+	app := c.App
+	app.Prompts.SummarizeSystem = fmt.Sprintf("<%d chars>", len([]rune(c.App.Prompts.SummarizeSystem)))
+	app.Prompts.ResponseSystem = fmt.Sprintf("<%d chars>", len([]rune(c.App.Prompts.ResponseSystem)))
+
+	data, err := toml.Marshal(app)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	secrets := []struct {
+		name  string
+		value string
+	}{
+		{"TG_BOT_TOKEN", c.TelegramBotToken},
+		{"OPENAI_API_KEY", c.OpenAIAPIKey},
+		{"ANTHROPIC_API_KEY", c.AnthropicAPIKey},
+		{"PG_DSN", c.PostgresDSN},
+		{"SETTINGS_ENCRYPTION_KEY", c.SettingsEncryptionKey},
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[secrets]\n")
+	for _, secret := range secrets {
+		state := "<unset>"
+		if secret.value != "" {
+			state = redacted
+		}
+		sb.WriteString(fmt.Sprintf("%s = %q\n", secret.name, state))
+	}
+	sb.WriteString("\n")
+	sb.Write(data)
+
+	return sb.String(), nil
+}
+
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -3,10 +3,12 @@ package context
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/xdefrag/william/internal/config"
 	"github.com/xdefrag/william/internal/gpt"
 	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
 )
 
 // BuildContextForResponseParams contains parameters for building context
@@ -20,12 +22,12 @@ type BuildContextForResponseParams struct {
 // Builder constructs context for GPT requests
 type Builder struct {
 	repo      *repo.Repository
-	gptClient *gpt.Client
+	gptClient gpt.Completer
 	config    *config.Config
 }
 
 // New creates a new context builder
-func New(repo *repo.Repository, gptClient *gpt.Client, cfg *config.Config) *Builder {
+func New(repo *repo.Repository, gptClient gpt.Completer, cfg *config.Config) *Builder {
 	return &Builder{
 		repo:      repo,
 		gptClient: gptClient,
@@ -53,22 +55,61 @@ func (b *Builder) BuildContextForResponse(ctx context.Context, params BuildConte
 		lastSummaryID = chatSummary.ID
 	}
 
-	recentMessages, err := b.repo.GetMessagesAfterIDInTopic(ctx, params.ChatID, params.TopicID, lastSummaryID)
+	recentMessages, err := b.repo.GetMessagesAfterIDInTopic(ctx, params.ChatID, params.TopicID, lastSummaryID, b.config.App.Limits.MessagesScanLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent messages: %w", err)
 	}
 
+	// Drop messages older than the configured max age
+	if maxAge := b.config.App.Limits.ContextMaxAgeMinutes; maxAge > 0 {
+		cutoff := time.Now().Add(-time.Duration(maxAge) * time.Minute)
+		recentMessages = filterMessagesSince(recentMessages, cutoff)
+	}
+
 	// Limit to configured number of recent messages for context
 	limit := b.config.App.Limits.RecentMessagesLimit
 	if len(recentMessages) > limit {
 		recentMessages = recentMessages[len(recentMessages)-limit:]
 	}
 
+	// Use per-chat OpenAI API key if configured
+	apiKey, err := b.repo.GetChatOpenAIKey(ctx, params.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat API key: %w", err)
+	}
+
+	summaryAge, summaryStale := summaryStaleness(chatSummary, b.config.App.Limits.SummaryStaleHours, time.Now())
+
 	return &gpt.ContextRequest{
+		ChatID:         params.ChatID,
+		APIKey:         apiKey,
 		ChatSummary:    chatSummary,
+		SummaryAge:     summaryAge,
+		SummaryStale:   summaryStale,
 		UserSummary:    userSummary,
 		RecentMessages: recentMessages,
 		UserName:       params.UserName,
 		UserID:         params.UserID,
 	}, nil
 }
+
+// filterMessagesSince returns messages created at or after the cutoff
+func filterMessagesSince(messages []*models.Message, cutoff time.Time) []*models.Message {
+	var filtered []*models.Message
+	for _, msg := range messages {
+		if !msg.CreatedAt.Before(cutoff) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+// summaryStaleness returns the summary age and whether it exceeds the threshold (0 = disabled)
+func summaryStaleness(summary *models.ChatSummary, staleHours int, now time.Time) (time.Duration, bool) {
+	if summary == nil {
+		return 0, false
+	}
+
+	age := now.Sub(summary.UpdatedAt)
+	return age, staleHours > 0 && age >= time.Duration(staleHours)*time.Hour
+}
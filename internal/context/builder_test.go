@@ -0,0 +1,50 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestFilterMessagesSince(t *testing.T) {
+	now := time.Now()
+	messages := []*models.Message{
+		{ID: 1, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: 2, CreatedAt: now.Add(-90 * time.Minute)},
+		{ID: 3, CreatedAt: now.Add(-30 * time.Minute)},
+		{ID: 4, CreatedAt: now},
+	}
+
+	filtered := filterMessagesSince(messages, now.Add(-time.Hour))
+
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 messages within max age, got %d", len(filtered))
+	}
+	if filtered[0].ID != 3 || filtered[1].ID != 4 {
+		t.Errorf("Expected messages 3 and 4, got %d and %d", filtered[0].ID, filtered[1].ID)
+	}
+}
+
+func TestSummaryStaleness(t *testing.T) {
+	now := time.Now()
+	summary := &models.ChatSummary{UpdatedAt: now.Add(-30 * time.Hour)}
+
+	age, stale := summaryStaleness(summary, 24, now)
+	if !stale {
+		t.Error("Expected 30 hour old summary to be stale with 24 hour threshold")
+	}
+	if age != 30*time.Hour {
+		t.Errorf("Expected age 30h, got %v", age)
+	}
+
+	if _, stale := summaryStaleness(summary, 48, now); stale {
+		t.Error("Expected summary to be fresh with 48 hour threshold")
+	}
+	if _, stale := summaryStaleness(summary, 0, now); stale {
+		t.Error("Expected staleness check to be disabled with zero threshold")
+	}
+	if _, stale := summaryStaleness(nil, 24, now); stale {
+		t.Error("Expected missing summary not to be stale")
+	}
+}
@@ -0,0 +1,95 @@
+package context
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// eventDateLayouts are the ISO-8601 forms event dates are parsed with, most specific first
+var eventDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// mergeEvents combines existing and new events, merging entries with the same
+// normalized title and compatible dates and keeping the most specific date
+func mergeEvents(existing, incoming []models.Event) []models.Event {
+	var merged []models.Event
+
+	for _, event := range append(append([]models.Event{}, existing...), incoming...) {
+		duplicate := false
+		for i := range merged {
+			if normalizeEventTitle(merged[i].Title) == normalizeEventTitle(event.Title) && eventDatesCompatible(merged[i].Date, event.Date) {
+				if len(event.Date) > len(merged[i].Date) {
+					merged[i].Date = event.Date
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, event)
+		}
+	}
+
+	return merged
+}
+
+// normalizeEventTitle lowercases the title and collapses whitespace
+func normalizeEventTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
+
+// eventDatesCompatible reports whether two dates may refer to the same event:
+// equal, one missing, or one a less specific form of the other (same day)
+func eventDatesCompatible(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// prunePastEvents drops events dated before now. Date-only events last until the end
+// of their day; events without a parseable date are kept.
+func prunePastEvents(events []models.Event, now time.Time) []models.Event {
+	var upcoming []models.Event
+	for _, event := range events {
+		if end, ok := eventEnd(event.Date, now.Location()); ok && end.Before(now) {
+			continue
+		}
+		upcoming = append(upcoming, event)
+	}
+	return upcoming
+}
+
+// chatEvents converts next events of a chat topic to rows stored in chat_events
+func chatEvents(chatID int64, topicID *int64, events []models.Event, loc *time.Location) []*models.ChatEvent {
+	rows := make([]*models.ChatEvent, 0, len(events))
+	for _, event := range events {
+		row := &models.ChatEvent{ChatID: chatID, TopicID: topicID, Title: event.Title, Date: event.Date}
+		if end, ok := eventEnd(event.Date, loc); ok {
+			row.EndsAt = &end
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// eventEnd parses an event date and returns the moment it is considered over
+func eventEnd(date string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range eventDateLayouts {
+		t, err := time.ParseInLocation(layout, date, loc)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			return t.AddDate(0, 0, 1), true
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,105 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestMergeEventsOverlappingRuns(t *testing.T) {
+	firstRun := []models.Event{
+		{Title: "Новогодний созвон", Date: "2025-12-31"},
+		{Title: "Релиз", Date: "2025-12-20"},
+	}
+	secondRun := []models.Event{
+		{Title: "  новогодний   Созвон ", Date: "2025-12-31T19:00"},
+	}
+
+	merged := mergeEvents(firstRun, secondRun)
+
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(merged), merged)
+	}
+	if merged[0].Title != "Новогодний созвон" || merged[0].Date != "2025-12-31T19:00" {
+		t.Errorf("expected merged event to keep the most specific date, got %+v", merged[0])
+	}
+}
+
+func TestMergeEventsDifferentDatesKept(t *testing.T) {
+	merged := mergeEvents(
+		[]models.Event{{Title: "Созвон", Date: "2025-12-01"}},
+		[]models.Event{{Title: "Созвон", Date: "2025-12-08"}},
+	)
+	if len(merged) != 2 {
+		t.Fatalf("expected events on different days to be kept, got %+v", merged)
+	}
+}
+
+func TestMergeEventsMissingDate(t *testing.T) {
+	merged := mergeEvents(
+		[]models.Event{{Title: "Созвон"}},
+		[]models.Event{{Title: "Созвон", Date: "2025-12-01"}},
+	)
+	if len(merged) != 1 || merged[0].Date != "2025-12-01" {
+		t.Fatalf("expected a single dated event, got %+v", merged)
+	}
+}
+
+func TestPrunePastEvents(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{Title: "past", Date: "2025-12-09"},
+		{Title: "today", Date: "2025-12-10"},
+		{Title: "earlier today", Date: "2025-12-10T09:00"},
+		{Title: "future", Date: "2025-12-11T10:00:00Z"},
+		{Title: "undated"},
+		{Title: "unparseable", Date: "next week"},
+	}
+
+	pruned := prunePastEvents(events, now)
+
+	var titles []string
+	for _, event := range pruned {
+		titles = append(titles, event.Title)
+	}
+	expected := []string{"today", "future", "undated", "unparseable"}
+	if len(titles) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, titles)
+	}
+	for i := range expected {
+		if titles[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, titles)
+			break
+		}
+	}
+}
+
+func TestChatEvents(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	topicID := int64(9)
+	events := []models.Event{
+		{Title: "Meetup", Date: "2025-12-10"},
+		{Title: "Release", Date: "2025-12-11T10:00"},
+		{Title: "Someday"},
+	}
+
+	rows := chatEvents(42, &topicID, events, loc)
+	if len(rows) != 3 {
+		t.Fatalf("expected a row per event, got %+v", rows)
+	}
+	for _, row := range rows {
+		if row.ChatID != 42 || row.TopicID == nil || *row.TopicID != 9 {
+			t.Errorf("unexpected chat or topic: %+v", row)
+		}
+	}
+	if rows[0].EndsAt == nil || !rows[0].EndsAt.Equal(time.Date(2025, 12, 11, 0, 0, 0, 0, loc)) {
+		t.Errorf("expected a date-only event to end at the end of its day, got %v", rows[0].EndsAt)
+	}
+	if rows[1].EndsAt == nil || !rows[1].EndsAt.Equal(time.Date(2025, 12, 11, 10, 0, 0, 0, loc)) {
+		t.Errorf("expected a timed event to end at its start, got %v", rows[1].EndsAt)
+	}
+	if rows[2].EndsAt != nil || rows[2].Title != "Someday" {
+		t.Errorf("expected an undated event without end, got %+v", rows[2])
+	}
+}
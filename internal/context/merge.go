@@ -0,0 +1,132 @@
+package context
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// Merge strategies for combining stored and newly summarized counts
+const (
+	MergeStrategySum = "sum" // Add new counts to existing ones
+	MergeStrategyMax = "max" // Keep the larger of existing and new counts
+	MergeStrategyEMA = "ema" // Exponential moving average, absent signals decay towards zero
+)
+
+// countValue converts a stored JSON count to float64
+func countValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	default:
+		return 0
+	}
+}
+
+// minDecayedSignal is the value below which decayed signals are dropped
+const minDecayedSignal = 0.5
+
+// decayCounts multiplies counts by factor for each day elapsed since the last update,
+// dropping signals that decayed below minDecayedSignal. A factor outside (0, 1) disables decay.
+func decayCounts(counts map[string]interface{}, factor float64, elapsed time.Duration) map[string]interface{} {
+	if factor <= 0 || factor >= 1 || elapsed <= 0 || len(counts) == 0 {
+		return counts
+	}
+
+	multiplier := math.Pow(factor, elapsed.Hours()/24)
+
+	decayed := make(map[string]interface{}, len(counts))
+	for key, value := range counts {
+		if v := countValue(value) * multiplier; v >= minDecayedSignal {
+			decayed[key] = v
+		}
+	}
+
+	return decayed
+}
+
+// mergeCounts combines existing counts with counts from a new summarization run.
+// alpha is the weight of new counts for the EMA strategy.
+func mergeCounts(existing map[string]interface{}, incoming map[string]int, strategy string, alpha float64) map[string]interface{} {
+	merged := make(map[string]interface{}, len(existing)+len(incoming))
+
+	switch strategy {
+	case MergeStrategyMax:
+		for key, value := range existing {
+			merged[key] = countValue(value)
+		}
+		for key, count := range incoming {
+			if float64(count) > countValue(merged[key]) {
+				merged[key] = float64(count)
+			}
+		}
+	case MergeStrategyEMA:
+		for key, value := range existing {
+			merged[key] = (1 - alpha) * countValue(value)
+		}
+		for key, count := range incoming {
+			merged[key] = countValue(merged[key]) + alpha*float64(count)
+		}
+	default: // MergeStrategySum
+		for key, value := range existing {
+			merged[key] = countValue(value)
+		}
+		for key, count := range incoming {
+			merged[key] = countValue(merged[key]) + float64(count)
+		}
+	}
+
+	return merged
+}
+
+// TopicCount is a topic name with its accumulated count
+type TopicCount struct {
+	Name  string
+	Count float64
+}
+
+// TopTopics returns up to n topics sorted by count, ties broken by name (n <= 0 = all).
+// Counts may be stored as JSON floats or ints.
+func TopTopics(topics map[string]interface{}, n int) []TopicCount {
+	sorted := make([]TopicCount, 0, len(topics))
+	for name, value := range topics {
+		sorted = append(sorted, TopicCount{Name: name, Count: countValue(value)})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	return sorted
+}
+
+// pruneTopics keeps the top maxTopics topics by count; ties are broken by name
+func pruneTopics(topics map[string]interface{}, maxTopics int) map[string]interface{} {
+	if maxTopics <= 0 || len(topics) <= maxTopics {
+		return topics
+	}
+
+	pruned := make(map[string]interface{}, maxTopics)
+	for _, topic := range TopTopics(topics, maxTopics) {
+		pruned[topic.Name] = topics[topic.Name]
+	}
+
+	return pruned
+}
@@ -0,0 +1,100 @@
+package context
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneTopics(t *testing.T) {
+	topics := map[string]interface{}{
+		"go":      float64(9),
+		"rust":    7,
+		"python":  float64(7),
+		"haskell": float64(2),
+		"cobol":   1,
+	}
+
+	pruned := pruneTopics(topics, 3)
+
+	if len(pruned) != 3 {
+		t.Fatalf("Expected 3 topics, got %d: %v", len(pruned), pruned)
+	}
+	for _, name := range []string{"go", "python", "rust"} {
+		if _, ok := pruned[name]; !ok {
+			t.Errorf("Expected topic %q to be kept, got %v", name, pruned)
+		}
+	}
+
+	if got := pruneTopics(topics, 0); len(got) != len(topics) {
+		t.Errorf("Expected no pruning with zero cap, got %d topics", len(got))
+	}
+}
+
+func TestMergeCounts(t *testing.T) {
+	existing := map[string]interface{}{"go": float64(4), "rust": float64(6)}
+	incoming := map[string]int{"go": 2, "python": 3}
+
+	tests := []struct {
+		strategy string
+		expected map[string]float64
+	}{
+		{MergeStrategySum, map[string]float64{"go": 6, "rust": 6, "python": 3}},
+		{MergeStrategyMax, map[string]float64{"go": 4, "rust": 6, "python": 3}},
+		{MergeStrategyEMA, map[string]float64{"go": 3, "rust": 3, "python": 1.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			merged := mergeCounts(existing, incoming, tt.strategy, 0.5)
+
+			if len(merged) != len(tt.expected) {
+				t.Fatalf("Expected %d keys, got %v", len(tt.expected), merged)
+			}
+			for key, want := range tt.expected {
+				if got := countValue(merged[key]); got != want {
+					t.Errorf("Expected %s = %v, got %v", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDecayCounts(t *testing.T) {
+	counts := map[string]interface{}{"go": float64(10), "rust": float64(1)}
+
+	day := 24 * time.Hour
+	prev := countValue(counts["go"])
+	for days := 1; days <= 20; days++ {
+		decayed := decayCounts(counts, 0.9, time.Duration(days)*day)
+		v := countValue(decayed["go"])
+		if v >= prev {
+			t.Fatalf("Expected signal to decrease after %d days, got %v (previous %v)", days, v, prev)
+		}
+		prev = v
+	}
+
+	decayed := decayCounts(counts, 0.9, 7*day)
+	if _, ok := decayed["rust"]; ok {
+		t.Errorf("Expected weak signal to be dropped after a week, got %v", decayed)
+	}
+
+	decayed = decayCounts(counts, 0.9, 60*day)
+	if len(decayed) != 0 {
+		t.Errorf("Expected all signals to decay away after 60 days, got %v", decayed)
+	}
+
+	if got := decayCounts(counts, 0, 60*day); len(got) != len(counts) {
+		t.Errorf("Expected no decay when disabled, got %v", got)
+	}
+}
+
+func TestMergeCountsSummarizeRunOverExistingSummary(t *testing.T) {
+	// The model counts only the new messages, so one run adds them once to the stored counts
+	existing := map[string]interface{}{"go": 5.0, "rust": 2.0}
+	incoming := map[string]int{"go": 1}
+
+	merged := mergeCounts(existing, incoming, MergeStrategySum, 0)
+	if merged["go"] != 6.0 || merged["rust"] != 2.0 {
+		t.Errorf("Expected go=6 rust=2 after one run, got %v", merged)
+	}
+}
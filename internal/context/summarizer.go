@@ -2,9 +2,12 @@ package context
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/xdefrag/william/internal/config"
@@ -16,19 +19,54 @@ import (
 // Summarizer handles message summarization
 type Summarizer struct {
 	repo      *repo.Repository
-	gptClient *gpt.Client
+	gptClient gpt.Completer
 	config    *config.Config
 	logger    *slog.Logger
+
+	// running holds the chat topics being summarized, so overlapping runs are skipped
+	mu      sync.Mutex
+	running map[runKey]bool
+}
+
+// runKey identifies a chat topic summarization run
+type runKey struct {
+	chatID int64
+	topic  TopicKey
 }
 
 // NewSummarizer creates a new summarizer
-func NewSummarizer(repo *repo.Repository, gptClient *gpt.Client, config *config.Config, logger *slog.Logger) *Summarizer {
+func NewSummarizer(repo *repo.Repository, gptClient gpt.Completer, config *config.Config, logger *slog.Logger) *Summarizer {
 	return &Summarizer{
 		repo:      repo,
 		gptClient: gptClient,
 		config:    config,
 		logger:    logger.WithGroup("summarizer"),
+		running:   make(map[runKey]bool),
+	}
+}
+
+// tryLock marks the chat topic as being summarized; false if a run is already in progress
+func (s *Summarizer) tryLock(chatID int64, topicKey TopicKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := runKey{chatID: chatID, topic: topicKey}
+	if s.running[key] {
+		return false
+	}
+	if s.running == nil {
+		s.running = make(map[runKey]bool)
 	}
+	s.running[key] = true
+	return true
+}
+
+// unlock ends the chat topic run started by tryLock
+func (s *Summarizer) unlock(chatID int64, topicKey TopicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, runKey{chatID: chatID, topic: topicKey})
 }
 
 // TopicKey represents a safe key for grouping messages by topic
@@ -45,28 +83,23 @@ func NewTopicKey(topicID *int64) TopicKey {
 	return TopicKey{hasValue: true, value: *topicID}
 }
 
-// SummarizeChat summarizes recent messages for a chat, grouping by topic
+// SummarizeChat summarizes each topic with recent messages in a chat, reading up to maxMessages
+// messages per topic that no summary covers yet
 func (s *Summarizer) SummarizeChat(ctx context.Context, chatID int64, maxMessages int) error {
-	// Get recent messages
+	// Recent messages tell which topics were active
 	messages, err := s.repo.GetLatestMessagesByChatID(ctx, chatID, maxMessages)
 	if err != nil {
 		return fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	if len(messages) == 0 {
-		return nil // Nothing to summarize
-	}
-
-	// Group messages by topic
-	topicGroups := make(map[TopicKey][]*models.Message)
+	topics := make(map[TopicKey]*int64)
 	for _, msg := range messages {
-		key := NewTopicKey(msg.TopicID)
-		topicGroups[key] = append(topicGroups[key], msg)
+		topics[NewTopicKey(msg.TopicID)] = msg.TopicID
 	}
 
-	// Summarize each topic group
-	for topicKey, topicMessages := range topicGroups {
-		if err := s.summarizeTopicMessages(ctx, chatID, topicKey, topicMessages); err != nil {
+	// Summarize each topic
+	for topicKey, topicID := range topics {
+		if err := s.SummarizeChatTopic(ctx, chatID, topicID, maxMessages); err != nil {
 			// Log error but continue with other topics
 			s.logger.Error("Failed to summarize topic messages",
 				slog.Int64("chat_id", chatID),
@@ -79,12 +112,28 @@ func (s *Summarizer) SummarizeChat(ctx context.Context, chatID int64, maxMessage
 }
 
 // summarizeTopicMessages summarizes messages for a specific topic
-func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, topicKey TopicKey, messages []*models.Message) error {
-	// Reverse messages to chronological order
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, topicKey TopicKey, messages []*models.Message) (err error) {
+	// Too few messages make a weak summary; keep the previous one until more arrive
+	if minMessages := s.config.App.Limits.MinTopicMessages; len(messages) < minMessages {
+		s.logger.InfoContext(ctx, "Too few messages to summarize topic, keeping previous summary",
+			slog.Int64("chat_id", chatID),
+			slog.Bool("has_topic", topicKey.hasValue),
+			slog.Int("messages", len(messages)),
+			slog.Int("min_messages", minMessages),
+		)
+		return nil
 	}
 
+	// Summarize event, midnight job and manual trigger may overlap; the first run wins
+	if !s.tryLock(chatID, topicKey) {
+		s.logger.InfoContext(ctx, "Topic summarization already in progress, skipping",
+			slog.Int64("chat_id", chatID),
+			slog.Bool("has_topic", topicKey.hasValue),
+		)
+		return nil
+	}
+	defer s.unlock(chatID, topicKey)
+
 	var topicID *int64
 	if topicKey.hasValue {
 		topicID = &topicKey.value
@@ -96,6 +145,42 @@ func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, t
 		return fmt.Errorf("failed to get existing chat summary: %w", err)
 	}
 
+	// Counts are merged into the stored ones, so messages an earlier run covered must not be read again
+	if existingChatSummary != nil {
+		messages = messagesAfter(messages, existingChatSummary.LastMessageID)
+	}
+	if len(messages) == 0 || len(messages) < s.config.App.Limits.MinTopicMessages {
+		s.logger.InfoContext(ctx, "Too few unsummarized messages in topic, keeping previous summary",
+			slog.Int64("chat_id", chatID),
+			slog.Bool("has_topic", topicKey.hasValue),
+			slog.Int("messages", len(messages)),
+		)
+		return nil
+	}
+
+	// Chronological order; the newest message becomes the summary's cursor
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	lastMessageID := messages[len(messages)-1].ID
+
+	if sampled := sampleMessages(messages, s.config.App.Limits.SummarizeSampling, s.config.App.Limits.SummarizeSamplingThreshold); len(sampled) < len(messages) {
+		s.logger.InfoContext(ctx, "Sampled oversized summarization batch",
+			slog.Int64("chat_id", chatID),
+			slog.Bool("has_topic", topicKey.hasValue),
+			slog.String("strategy", s.config.App.Limits.SummarizeSampling),
+			slog.Int("messages", len(messages)),
+			slog.Int("sampled", len(sampled)),
+		)
+		messages = sampled
+	}
+
+	// Record the run's metadata whatever its outcome; a skipped update counts as a failure
+	var usage gpt.Usage
+	var skipErr error
+	start := time.Now()
+	defer func() {
+		s.saveSummarizationRun(ctx, summarizationRun(chatID, topicID, len(messages), usage, time.Since(start), errors.Join(err, skipErr)))
+	}()
+
 	// Get unique user IDs from messages
 	userIDs := make(map[int64]bool)
 	for _, msg := range messages {
@@ -116,6 +201,12 @@ func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, t
 		}
 	}
 
+	// Use per-chat OpenAI API key and summarize prompt if configured
+	apiKey, systemPrompt, err := s.chatSummarizeOverrides(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
 	// Call GPT for summarization with existing data
 	req := gpt.SummarizeRequest{
 		ChatID:                chatID,
@@ -123,34 +214,79 @@ func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, t
 		ExistingChatSummary:   existingChatSummary,
 		ExistingUserSummaries: existingUserSummaries,
 		BotName:               s.config.App.App.Name,
+		APIKey:                apiKey,
+		SystemPrompt:          systemPrompt,
 	}
 
 	response, err := s.gptClient.Summarize(ctx, req)
+	if errors.Is(err, gpt.ErrEmptyCompletion) {
+		// Keep the existing summary rather than overwriting it with nothing
+		s.logger.Warn("OpenAI returned empty content, skipping summary update",
+			slog.Int64("chat_id", chatID),
+			slog.Any("topic_id", topicID),
+		)
+		skipErr = err
+		return nil
+	}
+	if errors.Is(err, gpt.ErrTruncatedCompletion) {
+		// The truncated attempts were billed even though the summary is skipped
+		var truncated *gpt.TruncatedCompletionError
+		if errors.As(err, &truncated) {
+			usage = truncated.Usage
+			s.saveTokenUsage(ctx, chatID, truncated.Usage)
+		}
+		s.logger.Warn("Summary truncated even at the max tokens limit, skipping summary update",
+			slog.Int64("chat_id", chatID),
+			slog.Any("topic_id", topicID),
+			slog.Int("messages", len(messages)),
+		)
+		skipErr = err
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to summarize with GPT: %w", err)
 	}
+	usage = response.Usage
+	s.saveTokenUsage(ctx, chatID, response.Usage)
 
-	// Save chat summary with topic ID
-	chatSummary := &models.ChatSummary{
-		ChatID:     chatID,
-		TopicID:    topicID,
-		Summary:    response.ChatSummary.Summary,
-		TopicsJSON: make(map[string]interface{}),
+	// Keep the raw completion for debugging when enabled
+	if s.config.App.Log.PersistRawCompletions {
+		if err := s.repo.SaveRawCompletion(ctx, chatID, topicID, response.Raw); err != nil {
+			s.logger.Error("Failed to save raw completion", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+		}
 	}
 
-	// Convert topics to interface{}
-	for topic, count := range response.ChatSummary.Topics {
-		chatSummary.TopicsJSON[topic] = count
+	// Merge new topic counts with existing ones server-side
+	var existingTopics map[string]interface{}
+	if existingChatSummary != nil {
+		existingTopics = s.decayCounts(existingChatSummary.TopicsJSON, existingChatSummary.UpdatedAt)
 	}
+	topics := s.mergeCounts(existingTopics, response.ChatSummary.Topics)
 
-	// Add next events if present
-	if len(response.ChatSummary.NextEvents) > 0 {
-		chatSummary.NextEventsJSON = response.ChatSummary.NextEvents
+	// Save chat summary with topic ID, keeping only the most discussed topics
+	chatSummary := &models.ChatSummary{
+		ChatID:        chatID,
+		TopicID:       topicID,
+		Summary:       response.ChatSummary.Summary,
+		TopicsJSON:    pruneTopics(topics, s.config.App.Limits.MaxTopics),
+		LastMessageID: lastMessageID,
 	}
 
-	err = s.repo.SaveChatSummary(ctx, chatSummary)
-	if err != nil {
-		return fmt.Errorf("failed to save chat summary: %w", err)
+	// Merge next events with existing ones, dropping duplicates across runs
+	var existingEvents []models.Event
+	if existingChatSummary != nil {
+		existingEvents = existingChatSummary.NextEventsJSON
+	}
+	events := mergeEvents(existingEvents, response.ChatSummary.NextEvents)
+	if s.config.App.Limits.PrunePastEvents {
+		events = prunePastEvents(events, time.Now().In(s.config.Location))
+	}
+	if len(events) > 0 {
+		chatSummary.NextEventsJSON = events
+	}
+	var storedEvents []*models.ChatEvent
+	if s.config.App.Limits.StoreChatEvents {
+		storedEvents = chatEvents(chatID, topicID, events, s.config.Location)
 	}
 
 	// Create user info map from messages for quick lookup
@@ -161,19 +297,28 @@ func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, t
 		}
 	}
 
-	// Save user summaries
+	// Build user summaries
+	var userSummaries []*models.UserSummary
 	for userIDStr, profile := range response.UserProfiles {
 		userID, err := strconv.ParseInt(userIDStr, 10, 64)
 		if err != nil {
 			continue // Skip invalid user IDs
 		}
 
+		// Merge new profile signals with the existing profile
+		var existingLikes, existingDislikes, existingCompetencies map[string]interface{}
+		if existing, ok := existingUserSummaries[userID]; ok {
+			existingLikes = s.decayCounts(existing.LikesJSON, existing.UpdatedAt)
+			existingDislikes = s.decayCounts(existing.DislikesJSON, existing.UpdatedAt)
+			existingCompetencies = s.decayCounts(existing.CompetenciesJSON, existing.UpdatedAt)
+		}
+
 		userSummary := &models.UserSummary{
 			ChatID:           chatID,
 			UserID:           userID,
-			LikesJSON:        make(map[string]interface{}),
-			DislikesJSON:     make(map[string]interface{}),
-			CompetenciesJSON: make(map[string]interface{}),
+			LikesJSON:        s.mergeCounts(existingLikes, profile.Likes),
+			DislikesJSON:     s.mergeCounts(existingDislikes, profile.Dislikes),
+			CompetenciesJSON: s.mergeCounts(existingCompetencies, profile.Competencies),
 		}
 
 		// Set user info from message data
@@ -183,90 +328,313 @@ func (s *Summarizer) summarizeTopicMessages(ctx context.Context, chatID int64, t
 			userSummary.LastName = userInfo.UserLastName
 		}
 
-		// Convert likes to interface{}
-		for topic, score := range profile.Likes {
-			userSummary.LikesJSON[topic] = score
+		// Add traits if present
+		if len(profile.Traits) > 0 {
+			userSummary.TraitsJSON = profile.Traits
 		}
 
-		// Convert dislikes to interface{}
-		for topic, score := range profile.Dislikes {
-			userSummary.DislikesJSON[topic] = score
-		}
+		userSummaries = append(userSummaries, userSummary)
+	}
 
-		// Convert competencies to interface{}
-		for topic, score := range profile.Competencies {
-			userSummary.CompetenciesJSON[topic] = score
+	// Save the chat summary, its events and user profiles atomically so a failed user save leaves neither
+	return s.repo.WithTx(ctx, func(tx *repo.Tx) error {
+		if err := tx.SaveChatSummary(ctx, chatSummary); err != nil {
+			return fmt.Errorf("failed to save chat summary: %w", err)
 		}
-
-		// Add traits if present
-		if len(profile.Traits) > 0 {
-			userSummary.TraitsJSON = profile.Traits
+		if err := tx.SaveChatEvents(ctx, storedEvents); err != nil {
+			return fmt.Errorf("failed to save chat events: %w", err)
+		}
+		for _, userSummary := range userSummaries {
+			if err := tx.SaveUserSummary(ctx, userSummary); err != nil {
+				return fmt.Errorf("failed to save user summary for user %d: %w", userSummary.UserID, err)
+			}
 		}
+		return nil
+	})
+}
 
-		err = s.repo.SaveUserSummary(ctx, userSummary)
-		if err != nil {
-			return fmt.Errorf("failed to save user summary for user %d: %w", userID, err)
+// saveSummarizationRun records the run when enabled; failures are only logged
+func (s *Summarizer) saveSummarizationRun(ctx context.Context, run *models.SummarizationRun) {
+	if !s.config.App.Log.PersistSummarizationRuns {
+		return
+	}
+
+	if err := s.repo.SaveSummarizationRun(ctx, run); err != nil {
+		s.logger.Error("Failed to save summarization run", slog.Int64("chat_id", run.ChatID), slog.String("error", err.Error()))
+	}
+}
+
+// summarizationRun builds the record of a summarization run that ended with err
+func summarizationRun(chatID int64, topicID *int64, messageCount int, usage gpt.Usage, duration time.Duration, err error) *models.SummarizationRun {
+	run := &models.SummarizationRun{
+		ChatID:           chatID,
+		TopicID:          topicID,
+		MessageCount:     messageCount,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		DurationMs:       duration.Milliseconds(),
+		Status:           models.SummarizationRunSuccess,
+	}
+	if usage.Model != "" {
+		run.Model = &usage.Model
+	}
+	if err != nil {
+		message := err.Error()
+		run.Status = models.SummarizationRunFailure
+		if errors.Is(err, gpt.ErrTruncatedCompletion) {
+			run.Status = models.SummarizationRunTruncated
 		}
+		run.Error = &message
 	}
+	return run
+}
 
-	return nil
+// decayCounts applies the configured time decay to counts last updated at updatedAt
+func (s *Summarizer) decayCounts(counts map[string]interface{}, updatedAt time.Time) map[string]interface{} {
+	return decayCounts(counts, s.config.App.Limits.SignalDecay, time.Since(updatedAt))
+}
+
+// mergeCounts merges counts using the configured merge strategy
+func (s *Summarizer) mergeCounts(existing map[string]interface{}, incoming map[string]int) map[string]interface{} {
+	return mergeCounts(existing, incoming, s.config.App.Limits.MergeStrategy, s.config.App.Limits.MergeEMAAlpha)
 }
 
-// SummarizeChatTopic summarizes messages for a specific chat topic
+// SummarizeChatTopic summarizes the oldest maxMessages messages of a chat topic that no summary
+// covers yet
 func (s *Summarizer) SummarizeChatTopic(ctx context.Context, chatID int64, topicID *int64, maxMessages int) error {
-	// Get recent messages for this specific topic
-	var messages []*models.Message
+	existing, err := s.repo.GetLatestChatSummaryByTopic(ctx, chatID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing chat summary: %w", err)
+	}
 
-	if topicID != nil {
-		// Get messages from specific topic using GetLatestMessagesByChatID and filter
-		allMessages, err := s.repo.GetLatestMessagesByChatID(ctx, chatID, maxMessages)
-		if err != nil {
-			return fmt.Errorf("failed to get messages: %w", err)
-		}
+	var afterID int64
+	if existing != nil {
+		afterID = existing.LastMessageID
+	}
 
-		// Filter messages by topic
-		for _, msg := range allMessages {
-			if msg.TopicID != nil && topicID != nil && *msg.TopicID == *topicID {
-				messages = append(messages, msg)
-			}
-		}
-	} else {
-		// Get general chat messages (topic_id IS NULL)
-		allMessages, err := s.repo.GetLatestMessagesByChatID(ctx, chatID, maxMessages)
-		if err != nil {
-			return fmt.Errorf("failed to get messages: %w", err)
-		}
+	messages, err := s.repo.GetUnsummarizedMessages(ctx, chatID, topicID, afterID, maxMessages)
+	if err != nil {
+		return fmt.Errorf("failed to get messages: %w", err)
+	}
 
-		// Filter messages without topic
-		for _, msg := range allMessages {
-			if msg.TopicID == nil {
-				messages = append(messages, msg)
-			}
+	if len(messages) == 0 {
+		return nil // Nothing to summarize
+	}
+
+	return s.summarizeTopicMessages(ctx, chatID, NewTopicKey(topicID), messages)
+}
+
+// messagesAfter returns the messages newer than the given message id
+func messagesAfter(messages []*models.Message, afterID int64) []*models.Message {
+	var newer []*models.Message
+	for _, msg := range messages {
+		if msg.ID > afterID {
+			newer = append(newer, msg)
 		}
 	}
+	return newer
+}
 
+// RefreshUserProfile rebuilds a single user's profile from their latest messages,
+// replacing the stored one. Other users' profiles and the chat summary are left as is.
+func (s *Summarizer) RefreshUserProfile(ctx context.Context, chatID, userID int64, maxMessages int) error {
+	messages, err := s.repo.GetLatestMessagesByUser(ctx, chatID, userID, maxMessages)
+	if err != nil {
+		return fmt.Errorf("failed to get user messages: %w", err)
+	}
 	if len(messages) == 0 {
 		return nil // Nothing to summarize
 	}
 
-	// Use existing summarizeTopicMessages method
-	topicKey := NewTopicKey(topicID)
-	return s.summarizeTopicMessages(ctx, chatID, topicKey, messages)
+	// Reverse messages to chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	apiKey, systemPrompt, err := s.chatSummarizeOverrides(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.gptClient.Summarize(ctx, gpt.SummarizeRequest{
+		ChatID:       chatID,
+		Messages:     messages,
+		BotName:      s.config.App.App.Name,
+		APIKey:       apiKey,
+		SystemPrompt: systemPrompt,
+	})
+	if errors.Is(err, gpt.ErrEmptyCompletion) || errors.Is(err, gpt.ErrTruncatedCompletion) {
+		var truncated *gpt.TruncatedCompletionError
+		if errors.As(err, &truncated) {
+			s.saveTokenUsage(ctx, chatID, truncated.Usage)
+		}
+		s.logger.Warn("OpenAI returned no usable content, keeping user profile",
+			slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+			slog.Int64("user_id", userID),
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to summarize with GPT: %w", err)
+	}
+	s.saveTokenUsage(ctx, chatID, response.Usage)
+
+	userSummary, ok := refreshedUserSummary(chatID, userID, response, messages[len(messages)-1])
+	if !ok {
+		s.logger.Warn("OpenAI returned no profile for user, keeping user profile",
+			slog.Int64("chat_id", chatID),
+			slog.Int64("user_id", userID),
+		)
+		return nil
+	}
+
+	if err := s.repo.SaveUserSummary(ctx, userSummary); err != nil {
+		return fmt.Errorf("failed to save user summary for user %d: %w", userID, err)
+	}
+
+	return nil
 }
 
-// SummarizeAllActiveChats summarizes all chats with recent activity
+// refreshedUserSummary builds the replacement summary of userID from a summarize response,
+// ignoring profiles of any other users the model returned
+func refreshedUserSummary(chatID, userID int64, response *gpt.SummarizeResponse, userInfo *models.Message) (*models.UserSummary, bool) {
+	profile, ok := response.UserProfiles[strconv.FormatInt(userID, 10)]
+	if !ok {
+		return nil, false
+	}
+
+	userSummary := &models.UserSummary{
+		ChatID:           chatID,
+		UserID:           userID,
+		LikesJSON:        mergeCounts(nil, profile.Likes, MergeStrategySum, 0),
+		DislikesJSON:     mergeCounts(nil, profile.Dislikes, MergeStrategySum, 0),
+		CompetenciesJSON: mergeCounts(nil, profile.Competencies, MergeStrategySum, 0),
+	}
+	if userInfo != nil {
+		userSummary.Username = userInfo.Username
+		userSummary.FirstName = &userInfo.UserFirstName
+		userSummary.LastName = userInfo.UserLastName
+	}
+	if len(profile.Traits) > 0 {
+		userSummary.TraitsJSON = profile.Traits
+	}
+
+	return userSummary, true
+}
+
+// SummarizeAllActiveChats summarizes all chats with recent activity. Chats below
+// limits.min_daily_messages are skipped; busier chats get a larger message window
+// (see limits.summarize_windows), maxMessages is the window for the rest.
 func (s *Summarizer) SummarizeAllActiveChats(ctx context.Context, since time.Time, maxMessages int) error {
-	chatIDs, err := s.repo.GetActiveChatIDs(ctx, since)
+	volumes, err := s.repo.GetActiveChatVolumes(ctx, since)
 	if err != nil {
 		return fmt.Errorf("failed to get active chats: %w", err)
 	}
 
-	for _, chatID := range chatIDs {
-		if err := s.SummarizeChat(ctx, chatID, maxMessages); err != nil {
+	for _, volume := range volumes {
+		if !shouldSummarizeDaily(s.config, volume.MessageCount) {
+			s.logger.Debug("Skipping quiet chat",
+				slog.Int64("chat_id", volume.ChatID),
+				slog.Int("message_count", volume.MessageCount),
+			)
+			continue
+		}
+
+		window := summarizeWindow(s.config, volume.MessageCount, maxMessages)
+		s.logger.Debug("Summarizing active chat",
+			slog.Int64("chat_id", volume.ChatID),
+			slog.Int("message_count", volume.MessageCount),
+			slog.Int("max_messages", window),
+		)
+
+		if err := s.SummarizeChat(ctx, volume.ChatID, window); err != nil {
 			// Log error but continue with other chats
-			s.logger.Error("Failed to summarize chat", slog.Int64("chat_id", chatID), slog.String("error", err.Error()))
+			s.logger.Error("Failed to summarize chat", slog.Int64("chat_id", volume.ChatID), slog.String("error", err.Error()))
 		}
 	}
 
 	return nil
 }
+
+// shouldSummarizeDaily reports whether a chat with messageCount messages since the last run
+// reaches the daily activity floor
+func shouldSummarizeDaily(cfg *config.Config, messageCount int) bool {
+	return messageCount >= cfg.App.Limits.MinDailyMessages
+}
+
+// summarizeWindow returns the message window for a chat with messageCount messages since the
+// last run: the large or medium bucket window if the chat reaches its threshold, else maxMessages
+func summarizeWindow(cfg *config.Config, messageCount, maxMessages int) int {
+	windows := cfg.App.Limits.SummarizeWindows
+	switch {
+	case windows.LargeChatMessages > 0 && windows.LargeMaxMessages > 0 && messageCount >= windows.LargeChatMessages:
+		return windows.LargeMaxMessages
+	case windows.MediumChatMessages > 0 && windows.MediumMaxMessages > 0 && messageCount >= windows.MediumChatMessages:
+		return windows.MediumMaxMessages
+	}
+	return maxMessages
+}
+
+// Summarize sampling strategies for batches over the sampling threshold
+const (
+	SamplingAll      = "all"
+	SamplingHeadTail = "head_tail"
+	SamplingUniform  = "uniform"
+)
+
+// sampleMessages reduces a chronological batch larger than threshold to threshold messages
+// (threshold <= 0 = no sampling). head_tail keeps the oldest and newest halves, uniform picks
+// evenly spaced messages. Both keep the first and last message and chronological order.
+func sampleMessages(messages []*models.Message, strategy string, threshold int) []*models.Message {
+	if threshold <= 0 || len(messages) <= threshold {
+		return messages
+	}
+
+	switch strategy {
+	case SamplingHeadTail:
+		head := (threshold + 1) / 2
+		sampled := make([]*models.Message, 0, threshold)
+		sampled = append(sampled, messages[:head]...)
+		return append(sampled, messages[len(messages)-(threshold-head):]...)
+	case SamplingUniform:
+		if threshold == 1 {
+			return messages[len(messages)-1:]
+		}
+		sampled := make([]*models.Message, threshold)
+		for i := range sampled {
+			sampled[i] = messages[i*(len(messages)-1)/(threshold-1)]
+		}
+		return sampled
+	default:
+		return messages
+	}
+}
+
+// chatSummarizeOverrides returns the per-chat API key and summarize prompt ("" = use global)
+func (s *Summarizer) chatSummarizeOverrides(ctx context.Context, chatID int64) (string, string, error) {
+	settings, err := s.repo.GetChatSettings(ctx, chatID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	apiKey, err := s.repo.GetChatOpenAIKey(ctx, chatID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get chat API key: %w", err)
+	}
+
+	return apiKey, chatSummarizePrompt(settings), nil
+}
+
+// chatSummarizePrompt returns the per-chat summarize prompt ("" = use global)
+func chatSummarizePrompt(settings *models.ChatSettings) string {
+	if settings.SummarizePrompt != nil {
+		return *settings.SummarizePrompt
+	}
+	return ""
+}
+
+// saveTokenUsage records the tokens of a summarization completion
+func (s *Summarizer) saveTokenUsage(ctx context.Context, chatID int64, usage gpt.Usage) {
+	SaveTokenUsage(ctx, s.repo, s.config.App.Usage.Prices, s.logger, chatID, usage)
+}
@@ -0,0 +1,317 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/gpt"
+	"github.com/xdefrag/william/internal/migrations"
+	"github.com/xdefrag/william/internal/repo"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestSummarizeTopicBelowThresholdKeepsPreviousSummary(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Limits.MinTopicMessages = 3
+
+	// No repository or GPT client: a below-threshold topic must return before
+	// reading or overwriting the stored summary
+	s := &Summarizer{config: cfg, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	text := "ok"
+	messages := []*models.Message{{ID: 1, Text: &text}, {ID: 2, Text: &text}}
+	topicID := int64(9)
+
+	if err := s.summarizeTopicMessages(context.Background(), 42, NewTopicKey(&topicID), messages); err != nil {
+		t.Fatalf("Expected below-threshold topic to be skipped, got %v", err)
+	}
+}
+
+func TestChatSummarizePrompt(t *testing.T) {
+	if prompt := chatSummarizePrompt(&models.ChatSettings{ChatID: 42}); prompt != "" {
+		t.Errorf("Expected no override, got prompt %q", prompt)
+	}
+
+	override := "Extract support issues"
+	if prompt := chatSummarizePrompt(&models.ChatSettings{ChatID: 42, SummarizePrompt: &override}); prompt != override {
+		t.Errorf("Expected chat override, got prompt %q", prompt)
+	}
+}
+
+func TestSummarizeWindowByChatVolume(t *testing.T) {
+	cfg := &config.Config{}
+	windows := &cfg.App.Limits.SummarizeWindows
+	windows.MediumChatMessages = 200
+	windows.MediumMaxMessages = 50
+	windows.LargeChatMessages = 1000
+	windows.LargeMaxMessages = 100
+
+	tests := []struct {
+		messageCount int
+		want         int
+	}{
+		{messageCount: 10, want: 25},
+		{messageCount: 200, want: 50},
+		{messageCount: 999, want: 50},
+		{messageCount: 5000, want: 100},
+	}
+
+	for _, tt := range tests {
+		if got := summarizeWindow(cfg, tt.messageCount, 25); got != tt.want {
+			t.Errorf("summarizeWindow(%d) = %d, want %d", tt.messageCount, got, tt.want)
+		}
+	}
+
+	if got := summarizeWindow(&config.Config{}, 5000, 25); got != 25 {
+		t.Errorf("Expected default window without buckets, got %d", got)
+	}
+}
+
+func TestShouldSummarizeDailyActivityFloor(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Limits.MinDailyMessages = 10
+
+	if shouldSummarizeDaily(cfg, 3) {
+		t.Error("Expected a chat below the floor to be skipped")
+	}
+	if !shouldSummarizeDaily(cfg, 10) {
+		t.Error("Expected a chat at the floor to be summarized")
+	}
+	if !shouldSummarizeDaily(cfg, 250) {
+		t.Error("Expected a chat above the floor to be summarized")
+	}
+
+	if !shouldSummarizeDaily(&config.Config{}, 1) {
+		t.Error("Expected every active chat to be summarized without a floor")
+	}
+}
+
+func TestSampleMessages(t *testing.T) {
+	messages := make([]*models.Message, 10)
+	for i := range messages {
+		messages[i] = &models.Message{ID: int64(i + 1)}
+	}
+	ids := func(sampled []*models.Message) []int64 {
+		result := make([]int64, len(sampled))
+		for i, msg := range sampled {
+			result[i] = msg.ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		strategy  string
+		threshold int
+		want      []int64
+	}{
+		{"all keeps the batch", SamplingAll, 4, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"head_tail", SamplingHeadTail, 5, []int64{1, 2, 3, 9, 10}},
+		{"uniform", SamplingUniform, 4, []int64{1, 4, 7, 10}},
+		{"batch within threshold", SamplingUniform, 10, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"no threshold", SamplingHeadTail, 0, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(sampleMessages(messages, tt.strategy, tt.threshold))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("sampleMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshedUserSummaryOnlyTargetsUser(t *testing.T) {
+	username := "ann"
+	response := &gpt.SummarizeResponse{
+		UserProfiles: map[string]gpt.UserProfileData{
+			"1": {Likes: map[string]int{"go": 3}, Competencies: map[string]int{"sql": 2}},
+			"2": {Likes: map[string]int{"rust": 5}},
+		},
+	}
+
+	summary, ok := refreshedUserSummary(42, 1, response, &models.Message{UserID: 1, UserFirstName: "Ann", Username: &username})
+	if !ok {
+		t.Fatal("Expected a summary for the targeted user")
+	}
+	if summary.ChatID != 42 || summary.UserID != 1 {
+		t.Errorf("Expected summary of user 1 in chat 42, got chat %d user %d", summary.ChatID, summary.UserID)
+	}
+	if summary.LikesJSON["go"] != float64(3) || len(summary.LikesJSON) != 1 {
+		t.Errorf("Expected only the targeted user's likes, got %v", summary.LikesJSON)
+	}
+	if summary.Username == nil || *summary.Username != "ann" || *summary.FirstName != "Ann" {
+		t.Errorf("Expected user info from messages, got %+v", summary)
+	}
+
+	if _, ok := refreshedUserSummary(42, 3, response, nil); ok {
+		t.Error("Expected no summary when the model returned no profile for the user")
+	}
+}
+
+func TestSummarizationRunStatus(t *testing.T) {
+	topicID := int64(9)
+	usage := gpt.Usage{Model: "gpt-4o-mini", PromptTokens: 1200, CompletionTokens: 300}
+
+	run := summarizationRun(42, &topicID, 50, usage, 1500*time.Millisecond, nil)
+	if run.Status != models.SummarizationRunSuccess || run.Error != nil {
+		t.Errorf("Expected a successful run, got %+v", run)
+	}
+	if run.ChatID != 42 || *run.TopicID != 9 || run.MessageCount != 50 || run.DurationMs != 1500 {
+		t.Errorf("Unexpected run metadata: %+v", run)
+	}
+	if run.Model == nil || *run.Model != "gpt-4o-mini" || run.PromptTokens != 1200 || run.CompletionTokens != 300 {
+		t.Errorf("Unexpected run usage: %+v", run)
+	}
+
+	run = summarizationRun(42, nil, 50, gpt.Usage{}, time.Second, fmt.Errorf("failed to summarize with GPT: %w", gpt.ErrEmptyCompletion))
+	if run.Status != models.SummarizationRunFailure || run.Error == nil || run.Model != nil {
+		t.Errorf("Expected a failed run without model, got %+v", run)
+	}
+
+	run = summarizationRun(42, nil, 50, gpt.Usage{}, time.Second, fmt.Errorf("summary exceeded 8192 tokens: %w", gpt.ErrTruncatedCompletion))
+	if run.Status != models.SummarizationRunTruncated || run.Error == nil {
+		t.Errorf("Expected a truncated run, got %+v", run)
+	}
+}
+
+func TestSummarizeTopicSkipsRunInProgress(t *testing.T) {
+	// No repository or GPT client: an overlapping run must return before touching them
+	s := &Summarizer{config: &config.Config{}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	text := "ok"
+	messages := []*models.Message{{ID: 1, Text: &text}}
+	topicID := int64(9)
+	topicKey := NewTopicKey(&topicID)
+
+	if !s.tryLock(42, topicKey) {
+		t.Fatal("Expected the first run to take the lock")
+	}
+	if err := s.summarizeTopicMessages(context.Background(), 42, topicKey, messages); err != nil {
+		t.Fatalf("Expected overlapping run to be skipped, got %v", err)
+	}
+
+	if !s.tryLock(42, NewTopicKey(nil)) || !s.tryLock(43, topicKey) {
+		t.Error("Expected other topics and chats not to be locked")
+	}
+
+	s.unlock(42, topicKey)
+	if !s.tryLock(42, topicKey) {
+		t.Error("Expected the lock to be released")
+	}
+}
+
+// topicCompleter reports every topic once per summarized message
+type topicCompleter struct {
+	calls int
+}
+
+func (c *topicCompleter) Summarize(_ context.Context, req gpt.SummarizeRequest) (*gpt.SummarizeResponse, error) {
+	c.calls++
+	return &gpt.SummarizeResponse{ChatSummary: gpt.ChatSummaryData{
+		Summary: "talk about go",
+		Topics:  map[string]int{"go": len(req.Messages)},
+	}}, nil
+}
+
+func (c *topicCompleter) GenerateResponse(context.Context, gpt.ContextRequest) (*gpt.MentionResponse, error) {
+	return &gpt.MentionResponse{}, nil
+}
+
+func newTestSummarizer(t *testing.T, chatID int64, completer gpt.Completer) *Summarizer {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PG_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+	if err := migrations.Run(ctx, sqlDB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, table := range []string{"messages", "chat_summaries", "user_summaries", "summarization_runs", "token_usage"} {
+			_, _ = pool.Exec(ctx, "DELETE FROM "+table+" WHERE chat_id = $1", chatID)
+		}
+	})
+
+	cfg := &config.Config{}
+	cfg.App.Limits.MinTopicMessages = 1
+	return NewSummarizer(repo.New(pool, nil), completer, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestSummarizeChatTwiceDoesNotDoubleCounts(t *testing.T) {
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	completer := &topicCompleter{}
+	s := newTestSummarizer(t, chatID, completer)
+
+	saveMessages := func(from, to int64) {
+		for id := from; id <= to; id++ {
+			text := "go is nice"
+			msg := &models.Message{TelegramMsgID: id, ChatID: chatID, UserID: 1, UserFirstName: "Ann", Text: &text}
+			if _, err := s.repo.SaveMessage(ctx, msg); err != nil {
+				t.Fatalf("SaveMessage failed: %v", err)
+			}
+		}
+	}
+	goCount := func() float64 {
+		summary, err := s.repo.GetLatestChatSummary(ctx, chatID)
+		if err != nil || summary == nil {
+			t.Fatalf("Expected a chat summary, got %v (err %v)", summary, err)
+		}
+		return countValue(summary.TopicsJSON["go"])
+	}
+
+	saveMessages(1, 3)
+	for i := 0; i < 2; i++ {
+		if err := s.SummarizeChat(ctx, chatID, 100); err != nil {
+			t.Fatalf("SummarizeChat failed: %v", err)
+		}
+	}
+	if got := goCount(); got != 3 {
+		t.Errorf("Expected the second run over the same messages to keep the count at 3, got %v", got)
+	}
+	if completer.calls != 1 {
+		t.Errorf("Expected already summarized messages not to be sent again, got %d calls", completer.calls)
+	}
+
+	// Only the new messages are added on the next run
+	saveMessages(4, 5)
+	if err := s.SummarizeChat(ctx, chatID, 100); err != nil {
+		t.Fatalf("SummarizeChat failed: %v", err)
+	}
+	if got := goCount(); got != 5 {
+		t.Errorf("Expected the new messages to add to the count, got %v", got)
+	}
+}
+
+func TestMessagesAfter(t *testing.T) {
+	messages := []*models.Message{{ID: 3}, {ID: 5}, {ID: 4}, {ID: 7}}
+
+	newer := messagesAfter(messages, 4)
+	if len(newer) != 2 || newer[0].ID != 5 || newer[1].ID != 7 {
+		t.Errorf("Expected messages 5 and 7, got %+v", newer)
+	}
+	if got := messagesAfter(messages, 0); len(got) != len(messages) {
+		t.Errorf("Expected every message without a cursor, got %d", len(got))
+	}
+}
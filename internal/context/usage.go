@@ -0,0 +1,36 @@
+package context
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/gpt"
+	"github.com/xdefrag/william/internal/repo"
+)
+
+// SaveTokenUsage records the tokens of a completion for the chat and logs its estimated cost.
+// Failures are only logged, so the caller goes on without a usage record.
+func SaveTokenUsage(ctx context.Context, r *repo.Repository, prices map[string]config.TokenPrice, logger *slog.Logger, chatID int64, usage gpt.Usage) {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return
+	}
+
+	if err := r.SaveTokenUsage(ctx, chatID, usage.Model, usage.PromptTokens, usage.CompletionTokens); err != nil {
+		logger.ErrorContext(ctx, "Failed to save token usage", slog.Any("error", err),
+			slog.Int64("chat_id", chatID),
+		)
+		return
+	}
+
+	attrs := []any{
+		slog.Int64("chat_id", chatID),
+		slog.String("model", usage.Model),
+		slog.Int64("prompt_tokens", usage.PromptTokens),
+		slog.Int64("completion_tokens", usage.CompletionTokens),
+	}
+	if cost, ok := gpt.EstimateCost(prices, usage); ok {
+		attrs = append(attrs, slog.Float64("estimated_cost", cost))
+	}
+	logger.DebugContext(ctx, "Token usage recorded", attrs...)
+}
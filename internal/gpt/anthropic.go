@@ -0,0 +1,219 @@
+package gpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xdefrag/william/internal/config"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+)
+
+// AnthropicClient is a Completer backed by the Anthropic Messages API.
+// Per-chat API keys are OpenAI keys, so requests always use the global Anthropic key.
+type AnthropicClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	config     *config.Config
+	logger     *slog.Logger
+}
+
+// NewAnthropic creates a new Anthropic client
+func NewAnthropic(apiKey string, cfg *config.Config, logger *slog.Logger) *AnthropicClient {
+	return &AnthropicClient{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		baseURL:    anthropicBaseURL,
+		apiKey:     apiKey,
+		config:     cfg,
+		logger:     logger.WithGroup("gpt.anthropic"),
+	}
+}
+
+// anthropicRequest is the body of a Messages API request
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// anthropicResponse is the part of a Messages API response used by the bot
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicError is a non-2xx Messages API response
+type anthropicError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *anthropicError) Error() string {
+	return fmt.Sprintf("anthropic API error %d: %s", e.StatusCode, e.Body)
+}
+
+// Summarize generates summaries for chat and users
+func (c *AnthropicClient) Summarize(ctx context.Context, req SummarizeRequest) (*SummarizeResponse, error) {
+	systemPrompt, userPrompt := buildSummarizePrompts(c.config, req)
+
+	c.logger.DebugContext(ctx, "Sending prompts to Anthropic for summarization",
+		slog.Int64("chat_id", req.ChatID),
+		slog.String("model", c.config.App.Anthropic.Model),
+		slog.Int("max_tokens", c.config.App.Anthropic.MaxTokensSummarize),
+	)
+
+	content, usage, err := c.complete(ctx, systemPrompt, userPrompt, c.config.App.Anthropic.MaxTokensSummarize)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SummarizeResponse
+	if err := unmarshalCompletion(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+	}
+	result.Raw = content
+	result.Usage = usage
+
+	return &result, nil
+}
+
+// GenerateResponse creates context-aware response for user query
+func (c *AnthropicClient) GenerateResponse(ctx context.Context, req ContextRequest) (*MentionResponse, error) {
+	systemPrompt, userPrompt := buildResponsePrompts(c.config, req)
+
+	c.logger.DebugContext(ctx, "Sending prompts to Anthropic for response generation",
+		slog.String("user_name", req.UserName),
+		slog.String("model", c.config.App.Anthropic.Model),
+		slog.Int("max_tokens", c.config.App.Anthropic.MaxTokensResponse),
+	)
+
+	content, usage, err := c.complete(ctx, systemPrompt, userPrompt, c.config.App.Anthropic.MaxTokensResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MentionResponse
+	if err := unmarshalCompletion(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+	}
+	result.Usage = usage
+
+	return &result, nil
+}
+
+// complete sends a Messages API request and returns the text content with its usage, retrying rate-limit,
+// overload and 5xx errors with the [openai] retry policy
+func (c *AnthropicClient) complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, Usage, error) {
+	body, err := json.Marshal(anthropicRequest{
+		Model:       c.config.App.Anthropic.Model,
+		MaxTokens:   maxTokens,
+		System:      systemPrompt,
+		Messages:    []anthropicMessage{{Role: "user", Content: userPrompt}},
+		Temperature: c.config.App.Anthropic.Temperature,
+	})
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to marshal Anthropic request: %w", err)
+	}
+
+	maxRetries := c.config.App.OpenAI.MaxRetries
+	baseDelay := time.Duration(c.config.App.OpenAI.RetryBaseDelayMs) * time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		content, usage, err := c.send(ctx, body)
+		if err == nil {
+			return content, usage, nil
+		}
+
+		var apiErr *anthropicError
+		if attempt >= maxRetries || !errors.As(err, &apiErr) || !isRetryableStatus(apiErr.StatusCode) {
+			return "", Usage{}, fmt.Errorf("failed to call Anthropic: %w", err)
+		}
+
+		delay := retryDelay(baseDelay, attempt)
+		c.logger.DebugContext(ctx, "Retrying Anthropic request",
+			slog.Any("error", err),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+		)
+
+		select {
+		case <-ctx.Done():
+			return "", Usage{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send performs a single Messages API request
+func (c *AnthropicClient) send(ctx context.Context, body []byte) (string, Usage, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", Usage{}, &anthropicError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return "", Usage{}, fmt.Errorf("failed to decode Anthropic response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	if strings.TrimSpace(text.String()) == "" {
+		return "", Usage{}, ErrEmptyCompletion
+	}
+	usage := Usage{
+		Model:            parsed.Model,
+		PromptTokens:     parsed.Usage.InputTokens,
+		CompletionTokens: parsed.Usage.OutputTokens,
+	}
+	return text.String(), usage, nil
+}
@@ -0,0 +1,120 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xdefrag/william/internal/config"
+)
+
+// newFakeAnthropicClient returns a client backed by a fake Messages API that fails with the
+// given statuses before answering with text. Received requests are appended to reqs.
+func newFakeAnthropicClient(t *testing.T, statuses []int, text string, reqs *[]anthropicRequest) *AnthropicClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "anthropic-key" || r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req anthropicRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		*reqs = append(*reqs, req)
+
+		if len(*reqs) <= len(statuses) {
+			w.WriteHeader(statuses[len(*reqs)-1])
+			_, _ = w.Write([]byte(`{"type":"error"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type":    "message",
+			"role":    "assistant",
+			"model":   "claude-test",
+			"content": []map[string]any{{"type": "text", "text": text}},
+			"usage":   map[string]any{"input_tokens": 50, "output_tokens": 10},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{}
+	cfg.App.Anthropic.Model = "claude-test"
+	cfg.App.Anthropic.MaxTokensSummarize = 2048
+	cfg.App.Anthropic.MaxTokensResponse = 1024
+	cfg.App.OpenAI.MaxRetries = 2
+	cfg.App.OpenAI.RetryBaseDelayMs = 1
+	cfg.App.Prompts.SummarizeSystem = "Summarize as JSON"
+
+	c := NewAnthropic("anthropic-key", cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.baseURL = srv.URL
+	return c
+}
+
+func TestAnthropicSummarize(t *testing.T) {
+	var reqs []anthropicRequest
+	raw := `{"chat_summary":{"summary":"ok"},"user_profiles":{}}`
+	c := newFakeAnthropicClient(t, nil, raw, &reqs)
+
+	resp, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if resp.ChatSummary.Summary != "ok" || resp.Raw != raw {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if resp.Usage != (Usage{Model: "claude-test", PromptTokens: 50, CompletionTokens: 10}) {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(reqs))
+	}
+	if reqs[0].Model != "claude-test" || reqs[0].MaxTokens != 2048 || reqs[0].System != "Summarize as JSON" {
+		t.Errorf("Unexpected request %+v", reqs[0])
+	}
+}
+
+func TestAnthropicGenerateResponseRetriesOverload(t *testing.T) {
+	var reqs []anthropicRequest
+	c := newFakeAnthropicClient(t, []int{529}, `{"should_reply":true,"response":"hi"}`, &reqs)
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{UserQuery: "hello"})
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if !resp.ShouldReply || resp.Response != "hi" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(reqs) != 2 {
+		t.Errorf("Expected 2 requests, got %d", len(reqs))
+	}
+}
+
+func TestAnthropicDoesNotRetryClientErrors(t *testing.T) {
+	var reqs []anthropicRequest
+	c := newFakeAnthropicClient(t, []int{http.StatusBadRequest}, "{}", &reqs)
+
+	if _, err := c.GenerateResponse(context.Background(), ContextRequest{UserQuery: "hello"}); err == nil {
+		t.Fatal("Expected error for bad request")
+	}
+	if len(reqs) != 1 {
+		t.Errorf("Expected a single request, got %d", len(reqs))
+	}
+}
+
+func TestAnthropicEmptyCompletion(t *testing.T) {
+	var reqs []anthropicRequest
+	c := newFakeAnthropicClient(t, nil, " ", &reqs)
+
+	if _, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1}); !errors.Is(err, ErrEmptyCompletion) {
+		t.Errorf("Expected ErrEmptyCompletion, got %v", err)
+	}
+}
@@ -3,34 +3,99 @@ package gpt
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/shared"
 	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/runtimestats"
 	"github.com/xdefrag/william/pkg/models"
 )
 
+// ErrEmptyCompletion indicates OpenAI returned no usable content (e.g. refusal or content filter)
+var ErrEmptyCompletion = errors.New("empty completion from OpenAI")
+
+// ErrTruncatedCompletion indicates the completion was cut off at the max tokens limit
+var ErrTruncatedCompletion = errors.New("completion truncated at max tokens")
+
+// TruncatedCompletionError reports a summary still truncated at the max tokens limit, with the
+// token usage of all attempts so it can be recorded. It matches ErrTruncatedCompletion.
+type TruncatedCompletionError struct {
+	MaxTokens int
+	Usage     Usage
+}
+
+func (e *TruncatedCompletionError) Error() string {
+	return fmt.Sprintf("summary exceeded %d tokens: %v", e.MaxTokens, ErrTruncatedCompletion)
+}
+
+func (e *TruncatedCompletionError) Unwrap() error {
+	return ErrTruncatedCompletion
+}
+
 // Client wraps OpenAI client
 type Client struct {
 	client *openai.Client
 	config *config.Config
+	stats  *runtimestats.Stats
 	logger *slog.Logger
+
+	// Clients of per-chat API key overrides, one per chat so a changed key replaces the old client
+	mu          sync.Mutex
+	chatClients map[int64]chatClient
+}
+
+// chatClient is the cached OpenAI client of a chat's API key
+type chatClient struct {
+	apiKey string
+	client *openai.Client
 }
 
 // New creates a new GPT client
-func New(apiKey string, cfg *config.Config, logger *slog.Logger) *Client {
+func New(apiKey string, cfg *config.Config, stats *runtimestats.Stats, logger *slog.Logger) *Client {
+	return &Client{
+		client:      newOpenAIClient(apiKey),
+		config:      cfg,
+		stats:       stats,
+		logger:      logger.WithGroup("gpt"),
+		chatClients: make(map[int64]chatClient),
+	}
+}
+
+// newOpenAIClient creates an OpenAI client for the given API key
+func newOpenAIClient(apiKey string) *openai.Client {
 	client := openai.NewClient(
 		option.WithAPIKey(apiKey),
 		option.WithMaxRetries(0), // Disable automatic retries to prevent unnecessary API costs
 	)
-	return &Client{
-		client: &client,
-		config: cfg,
-		logger: logger.WithGroup("gpt"),
+	return &client
+}
+
+// clientFor returns the OpenAI client for a chat's API key override, falling back to the global client
+func (c *Client) clientFor(chatID int64, apiKey string) *openai.Client {
+	if apiKey == "" {
+		return c.client
 	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.chatClients[chatID]
+	if !ok || cached.apiKey != apiKey {
+		cached = chatClient{apiKey: apiKey, client: newOpenAIClient(apiKey)}
+		c.chatClients[chatID] = cached
+	}
+
+	return cached.client
 }
 
 // SummarizeRequest represents request for summarization
@@ -40,12 +105,16 @@ type SummarizeRequest struct {
 	ExistingChatSummary   *models.ChatSummary
 	ExistingUserSummaries map[int64]*models.UserSummary // userID -> UserSummary
 	BotName               string                        // Bot name from config
+	APIKey                string                        // Per-chat OpenAI API key (empty = global key)
+	SystemPrompt          string                        // Per-chat summarize system prompt (empty = global prompt)
 }
 
 // SummarizeResponse represents the structured response from GPT for summarization
 type SummarizeResponse struct {
 	ChatSummary  ChatSummaryData            `json:"chat_summary"`
 	UserProfiles map[string]UserProfileData `json:"user_profiles"`
+	Raw          string                     `json:"-"` // Raw completion content as returned by the model
+	Usage        Usage                      `json:"-"` // Tokens used by the completion
 }
 
 // ChatSummaryData contains chat-level summary information
@@ -65,52 +134,238 @@ type UserProfileData struct {
 
 // ContextRequest represents request for context-aware response
 type ContextRequest struct {
-	ChatSummary      *models.ChatSummary
-	UserSummary      *models.UserSummary
-	RecentMessages   []*models.Message
-	UserQuery        string
-	UserName         string
-	UserID           int64
-	ReplyToText      *string // Text of message being replied to
-	ReplyToIsBot     *bool   // Whether replied-to message is from bot
-	BotName          string  // Bot name from config
+	ChatID         int64
+	APIKey         string // Per-chat OpenAI API key (empty = global key)
+	ChatSummary    *models.ChatSummary
+	UserSummary    *models.UserSummary
+	RecentMessages []*models.Message
+	UserQuery      string
+	UserName       string
+	UserID         int64
+	ReplyToText    *string // Text of message being replied to
+	ReplyToIsBot   *bool   // Whether replied-to message is from bot
+	BotName        string  // Bot name from config
+	SummaryAge     time.Duration
+	SummaryStale   bool // Whether the chat summary is older than the staleness threshold
 }
 
 // MentionResponse represents structured response for mention handling
 type MentionResponse struct {
-	ShouldReply bool   `json:"should_reply"`          // Whether to send a text response
-	Response    string `json:"response,omitempty"`    // Text response (if should_reply is true)
-	Reaction    string `json:"reaction,omitempty"`    // Emoji reaction to set (optional)
+	ShouldReply bool   `json:"should_reply"`        // Whether to send a text response
+	Response    string `json:"response,omitempty"`  // Text response (if should_reply is true)
+	Reaction    string `json:"reaction,omitempty"`  // Emoji reaction to set (optional)
+	Sentiment   string `json:"sentiment,omitempty"` // positive, neutral or negative (optional)
+	Usage       Usage  `json:"-"`                   // Tokens used by the completion
+}
+
+// mentionResponseSchema is the JSON schema of MentionResponse for structured outputs.
+// Strict mode requires every property, so optional fields are nullable or may be empty.
+var mentionResponseSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"should_reply": map[string]any{"type": "boolean"},
+		"response":     map[string]any{"type": "string"},
+		"reaction":     map[string]any{"type": "string"},
+		"sentiment": map[string]any{
+			"type": []string{"string", "null"},
+			"enum": []any{"positive", "neutral", "negative", nil},
+		},
+	},
+	"required":             []string{"should_reply", "response", "reaction", "sentiment"},
+	"additionalProperties": false,
+}
+
+// Usage holds the token counts reported for a completion
+type Usage struct {
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// completionUsage returns the usage of an OpenAI completion
+func completionUsage(resp *openai.ChatCompletion) Usage {
+	return Usage{
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
 }
 
 // Summarize generates summaries for chat and users
 func (c *Client) Summarize(ctx context.Context, req SummarizeRequest) (*SummarizeResponse, error) {
-	// Build messages content with user identification
-	var messagesText string
-	for _, msg := range req.Messages {
-		if msg.Text != nil {
-			var senderInfo string
-			if msg.IsBot {
-				// This is a bot message
-				senderInfo = fmt.Sprintf("Bot (%s)", req.BotName)
-			} else {
-				// Build user identification string
-				senderInfo = fmt.Sprintf("User ID: %d, Name: %s", msg.UserID, msg.UserFirstName)
+	systemPrompt, userPrompt := buildSummarizePrompts(c.config, req)
 
-				if msg.UserLastName != nil && *msg.UserLastName != "" {
-					senderInfo += fmt.Sprintf(" %s", *msg.UserLastName)
-				}
+	// Debug log prompts before sending to OpenAI
+	c.logger.DebugContext(ctx, "Sending prompts to OpenAI for summarization",
+		slog.Int64("chat_id", req.ChatID),
+		slog.String("model", c.config.App.OpenAI.Model),
+		slog.Int("max_tokens", c.config.App.OpenAI.MaxTokensSummarize),
+		slog.Float64("temperature", c.config.App.OpenAI.Temperature),
+	)
 
-				if msg.Username != nil && *msg.Username != "" {
-					senderInfo += fmt.Sprintf(", Username: @%s", *msg.Username)
-				}
-			}
+	params := openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userPrompt),
+		},
+		Model:       shared.ChatModel(c.config.App.OpenAI.Model),
+		Temperature: openai.Float(c.config.App.OpenAI.Temperature),
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		},
+	}
 
-			messagesText += fmt.Sprintf("%s: %s\n", senderInfo, *msg.Text)
+	// A summary cut off at max tokens is invalid JSON; retry with a doubled limit up to the configured cap
+	// Truncated attempts are billed too, so usage adds up over all of them
+	maxTokens := c.config.App.OpenAI.MaxTokensSummarize
+	var resp *openai.ChatCompletion
+	var usage Usage
+	for {
+		params.MaxTokens = openai.Int(int64(maxTokens))
+		var err error
+		resp, err = c.complete(ctx, req.ChatID, req.APIKey, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call OpenAI: %w", err)
 		}
+		usage = usage.Add(completionUsage(resp))
+		if !completionTruncated(resp) {
+			break
+		}
+
+		next := nextSummarizeMaxTokens(maxTokens, c.config.App.OpenAI.MaxTokensSummarizeLimit)
+		if next == 0 {
+			return nil, &TruncatedCompletionError{MaxTokens: maxTokens, Usage: usage}
+		}
+		c.logger.WarnContext(ctx, "OpenAI summary truncated, retrying with a higher token limit",
+			slog.Int64("chat_id", req.ChatID),
+			slog.Int("max_tokens", maxTokens),
+			slog.Int("retry_max_tokens", next),
+		)
+		maxTokens = next
 	}
 
-	systemPrompt := c.config.App.Prompts.SummarizeSystem
+	content, err := completionContent(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SummarizeResponse
+	if err := unmarshalCompletion(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+	}
+	result.Raw = content
+	result.Usage = usage
+
+	return &result, nil
+}
+
+// GenerateResponse creates context-aware response for user query
+func (c *Client) GenerateResponse(ctx context.Context, req ContextRequest) (*MentionResponse, error) {
+	systemPrompt, userPrompt := buildResponsePrompts(c.config, req)
+
+	// Debug log prompts before sending to OpenAI
+	c.logger.DebugContext(ctx, "Sending prompts to OpenAI for response generation",
+		slog.String("user_name", req.UserName),
+		slog.String("model", c.config.App.OpenAI.Model),
+		slog.Int("max_tokens", c.config.App.OpenAI.MaxTokensResponse),
+		slog.Float64("temperature", c.config.App.OpenAI.Temperature),
+	)
+
+	params := openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userPrompt),
+		},
+		Model:          shared.ChatModel(c.config.App.OpenAI.Model),
+		MaxTokens:      openai.Int(int64(c.config.App.OpenAI.MaxTokensResponse)),
+		Temperature:    openai.Float(c.config.App.OpenAI.Temperature),
+		ResponseFormat: c.mentionResponseFormat(),
+	}
+	resp, err := c.complete(ctx, req.ChatID, req.APIKey, params)
+	plainText := false
+	if err != nil && c.config.App.OpenAI.StructuredOutput && isResponseFormatUnsupported(err) {
+		c.logger.WarnContext(ctx, "Structured output is not supported by the model, falling back to plain text",
+			slog.String("model", c.config.App.OpenAI.Model),
+			slog.Any("error", err),
+		)
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{}
+		resp, err = c.complete(ctx, req.ChatID, req.APIKey, params)
+		plainText = true
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI: %w", err)
+	}
+
+	content, err := completionContent(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MentionResponse
+	if err := unmarshalCompletion(content, &result); err != nil {
+		if !plainText {
+			return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+		}
+		// Without a response format the model may answer in plain text
+		result = MentionResponse{ShouldReply: true, Response: strings.TrimSpace(content)}
+	}
+	result.Usage = completionUsage(resp)
+
+	return &result, nil
+}
+
+// mentionResponseFormat returns the response format of mention completions: the
+// MentionResponse schema with structured output enabled, else JSON mode
+func (c *Client) mentionResponseFormat() openai.ChatCompletionNewParamsResponseFormatUnion {
+	if !c.config.App.OpenAI.StructuredOutput {
+		return openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	}
+
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+			JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   "mention_response",
+				Strict: openai.Bool(true),
+				Schema: mentionResponseSchema,
+			},
+		},
+	}
+}
+
+// isResponseFormatUnsupported reports whether the request was rejected because the model
+// doesn't support the requested response format
+func isResponseFormatUnsupported(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return apiErr.Param == "response_format" || strings.Contains(apiErr.Message, "response_format")
+}
+
+// responseFormatNote describes the mention response JSON for prompts that don't
+const responseFormatNote = `Reply with a JSON object: {"should_reply": true or false, "response": "reply text or empty string", "reaction": "emoji or empty string", "sentiment": "positive, neutral, negative or null"}`
+
+// summarizeFormatNote describes the summarization JSON for prompts that don't
+const summarizeFormatNote = `Reply with a JSON object: {"chat_summary": {"summary": "summary text", "topics": {"topic": count}, "next_events": [{"title": "event title", "date": "ISO 8601 date or null"}]}, "user_profiles": {"<user id>": {"likes": {"interest": count}, "dislikes": {"topic": count}, "competencies": {"skill": count}, "traits": {"trait": "description"}}}}`
+
+// buildSummarizePrompts builds the system and user prompts for chat summarization
+func buildSummarizePrompts(cfg *config.Config, req SummarizeRequest) (string, string) {
+	// Build messages content with user identification
+	highlightBotReplies := cfg.App.App.HighlightBotReplies
+	messagesText := formatSummarizeMessages(req.Messages, req.BotName, highlightBotReplies)
+
+	includeLegacy := !cfg.App.Prompts.OmitLegacyFields
+
+	// A chat override describes what to summarize, not the output: the reply must still
+	// parse as SummarizeResponse, and JSON mode needs the prompt to ask for JSON
+	systemPrompt := cfg.App.Prompts.SummarizeSystem
+	if req.SystemPrompt != "" {
+		systemPrompt = req.SystemPrompt + "\n\n" + summarizeFormatNote
+	} else if !strings.Contains(strings.ToLower(systemPrompt), "json") {
+		systemPrompt += "\n\n" + summarizeFormatNote
+	}
 
 	// Build enhanced user prompt with existing data
 	userPrompt := fmt.Sprintf("Chat ID: %d\n\n", req.ChatID)
@@ -120,18 +375,16 @@ func (c *Client) Summarize(ctx context.Context, req SummarizeRequest) (*Summariz
 		userPrompt += "EXISTING CHAT SUMMARY:\n"
 		userPrompt += fmt.Sprintf("Summary: %s\n", req.ExistingChatSummary.Summary)
 
+		// Only names: stored counts are merged server-side, totals here would be counted twice
 		if len(req.ExistingChatSummary.TopicsJSON) > 0 {
-			topicsJSON, _ := json.Marshal(req.ExistingChatSummary.TopicsJSON)
-			userPrompt += fmt.Sprintf("Topics: %s\n", string(topicsJSON))
-		}
-
-		if req.ExistingChatSummary.NextEvents != nil {
-			userPrompt += fmt.Sprintf("Next events (legacy): %s\n", *req.ExistingChatSummary.NextEvents)
+			userPrompt += fmt.Sprintf("Topics: %s\n", countNames(req.ExistingChatSummary.TopicsJSON))
 		}
 
 		if len(req.ExistingChatSummary.NextEventsJSON) > 0 {
 			eventsJSON, _ := json.Marshal(req.ExistingChatSummary.NextEventsJSON)
 			userPrompt += fmt.Sprintf("Next events: %s\n", string(eventsJSON))
+		} else if includeLegacy && req.ExistingChatSummary.NextEvents != nil {
+			userPrompt += fmt.Sprintf("Next events (legacy): %s\n", *req.ExistingChatSummary.NextEvents)
 		}
 		userPrompt += "\n"
 	}
@@ -143,86 +396,127 @@ func (c *Client) Summarize(ctx context.Context, req SummarizeRequest) (*Summariz
 			userPrompt += fmt.Sprintf("User ID %d:\n", userID)
 
 			if len(summary.LikesJSON) > 0 {
-				likesJSON, _ := json.Marshal(summary.LikesJSON)
-				userPrompt += fmt.Sprintf("  Likes: %s\n", string(likesJSON))
+				userPrompt += fmt.Sprintf("  Likes: %s\n", countNames(summary.LikesJSON))
 			}
 
 			if len(summary.DislikesJSON) > 0 {
-				dislikesJSON, _ := json.Marshal(summary.DislikesJSON)
-				userPrompt += fmt.Sprintf("  Dislikes: %s\n", string(dislikesJSON))
+				userPrompt += fmt.Sprintf("  Dislikes: %s\n", countNames(summary.DislikesJSON))
 			}
 
 			if len(summary.CompetenciesJSON) > 0 {
-				competenciesJSON, _ := json.Marshal(summary.CompetenciesJSON)
-				userPrompt += fmt.Sprintf("  Competencies: %s\n", string(competenciesJSON))
-			}
-
-			if summary.Traits != nil {
-				userPrompt += fmt.Sprintf("  Traits (legacy): %s\n", *summary.Traits)
+				userPrompt += fmt.Sprintf("  Competencies: %s\n", countNames(summary.CompetenciesJSON))
 			}
 
 			if len(summary.TraitsJSON) > 0 {
 				traitsJSON, _ := json.Marshal(summary.TraitsJSON)
 				userPrompt += fmt.Sprintf("  Traits: %s\n", string(traitsJSON))
+			} else if includeLegacy && summary.Traits != nil {
+				userPrompt += fmt.Sprintf("  Traits (legacy): %s\n", *summary.Traits)
 			}
 			userPrompt += "\n"
 		}
 	}
 
 	userPrompt += fmt.Sprintf("NEW MESSAGES:\n%s\n", messagesText)
-	userPrompt += "IMPORTANT: Update and enhance the existing data with new information from the messages. Do not replace existing data, but merge and improve it."
+	if highlightBotReplies && hasBotConversation(req.Messages) {
+		userPrompt += fmt.Sprintf("Messages marked %s are questions to the assistant and its answers. "+
+			"Give the topics and facts from these conversations extra weight in the summary.\n\n", botConversationLabel)
+	}
+	userPrompt += "IMPORTANT: Update the existing summary and profiles with new information from the messages. " +
+		"Counts of topics, likes, dislikes and competencies must cover only the NEW MESSAGES: " +
+		"they are added to the stored counts, so never include previous totals. Reuse existing names for the same things."
 
-	// Debug log prompts before sending to OpenAI
-	c.logger.DebugContext(ctx, "Sending prompts to OpenAI for summarization",
-		slog.Int64("chat_id", req.ChatID),
-		slog.String("model", c.config.App.OpenAI.Model),
-		slog.Int("max_tokens", c.config.App.OpenAI.MaxTokensSummarize),
-		slog.Float64("temperature", c.config.App.OpenAI.Temperature),
-	)
+	return systemPrompt, userPrompt
+}
 
-	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
-		Model:       shared.ChatModel(c.config.App.OpenAI.Model),
-		MaxTokens:   openai.Int(int64(c.config.App.OpenAI.MaxTokensSummarize)),
-		Temperature: openai.Float(c.config.App.OpenAI.Temperature),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to call OpenAI: %w", err)
+// countNames lists the keys of stored counts, sorted, without their values
+func countNames(counts map[string]interface{}) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
+// botConversationLabel marks replies to the bot and its answers in the summarize prompt
+const botConversationLabel = "[Q&A with assistant]"
 
-	content := resp.Choices[0].Message.Content
+// isBotConversation reports whether the message is a reply to the bot or a bot answer to a message
+func isBotConversation(msg *models.Message) bool {
+	return msg.ReplyToBot || (msg.IsBot && msg.ReplyToMsgID != nil)
+}
 
-	var result SummarizeResponse
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+// hasBotConversation reports whether any message is part of a conversation with the bot
+func hasBotConversation(messages []*models.Message) bool {
+	for _, msg := range messages {
+		if msg.Text != nil && isBotConversation(msg) {
+			return true
+		}
 	}
+	return false
+}
 
-	return &result, nil
+// formatSummarizeMessages renders messages with sender identification for the summarize prompt.
+// With highlightBotReplies, conversations with the bot are labeled as Q&A with the assistant.
+func formatSummarizeMessages(messages []*models.Message, botName string, highlightBotReplies bool) string {
+	var messagesText string
+	for _, msg := range messages {
+		if msg.Text != nil {
+			var senderInfo string
+			if msg.IsBot {
+				// This is a bot message
+				senderInfo = fmt.Sprintf("Bot (%s)", botName)
+			} else {
+				// Build user identification string
+				senderInfo = fmt.Sprintf("User ID: %d, Name: %s", msg.UserID, msg.UserFirstName)
+
+				if msg.UserLastName != nil && *msg.UserLastName != "" {
+					senderInfo += fmt.Sprintf(" %s", *msg.UserLastName)
+				}
+
+				if msg.Username != nil && *msg.Username != "" {
+					senderInfo += fmt.Sprintf(", Username: @%s", *msg.Username)
+				}
+			}
+
+			if msg.ForwardOrigin != nil && *msg.ForwardOrigin != "" {
+				senderInfo += fmt.Sprintf(" [forwarded from %s]", *msg.ForwardOrigin)
+			}
+
+			if highlightBotReplies && isBotConversation(msg) {
+				senderInfo += " " + botConversationLabel
+			}
+
+			messagesText += fmt.Sprintf("%s: %s\n", senderInfo, *msg.Text)
+		}
+	}
+	return messagesText
 }
 
-// GenerateResponse creates context-aware response for user query
-func (c *Client) GenerateResponse(ctx context.Context, req ContextRequest) (*MentionResponse, error) {
-	// Build system prompt
-	systemPrompt := c.config.App.Prompts.ResponseSystem
+// buildResponsePrompts assembles system and user prompts for a mention response
+func buildResponsePrompts(cfg *config.Config, req ContextRequest) (string, string) {
+	// Build system prompt; JSON mode needs the prompt to ask for JSON, which a custom one may not do
+	includeLegacy := !cfg.App.Prompts.OmitLegacyFields
+
+	systemPrompt := cfg.App.Prompts.ResponseSystem
+	if !strings.Contains(strings.ToLower(systemPrompt), "json") {
+		systemPrompt += "\n\n" + responseFormatNote
+	}
 
 	// Add chat context
 	if req.ChatSummary != nil {
 		systemPrompt += fmt.Sprintf("\n\nChat context:\nSummary: %s", req.ChatSummary.Summary)
 
-		if req.ChatSummary.NextEvents != nil {
-			systemPrompt += fmt.Sprintf("\nUpcoming events (legacy): %s", *req.ChatSummary.NextEvents)
+		if req.SummaryStale {
+			systemPrompt += fmt.Sprintf("\nNote: chat summary is %d hours old and may be outdated", int(req.SummaryAge.Hours()))
 		}
 
 		if len(req.ChatSummary.NextEventsJSON) > 0 {
 			eventsJSON, _ := json.Marshal(req.ChatSummary.NextEventsJSON)
 			systemPrompt += fmt.Sprintf("\nUpcoming events: %s", string(eventsJSON))
+		} else if includeLegacy && req.ChatSummary.NextEvents != nil {
+			systemPrompt += fmt.Sprintf("\nUpcoming events (legacy): %s", *req.ChatSummary.NextEvents)
 		}
 
 		if len(req.ChatSummary.TopicsJSON) > 0 {
@@ -250,13 +544,11 @@ func (c *Client) GenerateResponse(ctx context.Context, req ContextRequest) (*Men
 			systemPrompt += fmt.Sprintf("\nCompetencies: %s", string(competenciesJSON))
 		}
 
-		if req.UserSummary.Traits != nil {
-			systemPrompt += fmt.Sprintf("\nTraits (legacy): %s", *req.UserSummary.Traits)
-		}
-
 		if len(req.UserSummary.TraitsJSON) > 0 {
 			traitsJSON, _ := json.Marshal(req.UserSummary.TraitsJSON)
 			systemPrompt += fmt.Sprintf("\nTraits: %s", string(traitsJSON))
+		} else if includeLegacy && req.UserSummary.Traits != nil {
+			systemPrompt += fmt.Sprintf("\nTraits (legacy): %s", *req.UserSummary.Traits)
 		}
 	}
 
@@ -302,37 +594,103 @@ func (c *Client) GenerateResponse(ctx context.Context, req ContextRequest) (*Men
 
 	userPrompt := recentContext + replyContext + fmt.Sprintf("\n\nUser query from user ID %d (%s): %s", req.UserID, req.UserName, req.UserQuery)
 
-	// Debug log prompts before sending to OpenAI
-	c.logger.DebugContext(ctx, "Sending prompts to OpenAI for response generation",
-		slog.String("user_name", req.UserName),
-		slog.String("model", c.config.App.OpenAI.Model),
-		slog.Int("max_tokens", c.config.App.OpenAI.MaxTokensResponse),
-		slog.Float64("temperature", c.config.App.OpenAI.Temperature),
-	)
+	return systemPrompt, userPrompt
+}
 
-	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
-		Model:       shared.ChatModel(c.config.App.OpenAI.Model),
-		MaxTokens:   openai.Int(int64(c.config.App.OpenAI.MaxTokensResponse)),
-		Temperature: openai.Float(c.config.App.OpenAI.Temperature),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to call OpenAI: %w", err)
+// complete sends a completion request, retrying rate-limit and 5xx errors with exponential backoff
+func (c *Client) complete(ctx context.Context, chatID int64, apiKey string, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	maxRetries := c.config.App.OpenAI.MaxRetries
+	baseDelay := time.Duration(c.config.App.OpenAI.RetryBaseDelayMs) * time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		c.stats.IncOpenAICalls()
+		resp, err := c.clientFor(chatID, apiKey).Chat.Completions.New(ctx, params)
+		if err == nil || attempt >= maxRetries || !isRetryableError(err) {
+			return resp, err
+		}
+
+		delay := retryDelay(baseDelay, attempt)
+		c.logger.DebugContext(ctx, "Retrying OpenAI request",
+			slog.Any("error", err),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isRetryableError reports whether OpenAI rejected the request with a rate-limit or server error
+func isRetryableError(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return isRetryableStatus(apiErr.StatusCode)
+}
+
+// isRetryableStatus reports whether an HTTP status is a rate-limit or server error
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryDelay doubles the base delay per attempt and adds up to one base delay of jitter
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	return base<<attempt + rand.N(base)
+}
+
+// unmarshalCompletion parses JSON completion content. If the model wrapped the JSON in text
+// or markdown fences, the outermost {...} block is parsed instead.
+func unmarshalCompletion(content string, v any) error {
+	err := json.Unmarshal([]byte(content), v)
+	if err == nil {
+		return nil
 	}
 
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return err
+	}
+
+	if jsonErr := json.Unmarshal([]byte(content[start:end+1]), v); jsonErr != nil {
+		return err
+	}
+	return nil
+}
+
+// completionTruncated reports whether the completion stopped at the max tokens limit
+func completionTruncated(resp *openai.ChatCompletion) bool {
+	return len(resp.Choices) > 0 && resp.Choices[0].FinishReason == "length"
+}
+
+// nextSummarizeMaxTokens doubles a truncated summary's max tokens up to limit, returning 0 when
+// no higher limit is allowed
+func nextSummarizeMaxTokens(current, limit int) int {
+	next := min(current*2, limit)
+	if next <= current {
+		return 0
+	}
+	return next
+}
+
+// completionContent extracts the message content from a completion, rejecting empty content
+func completionContent(resp *openai.ChatCompletion) (string, error) {
 	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
+		return "", fmt.Errorf("no response from OpenAI")
 	}
 
 	content := resp.Choices[0].Message.Content
-
-	var result MentionResponse
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+	if strings.TrimSpace(content) == "" {
+		return "", ErrEmptyCompletion
 	}
 
-	return &result, nil
+	return content, nil
 }
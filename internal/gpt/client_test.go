@@ -0,0 +1,620 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func newTestClient() *Client {
+	return New("global-key", &config.Config{}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// newTestServerClient returns a client whose completions are served with the given content
+func newTestServerClient(t *testing.T, content string) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": content},
+			}},
+			"usage": map[string]any{"prompt_tokens": 120, "completion_tokens": 30, "total_tokens": 150},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newTestClient()
+	client := openai.NewClient(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	c.client = &client
+	return c
+}
+
+// newTruncatingServerClient returns a client whose completions report length truncation while
+// max_tokens is below fitTokens, recording the max_tokens of each request
+func newTruncatingServerClient(t *testing.T, content string, fitTokens int) (*Client, *[]int) {
+	t.Helper()
+
+	var requested []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requested = append(requested, body.MaxTokens)
+
+		finishReason, reply := "stop", content
+		if body.MaxTokens < fitTokens {
+			finishReason, reply = "length", content[:len(content)/2]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": finishReason,
+				"message":       map[string]any{"role": "assistant", "content": reply},
+			}},
+			"usage": map[string]any{"prompt_tokens": 120, "completion_tokens": body.MaxTokens, "total_tokens": 120 + body.MaxTokens},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newTestClient()
+	client := openai.NewClient(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	c.client = &client
+	return c, &requested
+}
+
+func TestClientForPerChatKey(t *testing.T) {
+	c := newTestClient()
+
+	if c.clientFor(1, "") != c.client {
+		t.Error("Expected empty key to use the global client")
+	}
+
+	override := c.clientFor(1, "chat-key")
+	if override == c.client {
+		t.Error("Expected chat key override to use a separate client")
+	}
+
+	if c.clientFor(1, "chat-key") != override {
+		t.Error("Expected client for the same key to be cached")
+	}
+
+	// A changed key replaces the chat's client instead of adding one
+	if c.clientFor(1, "other-key") == override {
+		t.Error("Expected different keys to use different clients")
+	}
+	if len(c.chatClients) != 1 {
+		t.Errorf("Expected one cached client per chat, got %d", len(c.chatClients))
+	}
+}
+
+func TestSummarizeUsesChatKey(t *testing.T) {
+	var authorizations []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": `{"chat_summary":{"summary":"ok"},"user_profiles":{}}`},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("OPENAI_BASE_URL", srv.URL)
+
+	c := newTestClient()
+	for _, req := range []SummarizeRequest{{ChatID: 1, APIKey: "chat-key"}, {ChatID: 2}} {
+		if _, err := c.Summarize(context.Background(), req); err != nil {
+			t.Fatalf("Summarize failed: %v", err)
+		}
+	}
+
+	if len(authorizations) != 2 || authorizations[0] != "Bearer chat-key" || authorizations[1] != "Bearer global-key" {
+		t.Errorf("Expected the chat key for its chat and the global key otherwise, got %v", authorizations)
+	}
+}
+
+func TestSummarizeEmptyCompletion(t *testing.T) {
+	c := newTestServerClient(t, "  \n")
+
+	_, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if !errors.Is(err, ErrEmptyCompletion) {
+		t.Errorf("Expected ErrEmptyCompletion, got %v", err)
+	}
+}
+
+func TestSummarizeRetriesTruncatedCompletion(t *testing.T) {
+	raw := `{"chat_summary":{"summary":"ok","topics":{"go":2}},"user_profiles":{}}`
+
+	c, requested := newTruncatingServerClient(t, raw, 4096)
+	c.config.App.OpenAI.MaxTokensSummarize = 1024
+	c.config.App.OpenAI.MaxTokensSummarizeLimit = 8192
+
+	resp, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if resp.ChatSummary.Summary != "ok" {
+		t.Errorf("Expected the retried summary, got %+v", resp.ChatSummary)
+	}
+	if want := []int{1024, 2048, 4096}; !reflect.DeepEqual(*requested, want) {
+		t.Errorf("Expected max_tokens %v, got %v", want, *requested)
+	}
+	if want := (Usage{Model: "gpt-4o-mini", PromptTokens: 360, CompletionTokens: 1024 + 2048 + 4096}); resp.Usage != want {
+		t.Errorf("Expected usage of all attempts %+v, got %+v", want, resp.Usage)
+	}
+
+	// Still truncated at the cap
+	c, requested = newTruncatingServerClient(t, raw, 4096)
+	c.config.App.OpenAI.MaxTokensSummarize = 1024
+	c.config.App.OpenAI.MaxTokensSummarizeLimit = 3000
+
+	_, err = c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if !errors.Is(err, ErrTruncatedCompletion) {
+		t.Errorf("Expected ErrTruncatedCompletion, got %v", err)
+	}
+	if want := []int{1024, 2048, 3000}; !reflect.DeepEqual(*requested, want) {
+		t.Errorf("Expected max_tokens %v, got %v", want, *requested)
+	}
+	var truncated *TruncatedCompletionError
+	if !errors.As(err, &truncated) || truncated.Usage.CompletionTokens != 1024+2048+3000 {
+		t.Errorf("Expected the truncated attempts' usage in the error, got %v", err)
+	}
+
+	// No retry without a limit
+	c, requested = newTruncatingServerClient(t, raw, 4096)
+	c.config.App.OpenAI.MaxTokensSummarize = 1024
+
+	_, err = c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if !errors.Is(err, ErrTruncatedCompletion) || len(*requested) != 1 {
+		t.Errorf("Expected a single truncated request, got %v after %v", err, *requested)
+	}
+}
+
+func TestSummarizeKeepsRawCompletion(t *testing.T) {
+	raw := `{"chat_summary":{"summary":"ok","topics":{"go":2}},"user_profiles":{}}`
+	c := newTestServerClient(t, raw)
+
+	resp, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if resp.Raw != raw {
+		t.Errorf("Expected raw completion %q, got %q", raw, resp.Raw)
+	}
+	if resp.Usage != (Usage{Model: "gpt-4o-mini", PromptTokens: 120, CompletionTokens: 30}) {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestSummarizeParsesFencedCompletion(t *testing.T) {
+	raw := "```json\n{\"chat_summary\":{\"summary\":\"ok\"},\"user_profiles\":{}}\n```"
+	c := newTestServerClient(t, raw)
+
+	resp, err := c.Summarize(context.Background(), SummarizeRequest{ChatID: 1})
+	if err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if resp.ChatSummary.Summary != "ok" {
+		t.Errorf("Expected summary %q, got %q", "ok", resp.ChatSummary.Summary)
+	}
+}
+
+func TestUnmarshalCompletion(t *testing.T) {
+	var result MentionResponse
+	if err := unmarshalCompletion(`Sure! {"should_reply":true,"response":"hi"} Hope it helps.`, &result); err != nil {
+		t.Fatalf("Expected embedded JSON to parse, got %v", err)
+	}
+	if !result.ShouldReply || result.Response != "hi" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if err := unmarshalCompletion("no json here", &result); err == nil {
+		t.Error("Expected error for content without JSON")
+	}
+}
+
+func TestGenerateResponseEmptyCompletion(t *testing.T) {
+	c := newTestServerClient(t, "")
+
+	_, err := c.GenerateResponse(context.Background(), ContextRequest{ChatID: 1, UserQuery: "hi"})
+	if !errors.Is(err, ErrEmptyCompletion) {
+		t.Errorf("Expected ErrEmptyCompletion, got %v", err)
+	}
+}
+
+func TestBuildResponsePromptsStaleSummaryNote(t *testing.T) {
+	client := newTestClient()
+	req := ContextRequest{
+		ChatSummary:  &models.ChatSummary{Summary: "old news"},
+		SummaryAge:   30 * time.Hour,
+		SummaryStale: true,
+	}
+
+	systemPrompt, _ := buildResponsePrompts(client.config, req)
+	if !strings.Contains(systemPrompt, "Note: chat summary is 30 hours old") {
+		t.Errorf("Expected stale summary note in system prompt, got %q", systemPrompt)
+	}
+
+	req.SummaryStale = false
+	systemPrompt, _ = buildResponsePrompts(client.config, req)
+	if strings.Contains(systemPrompt, "Note: chat summary") {
+		t.Errorf("Expected no stale summary note for fresh summary, got %q", systemPrompt)
+	}
+}
+
+func TestFormatSummarizeMessagesForwardOrigin(t *testing.T) {
+	text := "Interesting article"
+	origin := "Tech News (@technews)"
+	messages := []*models.Message{
+		{UserID: 1, UserFirstName: "Ann", Text: &text, ForwardOrigin: &origin},
+		{UserID: 2, UserFirstName: "Bob", Text: &text},
+	}
+
+	got := formatSummarizeMessages(messages, "William", false)
+	want := "User ID: 1, Name: Ann [forwarded from Tech News (@technews)]: Interesting article\n" +
+		"User ID: 2, Name: Bob: Interesting article\n"
+	if got != want {
+		t.Errorf("formatSummarizeMessages() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatSummarizeMessagesBotConversation(t *testing.T) {
+	question := "Когда релиз?"
+	answer := "В пятницу"
+	other := "Всем привет"
+	replyTo := int64(10)
+	messages := []*models.Message{
+		{UserID: 1, UserFirstName: "Ann", Text: &question, ReplyToMsgID: &replyTo, ReplyToBot: true},
+		{IsBot: true, Text: &answer, ReplyToMsgID: &replyTo},
+		{UserID: 2, UserFirstName: "Bob", Text: &other, ReplyToMsgID: &replyTo},
+	}
+
+	got := formatSummarizeMessages(messages, "William", true)
+	want := "User ID: 1, Name: Ann [Q&A with assistant]: Когда релиз?\n" +
+		"Bot (William) [Q&A with assistant]: В пятницу\n" +
+		"User ID: 2, Name: Bob: Всем привет\n"
+	if got != want {
+		t.Errorf("formatSummarizeMessages() = %q, want %q", got, want)
+	}
+
+	if plain := formatSummarizeMessages(messages, "William", false); strings.Contains(plain, botConversationLabel) {
+		t.Errorf("Expected no labels when highlighting is disabled, got %q", plain)
+	}
+}
+
+// newStatusServerClient returns a client whose completions fail with the given statuses before succeeding
+func newStatusServerClient(t *testing.T, statuses []int, calls *int) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		if *calls <= len(statuses) {
+			w.WriteHeader(statuses[*calls-1])
+			_, _ = w.Write([]byte(`{"error":{"message":"failed"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": `{"response":"ok"}`},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newTestClient()
+	c.config.App.OpenAI.MaxRetries = 2
+	c.config.App.OpenAI.RetryBaseDelayMs = 1
+	client := openai.NewClient(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	c.client = &client
+	return c
+}
+
+func TestGenerateResponseRetriesTransientErrors(t *testing.T) {
+	var calls int
+	c := newStatusServerClient(t, []int{http.StatusTooManyRequests, http.StatusBadGateway}, &calls)
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{UserQuery: "hi"})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if resp.Response != "ok" {
+		t.Errorf("Expected response %q, got %q", "ok", resp.Response)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestGenerateResponseDoesNotRetryClientErrors(t *testing.T) {
+	var calls int
+	c := newStatusServerClient(t, []int{http.StatusBadRequest}, &calls)
+
+	if _, err := c.GenerateResponse(context.Background(), ContextRequest{UserQuery: "hi"}); err == nil {
+		t.Fatal("Expected error for bad request")
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call, got %d", calls)
+	}
+}
+
+func TestGenerateResponseRetryLimit(t *testing.T) {
+	var calls int
+	c := newStatusServerClient(t, []int{500, 500, 500, 500}, &calls)
+
+	if _, err := c.GenerateResponse(context.Background(), ContextRequest{UserQuery: "hi"}); err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestBuildSummarizePromptsChatOverride(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Prompts.SummarizeSystem = "global prompt"
+
+	systemPrompt, _ := buildSummarizePrompts(cfg, SummarizeRequest{ChatID: 1, SystemPrompt: "Extract support issues"})
+	if !strings.HasPrefix(systemPrompt, "Extract support issues") || strings.Contains(systemPrompt, "global prompt") {
+		t.Errorf("Expected chat override prompt, got %q", systemPrompt)
+	}
+	// JSON mode rejects prompts without "json", and the reply must parse as SummarizeResponse
+	if !strings.HasSuffix(systemPrompt, summarizeFormatNote) || !strings.Contains(strings.ToLower(systemPrompt), "json") {
+		t.Errorf("Expected the output format appended to the override, got %q", systemPrompt)
+	}
+
+	systemPrompt, _ = buildSummarizePrompts(cfg, SummarizeRequest{ChatID: 1})
+	if systemPrompt != "global prompt\n\n"+summarizeFormatNote {
+		t.Errorf("Expected the format appended to a global prompt without JSON, got %q", systemPrompt)
+	}
+
+	cfg.App.Prompts.SummarizeSystem = "Summarize as JSON"
+	if systemPrompt, _ = buildSummarizePrompts(cfg, SummarizeRequest{ChatID: 1}); systemPrompt != "Summarize as JSON" {
+		t.Errorf("Expected a global prompt asking for JSON unchanged, got %q", systemPrompt)
+	}
+}
+
+func TestBuildSummarizePromptsOmitsStoredCounts(t *testing.T) {
+	text := "ещё немного про go"
+	_, userPrompt := buildSummarizePrompts(&config.Config{}, SummarizeRequest{
+		ChatID:              1,
+		Messages:            []*models.Message{{UserID: 1, UserFirstName: "Alice", Text: &text}},
+		ExistingChatSummary: &models.ChatSummary{Summary: "про go", TopicsJSON: map[string]interface{}{"go": 5.0, "rust": 2.0}},
+		ExistingUserSummaries: map[int64]*models.UserSummary{
+			1: {LikesJSON: map[string]interface{}{"go": 4.0}},
+		},
+	})
+
+	// Stored totals are merged server-side; sending them would make the model return them again
+	if strings.Contains(userPrompt, "5") || strings.Contains(userPrompt, "4") || strings.Contains(userPrompt, "merge and improve") {
+		t.Errorf("Expected no stored counts in the summarize prompt, got %q", userPrompt)
+	}
+	if !strings.Contains(userPrompt, "Topics: go, rust") || !strings.Contains(userPrompt, "Likes: go") {
+		t.Errorf("Expected stored count names for consistent naming, got %q", userPrompt)
+	}
+	if !strings.Contains(userPrompt, "only the NEW MESSAGES") {
+		t.Errorf("Expected counts limited to new messages, got %q", userPrompt)
+	}
+}
+
+func TestGenerateResponseShouldNotReply(t *testing.T) {
+	c := newTestServerClient(t, `{"should_reply":false,"response":"","reaction":"👍"}`)
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{ChatID: 1, UserQuery: "спасибо"})
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if resp.ShouldReply || resp.Response != "" {
+		t.Errorf("Expected reaction-only response, got %+v", resp)
+	}
+	if resp.Reaction != "👍" {
+		t.Errorf("Expected reaction %q, got %q", "👍", resp.Reaction)
+	}
+}
+
+func TestBuildResponsePromptsAddsJSONFormat(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Prompts.ResponseSystem = "Ты секретарь чата."
+
+	systemPrompt, _ := buildResponsePrompts(cfg, ContextRequest{})
+	if !strings.Contains(systemPrompt, responseFormatNote) {
+		t.Errorf("Expected format note in prompt without JSON instructions, got %q", systemPrompt)
+	}
+
+	cfg.App.Prompts.ResponseSystem = "OUTPUT FORMAT (СТРОГО JSON)"
+	if systemPrompt, _ := buildResponsePrompts(cfg, ContextRequest{}); strings.Contains(systemPrompt, responseFormatNote) {
+		t.Errorf("Expected prompt with JSON instructions to be kept, got %q", systemPrompt)
+	}
+}
+
+// newFormatServerClient returns a structured output client whose completions are served with the
+// given content; requests with a JSON schema response format are rejected when schemaUnsupported
+func newFormatServerClient(t *testing.T, content string, schemaUnsupported bool, formats *[]string) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*formats = append(*formats, body.ResponseFormat.Type)
+
+		w.Header().Set("Content-Type", "application/json")
+		if schemaUnsupported && body.ResponseFormat.Type == "json_schema" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Invalid parameter: 'response_format' of type 'json_schema' is not supported with this model.","param":"response_format"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": content},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newTestClient()
+	c.config.App.OpenAI.StructuredOutput = true
+	client := openai.NewClient(
+		option.WithAPIKey("test-key"),
+		option.WithBaseURL(srv.URL),
+		option.WithMaxRetries(0),
+	)
+	c.client = &client
+	return c
+}
+
+func TestMentionResponseSchemaConformance(t *testing.T) {
+	properties := mentionResponseSchema["properties"].(map[string]any)
+	required := mentionResponseSchema["required"].([]string)
+	if len(required) != len(properties) {
+		t.Errorf("Strict schema must require every property, got %v", required)
+	}
+	for _, name := range required {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("Required property %q is missing from schema", name)
+		}
+	}
+
+	var formats []string
+	c := newFormatServerClient(t, `{"should_reply":true,"response":"Привет","reaction":"👍","sentiment":null}`, false, &formats)
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{ChatID: 1, UserQuery: "привет"})
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if !resp.ShouldReply || resp.Response != "Привет" || resp.Reaction != "👍" || resp.Sentiment != "" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(formats) != 1 || formats[0] != "json_schema" {
+		t.Errorf("Expected a single json_schema request, got %v", formats)
+	}
+}
+
+func TestGenerateResponseStructuredOutputFallback(t *testing.T) {
+	var formats []string
+	c := newFormatServerClient(t, "Привет! Чем помочь?", true, &formats)
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{ChatID: 1, UserQuery: "привет"})
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if !resp.ShouldReply || resp.Response != "Привет! Чем помочь?" {
+		t.Errorf("Expected plain text reply, got %+v", resp)
+	}
+	if len(formats) != 2 || formats[0] != "json_schema" || formats[1] != "" {
+		t.Errorf("Expected json_schema request followed by plain text, got %v", formats)
+	}
+}
+
+func TestGenerateResponseJSONModeWithoutStructuredOutput(t *testing.T) {
+	var formats []string
+	c := newFormatServerClient(t, `{"should_reply":false,"response":"","reaction":"🔥"}`, true, &formats)
+	c.config.App.OpenAI.StructuredOutput = false
+
+	resp, err := c.GenerateResponse(context.Background(), ContextRequest{ChatID: 1, UserQuery: "огонь"})
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if resp.Reaction != "🔥" {
+		t.Errorf("Expected reaction %q, got %q", "🔥", resp.Reaction)
+	}
+	if len(formats) != 1 || formats[0] != "json_object" {
+		t.Errorf("Expected a single json_object request, got %v", formats)
+	}
+}
+
+func TestBuildPromptsLegacyFieldsFallback(t *testing.T) {
+	legacyEvents, legacyTraits := "legacy meetup", "legacy friendly"
+	withJSON := func() (*models.ChatSummary, *models.UserSummary) {
+		return &models.ChatSummary{NextEvents: &legacyEvents, NextEventsJSON: []models.Event{{Title: "json meetup"}}},
+			&models.UserSummary{Traits: &legacyTraits, TraitsJSON: models.UserTrait{"tone": "json friendly"}}
+	}
+	legacyOnly := func() (*models.ChatSummary, *models.UserSummary) {
+		return &models.ChatSummary{NextEvents: &legacyEvents}, &models.UserSummary{Traits: &legacyTraits}
+	}
+	prompts := func(cfg *config.Config, chat *models.ChatSummary, user *models.UserSummary) string {
+		summarizeSystem, summarizeUser := buildSummarizePrompts(cfg, SummarizeRequest{
+			ExistingChatSummary:   chat,
+			ExistingUserSummaries: map[int64]*models.UserSummary{1: user},
+		})
+		responseSystem, _ := buildResponsePrompts(cfg, ContextRequest{ChatSummary: chat, UserSummary: user})
+		return summarizeSystem + summarizeUser + responseSystem
+	}
+
+	cfg := &config.Config{}
+	chat, user := withJSON()
+	if prompt := prompts(cfg, chat, user); strings.Contains(prompt, "legacy") || !strings.Contains(prompt, "json meetup") || !strings.Contains(prompt, "json friendly") {
+		t.Errorf("Expected only JSON fields when present, got %q", prompt)
+	}
+
+	chat, user = legacyOnly()
+	if prompt := prompts(cfg, chat, user); strings.Count(prompt, "legacy meetup") != 2 || strings.Count(prompt, "legacy friendly") != 2 {
+		t.Errorf("Expected legacy fields as fallback in both prompts, got %q", prompt)
+	}
+
+	cfg.App.Prompts.OmitLegacyFields = true
+	if prompt := prompts(cfg, chat, user); strings.Contains(prompt, "legacy") {
+		t.Errorf("Expected legacy fields omitted, got %q", prompt)
+	}
+}
@@ -0,0 +1,27 @@
+package gpt
+
+import "context"
+
+// LLM providers selectable with [llm] provider
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// Completer generates chat summaries and mention responses with an LLM backend
+type Completer interface {
+	Summarize(ctx context.Context, req SummarizeRequest) (*SummarizeResponse, error)
+	GenerateResponse(ctx context.Context, req ContextRequest) (*MentionResponse, error)
+}
+
+// StreamingCompleter is a Completer that can stream mention responses as they are generated
+type StreamingCompleter interface {
+	Completer
+	GenerateResponseStream(ctx context.Context, req ContextRequest, onPartial func(text string)) (*MentionResponse, error)
+}
+
+var (
+	_ StreamingCompleter = (*Client)(nil)
+	_ Completer          = (*Client)(nil)
+	_ Completer          = (*AnthropicClient)(nil)
+)
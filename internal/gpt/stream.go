@@ -0,0 +1,120 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+// GenerateResponseStream creates a context-aware response like GenerateResponse, streaming the
+// completion. onPartial is called with the response text received so far once the model has
+// decided to reply; the returned response holds the complete result.
+func (c *Client) GenerateResponseStream(ctx context.Context, req ContextRequest, onPartial func(text string)) (*MentionResponse, error) {
+	systemPrompt, userPrompt := buildResponsePrompts(c.config, req)
+
+	c.logger.DebugContext(ctx, "Streaming prompts to OpenAI for response generation",
+		slog.String("user_name", req.UserName),
+		slog.String("model", c.config.App.OpenAI.Model),
+		slog.Int("max_tokens", c.config.App.OpenAI.MaxTokensResponse),
+	)
+
+	c.stats.IncOpenAICalls()
+	stream := c.clientFor(req.ChatID, req.APIKey).Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userPrompt),
+		},
+		Model:          shared.ChatModel(c.config.App.OpenAI.Model),
+		MaxTokens:      openai.Int(int64(c.config.App.OpenAI.MaxTokensResponse)),
+		Temperature:    openai.Float(c.config.App.OpenAI.Temperature),
+		ResponseFormat: c.mentionResponseFormat(),
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	})
+	defer func() { _ = stream.Close() }()
+
+	var acc openai.ChatCompletionAccumulator
+	var content strings.Builder
+	var lastPartial string
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if partial, ok := partialResponseText(content.String()); ok && partial != lastPartial {
+			lastPartial = partial
+			onPartial(partial)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to stream from OpenAI: %w", err)
+	}
+
+	text, err := completionContent(&acc.ChatCompletion)
+	if err != nil {
+		return nil, err
+	}
+
+	var result MentionResponse
+	if err := unmarshalCompletion(text, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response JSON: %w", err)
+	}
+	result.Usage = completionUsage(&acc.ChatCompletion)
+
+	return &result, nil
+}
+
+var (
+	shouldReplyField   = regexp.MustCompile(`"should_reply"\s*:\s*true`)
+	responseFieldStart = regexp.MustCompile(`"response"\s*:\s*"`)
+)
+
+// partialResponseText extracts the "response" string received so far from incomplete completion
+// JSON. It reports false until "should_reply": true has been received, so replies are never
+// shown before the model decides to answer.
+func partialResponseText(content string) (string, bool) {
+	if !shouldReplyField.MatchString(content) {
+		return "", false
+	}
+
+	loc := responseFieldStart.FindStringIndex(content)
+	if loc == nil {
+		return "", false
+	}
+
+	// Take the string body up to its closing quote, if it has arrived
+	raw := content[loc[1]:]
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' {
+			i++
+		} else if raw[i] == '"' {
+			raw = raw[:i]
+			break
+		}
+	}
+
+	// Drop a trailing rune or escape sequence that is not complete yet (at most \uXXXX\uX)
+	for r, size := utf8.DecodeLastRuneInString(raw); r == utf8.RuneError && size == 1; r, size = utf8.DecodeLastRuneInString(raw) {
+		raw = raw[:len(raw)-1]
+	}
+	for trimmed := 0; trimmed < 12 && raw != ""; trimmed++ {
+		var text string
+		if err := json.Unmarshal([]byte(`"`+raw+`"`), &text); err == nil {
+			return text, text != ""
+		}
+		raw = raw[:len(raw)-1]
+	}
+
+	return "", false
+}
@@ -0,0 +1,88 @@
+package gpt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+func TestPartialResponseText(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+		ok      bool
+	}{
+		{`{"should_reply": false, "response": "hi`, "", false},
+		{`{"should_reply": true, "respo`, "", false},
+		{`{"should_reply": true, "response": "`, "", false},
+		{`{"should_reply": true, "response": "Привет, мир`, "Привет, мир", true},
+		{`{"should_reply": true, "response": "line\nnext \"quoted\" é`, "line\nnext \"quoted\" é", true},
+		{`{"should_reply": true, "response": "cut \u00`, "cut ", true},
+		{`{"should_reply": true, "response": "done", "reaction": "👍"}`, "done", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := partialResponseText(tt.content)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("partialResponseText(%q) = %q, %v; want %q, %v", tt.content, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGenerateResponseStream(t *testing.T) {
+	content := `{"should_reply": true, "response": "Hello there", "reaction": "👍"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		runes := []rune(content)
+		for i := 0; i < len(runes); i += 8 {
+			piece := string(runes[i:min(i+8, len(runes))])
+			chunk, _ := json.Marshal(map[string]any{
+				"id": "chatcmpl-test", "object": "chat.completion.chunk", "created": 0, "model": "gpt-4o-mini",
+				"choices": []map[string]any{{"index": 0, "delta": map[string]any{"content": piece}}},
+			})
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		usage, _ := json.Marshal(map[string]any{
+			"id": "chatcmpl-test", "object": "chat.completion.chunk", "created": 0, "model": "gpt-4o-mini",
+			"choices": []map[string]any{},
+			"usage":   map[string]any{"prompt_tokens": 40, "completion_tokens": 12, "total_tokens": 52},
+		})
+		_, _ = fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", usage)
+	}))
+	defer srv.Close()
+
+	c := newTestClient()
+	client := openai.NewClient(option.WithAPIKey("test-key"), option.WithBaseURL(srv.URL), option.WithMaxRetries(0))
+	c.client = &client
+
+	var partials []string
+	resp, err := c.GenerateResponseStream(context.Background(), ContextRequest{UserQuery: "hi"}, func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil {
+		t.Fatalf("GenerateResponseStream returned error: %v", err)
+	}
+
+	if !resp.ShouldReply || resp.Response != "Hello there" || resp.Reaction != "👍" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 40 || resp.Usage.CompletionTokens != 12 {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+	if len(partials) < 2 || partials[len(partials)-1] != "Hello there" {
+		t.Errorf("Expected growing partial texts ending with the full response, got %q", partials)
+	}
+	for i := 1; i < len(partials); i++ {
+		if !strings.HasPrefix(partials[i], partials[i-1]) {
+			t.Errorf("Expected partial %q to extend %q", partials[i], partials[i-1])
+		}
+	}
+}
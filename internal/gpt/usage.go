@@ -0,0 +1,37 @@
+package gpt
+
+import (
+	"strings"
+
+	"github.com/xdefrag/william/internal/config"
+)
+
+// Add returns the usage with the tokens of other added, keeping the latest reported model
+func (u Usage) Add(other Usage) Usage {
+	if other.Model != "" {
+		u.Model = other.Model
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	return u
+}
+
+// EstimateCost returns the cost of the usage from per-1k-token prices. Versioned model names
+// (e.g. "gpt-4o-mini-2024-07-18") use the longest matching price prefix. Reports false if
+// no price is configured for the model.
+func EstimateCost(prices map[string]config.TokenPrice, usage Usage) (float64, bool) {
+	price, ok := prices[usage.Model]
+	if !ok {
+		matched := ""
+		for model, p := range prices {
+			if strings.HasPrefix(usage.Model, model) && len(model) > len(matched) {
+				matched, price, ok = model, p, true
+			}
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+
+	return float64(usage.PromptTokens)/1000*price.Prompt + float64(usage.CompletionTokens)/1000*price.Completion, true
+}
@@ -0,0 +1,44 @@
+package gpt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestEstimateCost(t *testing.T) {
+	prices := map[string]config.TokenPrice{
+		"gpt-4o":      {Prompt: 0.0025, Completion: 0.01},
+		"gpt-4o-mini": {Prompt: 0.00015, Completion: 0.0006},
+	}
+
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"gpt-4o-mini", 0.00015*2 + 0.0006, true},
+		{"gpt-4o-mini-2024-07-18", 0.00015*2 + 0.0006, true},
+		{"gpt-4o-2024-08-06", 0.0025*2 + 0.01, true},
+		{"claude-3-5-haiku", 0, false},
+	}
+
+	for _, tt := range tests {
+		cost, ok := EstimateCost(prices, Usage{Model: tt.model, PromptTokens: 2000, CompletionTokens: 1000})
+		if ok != tt.ok || math.Abs(cost-tt.want) > 1e-12 {
+			t.Errorf("EstimateCost(%s) = %v, %v; want %v, %v", tt.model, cost, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	usage := Usage{}.Add(Usage{Model: "gpt-4o-mini", PromptTokens: 100, CompletionTokens: 50})
+	usage = usage.Add(Usage{Model: "gpt-4o-mini-2024-07-18", PromptTokens: 120, CompletionTokens: 80})
+	usage = usage.Add(Usage{})
+
+	want := Usage{Model: "gpt-4o-mini-2024-07-18", PromptTokens: 220, CompletionTokens: 130}
+	if usage != want {
+		t.Errorf("Add() = %+v, want %+v", usage, want)
+	}
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-chat list of disabled bot commands (empty = all enabled)
+ALTER TABLE chat_settings
+ADD COLUMN disabled_commands TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS disabled_commands;
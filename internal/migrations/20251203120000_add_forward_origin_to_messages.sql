@@ -0,0 +1,7 @@
+-- +goose Up
+-- Store provenance of forwarded messages
+ALTER TABLE messages
+ADD COLUMN forward_origin TEXT;
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN IF EXISTS forward_origin;
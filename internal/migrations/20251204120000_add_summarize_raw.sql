@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE summarize_raw (
+  id          BIGSERIAL PRIMARY KEY,
+  chat_id     BIGINT NOT NULL,
+  topic_id    BIGINT,
+  completion  TEXT NOT NULL,
+  created_at  TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_summarize_raw_chat_topic ON summarize_raw(chat_id, topic_id, created_at DESC);
+CREATE INDEX idx_summarize_raw_created_at ON summarize_raw(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summarize_raw_created_at;
+DROP INDEX IF EXISTS idx_summarize_raw_chat_topic;
+DROP TABLE IF EXISTS summarize_raw;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Message id (and topic) of the summary pinned by /pinsummary, NULL when nothing is pinned
+ALTER TABLE chat_settings
+ADD COLUMN pinned_summary_message_id BIGINT,
+ADD COLUMN pinned_summary_topic_id BIGINT;
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS pinned_summary_topic_id;
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS pinned_summary_message_id;
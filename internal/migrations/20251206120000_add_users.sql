@@ -0,0 +1,22 @@
+-- +goose Up
+-- Current identity of each user per chat, kept up to date from incoming messages.
+-- Keyed by chat to match messages and user_summaries.
+CREATE TABLE users (
+  chat_id    BIGINT NOT NULL,
+  user_id    BIGINT NOT NULL,
+  username   TEXT,
+  first_name TEXT NOT NULL DEFAULT '',
+  last_name  TEXT,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (chat_id, user_id)
+);
+
+-- Seed from the latest message of every user in every chat
+INSERT INTO users (chat_id, user_id, username, first_name, last_name, updated_at)
+SELECT DISTINCT ON (chat_id, user_id) chat_id, user_id, username, user_first_name, user_last_name, created_at
+FROM messages
+WHERE is_bot = false
+ORDER BY chat_id, user_id, created_at DESC;
+
+-- +goose Down
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Language of command responses in the chat (empty = default)
+ALTER TABLE chat_settings
+ADD COLUMN ui_language TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS ui_language;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Store reply relationships so conversations with the bot can be told apart
+ALTER TABLE messages
+ADD COLUMN reply_to_msg_id BIGINT,
+ADD COLUMN reply_to_bot BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to_bot;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to_msg_id;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Track edits of stored messages
+ALTER TABLE messages
+ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE INDEX idx_messages_chat_telegram_msg_id ON messages(chat_id, telegram_msg_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_chat_telegram_msg_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Soft-delete messages so they are left out of summaries, context and stats
+ALTER TABLE messages
+ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Per-chat summarization thresholds (0 = use the global configuration)
+ALTER TABLE chat_settings
+ADD COLUMN max_msg_buffer INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN summarize_max_messages INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS summarize_max_messages;
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS max_msg_buffer;
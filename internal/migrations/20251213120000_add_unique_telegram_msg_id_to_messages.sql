@@ -0,0 +1,18 @@
+-- +goose Up
+-- Remove duplicates stored from redelivered updates, keeping the first copy
+DELETE FROM messages m
+USING messages d
+WHERE m.chat_id = d.chat_id
+  AND m.telegram_msg_id = d.telegram_msg_id
+  AND m.id > d.id;
+
+DROP INDEX IF EXISTS idx_messages_chat_telegram_msg_id;
+
+ALTER TABLE messages
+ADD CONSTRAINT unique_message_telegram_msg_id UNIQUE (chat_id, telegram_msg_id);
+
+-- +goose Down
+ALTER TABLE messages
+DROP CONSTRAINT IF EXISTS unique_message_telegram_msg_id;
+
+CREATE INDEX idx_messages_chat_telegram_msg_id ON messages(chat_id, telegram_msg_id);
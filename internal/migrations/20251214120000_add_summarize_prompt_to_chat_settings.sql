@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-chat override of the summarize system prompt
+ALTER TABLE chat_settings
+ADD COLUMN summarize_prompt TEXT;
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS summarize_prompt;
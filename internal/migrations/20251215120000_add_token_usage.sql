@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE token_usage (
+  id                 BIGSERIAL PRIMARY KEY,
+  chat_id            BIGINT NOT NULL,
+  model              TEXT NOT NULL,
+  prompt_tokens      INTEGER NOT NULL,
+  completion_tokens  INTEGER NOT NULL,
+  created_at         TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_token_usage_chat_created_at ON token_usage(chat_id, created_at);
+CREATE INDEX idx_token_usage_created_at ON token_usage(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_token_usage_created_at;
+DROP INDEX IF EXISTS idx_token_usage_chat_created_at;
+DROP TABLE IF EXISTS token_usage;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE welcome_log (
+  chat_id      BIGINT NOT NULL,
+  user_id      BIGINT NOT NULL,
+  welcomed_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (chat_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS welcome_log;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One index per supported search language (search.language in app.toml)
+CREATE INDEX IF NOT EXISTS idx_messages_text_search_russian
+  ON messages USING GIN (to_tsvector('russian', coalesce(text, '')));
+CREATE INDEX IF NOT EXISTS idx_messages_text_search_english
+  ON messages USING GIN (to_tsvector('english', coalesce(text, '')));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_messages_text_search_english;
+DROP INDEX IF EXISTS idx_messages_text_search_russian;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-chat switch for emoji reactions to mentions
+ALTER TABLE chat_settings
+ADD COLUMN reactions_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE chat_settings DROP COLUMN IF EXISTS reactions_enabled;
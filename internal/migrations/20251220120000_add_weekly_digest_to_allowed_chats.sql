@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-chat opt-in for the weekly digest post
+ALTER TABLE allowed_chats
+ADD COLUMN weekly_digest BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE allowed_chats DROP COLUMN IF EXISTS weekly_digest;
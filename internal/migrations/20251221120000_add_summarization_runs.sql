@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE summarization_runs (
+  id                 BIGSERIAL PRIMARY KEY,
+  chat_id            BIGINT NOT NULL,
+  topic_id           BIGINT,
+  message_count      INT NOT NULL,
+  model              TEXT,
+  prompt_tokens      BIGINT NOT NULL DEFAULT 0,
+  completion_tokens  BIGINT NOT NULL DEFAULT 0,
+  duration_ms        BIGINT NOT NULL,
+  status             TEXT NOT NULL,
+  error              TEXT,
+  created_at         TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_summarization_runs_chat_created_at ON summarization_runs(chat_id, created_at DESC);
+CREATE INDEX idx_summarization_runs_created_at ON summarization_runs(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_summarization_runs_created_at;
+DROP INDEX IF EXISTS idx_summarization_runs_chat_created_at;
+DROP TABLE IF EXISTS summarization_runs;
+-- +goose StatementEnd
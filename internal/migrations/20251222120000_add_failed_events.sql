@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE failed_events (
+  id          BIGSERIAL PRIMARY KEY,
+  topic       TEXT NOT NULL,
+  handler     TEXT NOT NULL,
+  payload     TEXT NOT NULL,
+  error       TEXT NOT NULL,
+  attempts    INT NOT NULL,
+  created_at  TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_failed_events_created_at ON failed_events(created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_failed_events_created_at;
+DROP TABLE IF EXISTS failed_events;
+-- +goose StatementEnd
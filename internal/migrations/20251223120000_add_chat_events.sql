@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE chat_events (
+  id          BIGSERIAL PRIMARY KEY,
+  chat_id     BIGINT NOT NULL,
+  topic_id    BIGINT,
+  title       TEXT NOT NULL,
+  date        TEXT NOT NULL DEFAULT '',
+  ends_at     TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_chat_events_unique ON chat_events(chat_id, COALESCE(topic_id, 0), lower(title), date);
+CREATE INDEX idx_chat_events_ends_at ON chat_events(chat_id, ends_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_chat_events_ends_at;
+DROP INDEX IF EXISTS idx_chat_events_unique;
+DROP TABLE IF EXISTS chat_events;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE handled_commands (
+  chat_id          BIGINT NOT NULL,
+  telegram_msg_id  BIGINT NOT NULL,
+  created_at       TIMESTAMPTZ DEFAULT now(),
+  PRIMARY KEY (chat_id, telegram_msg_id)
+);
+
+CREATE INDEX idx_handled_commands_created_at ON handled_commands(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_handled_commands_created_at;
+DROP TABLE IF EXISTS handled_commands;
+-- +goose StatementEnd
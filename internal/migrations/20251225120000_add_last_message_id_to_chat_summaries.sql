@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- last_message_id is the newest message a chat topic summary covers; later runs only read newer messages
+ALTER TABLE chat_summaries ADD COLUMN last_message_id BIGINT NOT NULL DEFAULT 0;
+
+UPDATE chat_summaries s
+SET last_message_id = COALESCE((
+  SELECT MAX(m.id) FROM messages m
+  WHERE m.chat_id = s.chat_id
+    AND m.topic_id IS NOT DISTINCT FROM s.topic_id
+    AND m.created_at <= s.updated_at
+), 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chat_summaries DROP COLUMN IF EXISTS last_message_id;
+-- +goose StatementEnd
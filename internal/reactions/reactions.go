@@ -0,0 +1,24 @@
+// Package reactions validates emoji used as Telegram message reactions
+package reactions
+
+import "strings"
+
+// allowed lists the emoji Telegram accepts as message reactions
+var allowed = map[string]struct{}{}
+
+func init() {
+	for _, emoji := range strings.Fields("❤ 👍 👎 🔥 🥰 👏 😁 🤔 🤯 😱 🤬 😢 🎉 🤩 🤮 💩 🙏 👌 🕊 🤡 🥱 🥴 😍 🐳 ❤‍🔥 🌚 🌭 💯 🤣 ⚡ 🍌 🏆 " +
+		"💔 🤨 😐 🍓 🍾 💋 🖕 😈 😴 😭 🤓 👻 👨‍💻 👀 🎃 🙈 😇 😨 🤝 ✍ 🤗 🫡 🎅 🎄 ☃ 💅 🤪 🗿 🆒 💘 🙉 🦄 😘 💊 🙊 😎 👾 🤷‍♂ 🤷 🤷‍♀ 😡") {
+		allowed[emoji] = struct{}{}
+	}
+}
+
+// Emoji returns the reaction as Telegram expects it, or empty string if it is
+// empty or not an allowed reaction. Emoji variation selectors are ignored.
+func Emoji(reaction string) string {
+	emoji := strings.ReplaceAll(strings.TrimSpace(reaction), "\uFE0F", "")
+	if _, ok := allowed[emoji]; !ok {
+		return ""
+	}
+	return emoji
+}
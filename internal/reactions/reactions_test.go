@@ -0,0 +1,23 @@
+package reactions
+
+import "testing"
+
+func TestEmoji(t *testing.T) {
+	tests := map[string]string{
+		"👍":      "👍",
+		" 🤔 ":    "🤔",
+		"❤️":     "❤",
+		"❤‍🔥":    "❤‍🔥",
+		"":       "",
+		"🦖":      "",
+		"⏳":      "",
+		"thumbs": "",
+		"👍👍":     "",
+	}
+
+	for reaction, want := range tests {
+		if got := Emoji(reaction); got != want {
+			t.Errorf("Emoji(%q) = %q, want %q", reaction, got, want)
+		}
+	}
+}
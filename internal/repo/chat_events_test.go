@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestChatEventsUpcoming(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(7)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_events WHERE chat_id = $1`, chatID)
+	})
+
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+	at := func(t time.Time) *time.Time { return &t }
+	events := []*models.ChatEvent{
+		{ChatID: chatID, TopicID: &topicID, Title: "Past", Date: "2025-12-09", EndsAt: at(now.Add(-12 * time.Hour))},
+		{ChatID: chatID, TopicID: &topicID, Title: "Release", Date: "2025-12-12", EndsAt: at(now.Add(48 * time.Hour))},
+		{ChatID: chatID, TopicID: &topicID, Title: "Meetup", Date: "2025-12-11T10:00:00Z", EndsAt: at(now.Add(22 * time.Hour))},
+		{ChatID: chatID, TopicID: &topicID, Title: "Someday"},
+		{ChatID: chatID, Title: "General", Date: "2025-12-11", EndsAt: at(now.Add(36 * time.Hour))},
+	}
+
+	// Summarization stores events in its transaction; a later run extracting the same
+	// event again must not duplicate it
+	err := r.WithTx(ctx, func(tx *Tx) error {
+		return tx.SaveChatEvents(ctx, events)
+	})
+	if err != nil {
+		t.Fatalf("WithTx returned error: %v", err)
+	}
+	duplicate := &models.ChatEvent{ChatID: chatID, TopicID: &topicID, Title: "meetup", Date: "2025-12-11T10:00:00Z", EndsAt: at(now.Add(22 * time.Hour))}
+	if err := r.SaveChatEvents(ctx, []*models.ChatEvent{duplicate}); err != nil {
+		t.Fatalf("SaveChatEvents returned error: %v", err)
+	}
+
+	upcoming, err := r.GetUpcomingEvents(ctx, chatID, &topicID, now, 0)
+	if err != nil {
+		t.Fatalf("GetUpcomingEvents returned error: %v", err)
+	}
+	var titles []string
+	for _, event := range upcoming {
+		titles = append(titles, event.Title)
+	}
+	if len(titles) != 3 || titles[0] != "Meetup" || titles[1] != "Release" || titles[2] != "Someday" {
+		t.Fatalf("Expected upcoming topic events soonest first, got %v", titles)
+	}
+
+	general, err := r.GetUpcomingEvents(ctx, chatID, nil, now, 0)
+	if err != nil {
+		t.Fatalf("GetUpcomingEvents returned error: %v", err)
+	}
+	if len(general) != 1 || general[0].Title != "General" || general[0].TopicID != nil {
+		t.Fatalf("Expected only the general topic event, got %+v", general)
+	}
+
+	if err := r.DeleteChatEvent(ctx, chatID, upcoming[0].ID); err != nil {
+		t.Fatalf("DeleteChatEvent returned error: %v", err)
+	}
+	upcoming, err = r.GetUpcomingEvents(ctx, chatID, &topicID, now.Add(30*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("GetUpcomingEvents returned error: %v", err)
+	}
+	if len(upcoming) != 2 || upcoming[0].Title != "Release" {
+		t.Errorf("Expected Release and Someday after deleting Meetup, got %+v", upcoming)
+	}
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestFailedEvents(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	topic := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM failed_events WHERE topic = $1`, topic)
+	})
+
+	for _, payload := range []string{`{"chat_id":1}`, `{"chat_id":2}`} {
+		event := &models.FailedEvent{
+			Topic:    topic,
+			Handler:  "summarize_handler",
+			Payload:  payload,
+			Error:    "failed to summarize chat topic: boom",
+			Attempts: 3,
+		}
+		if err := r.SaveFailedEvent(ctx, event); err != nil {
+			t.Fatalf("SaveFailedEvent returned error: %v", err)
+		}
+	}
+
+	events, err := r.GetFailedEvents(ctx, topic, 10)
+	if err != nil {
+		t.Fatalf("GetFailedEvents returned error: %v", err)
+	}
+	if len(events) != 2 || events[0].Payload != `{"chat_id":2}` || events[1].Payload != `{"chat_id":1}` {
+		t.Fatalf("Expected failed events newest first, got %+v", events)
+	}
+	if events[0].Handler != "summarize_handler" || events[0].Attempts != 3 || events[0].Error == "" {
+		t.Errorf("Unexpected failed event: %+v", events[0])
+	}
+}
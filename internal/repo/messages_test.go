@@ -0,0 +1,544 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestSaveMessageForwardOrigin(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	text := "Interesting article"
+	origin := "Tech News (@technews)"
+	msg := &models.Message{
+		TelegramMsgID: 1,
+		ChatID:        chatID,
+		UserID:        1,
+		UserFirstName: "Ann",
+		Text:          &text,
+		ForwardOrigin: &origin,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := r.SaveMessage(ctx, msg); err != nil {
+		t.Fatalf("SaveMessage returned error: %v", err)
+	}
+
+	messages, err := r.GetLatestMessagesByChatID(ctx, chatID, 10)
+	if err != nil {
+		t.Fatalf("GetLatestMessagesByChatID returned error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ForwardOrigin == nil || *messages[0].ForwardOrigin != origin {
+		t.Errorf("Expected stored forward origin %q, got %+v", origin, messages)
+	}
+}
+
+func TestSaveMessageIgnoresDuplicates(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	for i, wantNew := range []bool{true, false} {
+		text := "hello"
+		msg := &models.Message{
+			TelegramMsgID: 5,
+			ChatID:        chatID,
+			UserID:        1,
+			UserFirstName: "Ann",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		isNew, err := r.SaveMessage(ctx, msg)
+		if err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+		if isNew != wantNew {
+			t.Errorf("Delivery %d: expected new=%v, got %v", i+1, wantNew, isNew)
+		}
+	}
+
+	var count int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM messages WHERE chat_id = $1`, chatID).Scan(&count); err != nil {
+		t.Fatalf("Failed to count messages: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 stored message, got %d", count)
+	}
+}
+
+func TestGetMessagesAfterIDLimit(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(4)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		text := "message"
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        1,
+			TopicID:       &topicID,
+			UserFirstName: "Ann",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	limited, err := r.GetMessagesAfterID(ctx, chatID, 0, 2)
+	if err != nil {
+		t.Fatalf("GetMessagesAfterID returned error: %v", err)
+	}
+	if len(limited) != 2 || limited[0].ID != ids[3] || limited[1].ID != ids[4] {
+		t.Errorf("Expected the 2 latest messages in order, got %+v", limited)
+	}
+
+	inTopic, err := r.GetMessagesAfterIDInTopic(ctx, chatID, &topicID, ids[0], 3)
+	if err != nil {
+		t.Fatalf("GetMessagesAfterIDInTopic returned error: %v", err)
+	}
+	if len(inTopic) != 3 || inTopic[0].ID != ids[2] || inTopic[2].ID != ids[4] {
+		t.Errorf("Expected the 3 latest topic messages in order, got %+v", inTopic)
+	}
+
+	unlimited, err := r.GetMessagesAfterID(ctx, chatID, ids[0], 0)
+	if err != nil {
+		t.Fatalf("GetMessagesAfterID returned error: %v", err)
+	}
+	if len(unlimited) != 4 {
+		t.Errorf("Expected all 4 messages after the first without a limit, got %d", len(unlimited))
+	}
+}
+
+func TestUpdateMessageText(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	text := "Созвон в 18:00"
+	msg := &models.Message{
+		TelegramMsgID: 7,
+		ChatID:        chatID,
+		UserID:        1,
+		UserFirstName: "Ann",
+		Text:          &text,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := r.SaveMessage(ctx, msg); err != nil {
+		t.Fatalf("SaveMessage returned error: %v", err)
+	}
+
+	editedAt := time.Now().Truncate(time.Second)
+	updated, err := r.UpdateMessageText(ctx, chatID, 7, "Созвон в 19:00", editedAt)
+	if err != nil {
+		t.Fatalf("UpdateMessageText returned error: %v", err)
+	}
+	if !updated {
+		t.Fatal("Expected the stored message to be updated")
+	}
+
+	messages, err := r.GetLatestMessagesByChatID(ctx, chatID, 10)
+	if err != nil {
+		t.Fatalf("GetLatestMessagesByChatID returned error: %v", err)
+	}
+	if len(messages) != 1 || *messages[0].Text != "Созвон в 19:00" {
+		t.Fatalf("Expected edited text, got %+v", messages)
+	}
+	if messages[0].EditedAt == nil || !messages[0].EditedAt.Equal(editedAt) {
+		t.Errorf("Expected edited_at %v, got %v", editedAt, messages[0].EditedAt)
+	}
+
+	missing, err := r.UpdateMessageText(ctx, chatID, 8, "never stored", editedAt)
+	if err != nil {
+		t.Fatalf("UpdateMessageText returned error: %v", err)
+	}
+	if missing {
+		t.Error("Expected no update for a message that was never stored")
+	}
+}
+
+func TestMarkMessageDeleted(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	for i, text := range []string{"keep me", "forget me"} {
+		text := text
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        1,
+			UserFirstName: "Ann",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+	}
+
+	if err := r.MarkMessageDeleted(ctx, chatID, 2); err != nil {
+		t.Fatalf("MarkMessageDeleted returned error: %v", err)
+	}
+	if err := r.MarkMessageDeleted(ctx, chatID, 2); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for an already deleted message, got %v", err)
+	}
+
+	latest, err := r.GetLatestMessagesByChatID(ctx, chatID, 10)
+	if err != nil {
+		t.Fatalf("GetLatestMessagesByChatID returned error: %v", err)
+	}
+	if len(latest) != 1 || *latest[0].Text != "keep me" {
+		t.Errorf("Expected the deleted message to be filtered out, got %+v", latest)
+	}
+
+	after, err := r.GetMessagesAfterID(ctx, chatID, 0, 0)
+	if err != nil {
+		t.Fatalf("GetMessagesAfterID returned error: %v", err)
+	}
+	if len(after) != 1 {
+		t.Errorf("Expected 1 message after filtering deletions, got %d", len(after))
+	}
+
+	stats, err := r.GetUserMessageStats(ctx, chatID, 10, false)
+	if err != nil {
+		t.Fatalf("GetUserMessageStats returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].MessageCount != 1 {
+		t.Errorf("Expected the deleted message not to be counted, got %+v", stats)
+	}
+}
+
+func TestGetRecentBotMessages(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(7)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	saved := []struct {
+		text    string
+		isBot   bool
+		topicID *int64
+	}{
+		{"first reply", true, nil},
+		{"question", false, nil},
+		{"second reply", true, nil},
+		{"topic reply", true, &topicID},
+		{"third reply", true, nil},
+	}
+	for i, m := range saved {
+		text := m.text
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        1,
+			TopicID:       m.topicID,
+			IsBot:         m.isBot,
+			UserFirstName: "William",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+	}
+
+	recent, err := r.GetRecentBotMessages(ctx, chatID, nil, 2)
+	if err != nil {
+		t.Fatalf("GetRecentBotMessages returned error: %v", err)
+	}
+	if len(recent) != 2 || *recent[0].Text != "third reply" || *recent[1].Text != "second reply" {
+		t.Errorf("Expected the 2 latest bot replies newest first, got %+v", recent)
+	}
+
+	inTopic, err := r.GetRecentBotMessages(ctx, chatID, &topicID, 10)
+	if err != nil {
+		t.Fatalf("GetRecentBotMessages returned error: %v", err)
+	}
+	if len(inTopic) != 1 || *inTopic[0].Text != "topic reply" {
+		t.Errorf("Expected only the topic reply, got %+v", inTopic)
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	for i, text := range []string{
+		"Созвон перенесли на пятницу",
+		"В пятницу будет созвон по релизу, созвон обязателен",
+		"Релиз откладывается",
+	} {
+		text := text
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        1,
+			UserFirstName: "Ann",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+	}
+
+	results, err := r.SearchMessages(ctx, chatID, "созвоны", "russian", 10)
+	if err != nil {
+		t.Fatalf("SearchMessages returned error: %v", err)
+	}
+	if len(results) != 2 || results[0].Message.TelegramMsgID != 2 || results[0].Rank < results[1].Rank {
+		t.Errorf("Expected both stemmed matches ranked by relevance, got %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, "<b>") {
+		t.Errorf("Expected highlighted snippet, got %q", results[0].Snippet)
+	}
+
+	phrase, err := r.SearchMessages(ctx, chatID, `"перенесли на пятницу"`, "russian", 10)
+	if err != nil {
+		t.Fatalf("SearchMessages returned error: %v", err)
+	}
+	if len(phrase) != 1 || phrase[0].Message.TelegramMsgID != 1 {
+		t.Errorf("Expected only the phrase match, got %+v", phrase)
+	}
+
+	if _, err := r.SearchMessages(ctx, chatID, "релиз", "klingon", 10); err == nil {
+		t.Error("Expected error for unsupported search language")
+	}
+}
+
+func TestGetLatestMessagesByUser(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	ann, bob := "Ann_Dev", "bob"
+	for i, m := range []struct {
+		userID   int64
+		username *string
+	}{{1, &ann}, {2, &bob}, {1, &ann}, {2, &bob}} {
+		text := "message"
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        m.userID,
+			UserFirstName: "User",
+			Username:      m.username,
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+	}
+
+	messages, err := r.GetLatestMessagesByUser(ctx, chatID, 1, 10)
+	if err != nil {
+		t.Fatalf("GetLatestMessagesByUser returned error: %v", err)
+	}
+	if len(messages) != 2 || messages[0].TelegramMsgID != 3 || messages[1].TelegramMsgID != 1 {
+		t.Errorf("Expected the user's messages newest first, got %+v", messages)
+	}
+
+	userID, err := r.FindUserIDByUsername(ctx, chatID, "ann_dev")
+	if err != nil || userID != 1 {
+		t.Errorf("Expected user 1 by case-insensitive username, got %d, %v", userID, err)
+	}
+	if _, err := r.FindUserIDByUsername(ctx, chatID, "carol"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for unknown username, got %v", err)
+	}
+}
+
+func TestDeleteMessagesOlderThan(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	old := time.Now().AddDate(0, 0, -40)
+	unsummarizedTopic := int64(7)
+	saved := make([]*models.Message, 0, 5)
+	for i, m := range []struct {
+		topicID   *int64
+		createdAt time.Time
+	}{{nil, old}, {nil, old}, {nil, time.Now()}, {&unsummarizedTopic, old}, {nil, old}} {
+		text := "message"
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			TopicID:       m.topicID,
+			UserID:        1,
+			UserFirstName: "User",
+			Text:          &text,
+			CreatedAt:     m.createdAt,
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+		saved = append(saved, msg)
+	}
+
+	// The summary read up to the third message; the fifth is old but was never summarized
+	if err := r.SaveChatSummary(ctx, &models.ChatSummary{ChatID: chatID, Summary: "summary", LastMessageID: saved[2].ID}); err != nil {
+		t.Fatalf("SaveChatSummary returned error: %v", err)
+	}
+
+	deleted, err := r.DeleteMessagesOlderThan(ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("DeleteMessagesOlderThan returned error: %v", err)
+	}
+	if deleted < 2 {
+		t.Errorf("Expected at least the 2 old summarized messages to be deleted, got %d", deleted)
+	}
+
+	var remaining []int64
+	rows, err := r.pool.Query(ctx, `SELECT telegram_msg_id FROM messages WHERE chat_id = $1 ORDER BY telegram_msg_id`, chatID)
+	if err != nil {
+		t.Fatalf("Failed to query messages: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("Failed to scan message: %v", err)
+		}
+		remaining = append(remaining, id)
+	}
+	if !reflect.DeepEqual(remaining, []int64{3, 4, 5}) {
+		t.Errorf("Expected recent and unsummarized messages to be kept, got %v", remaining)
+	}
+}
+
+func TestGetUserMessageStatsSince(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	// User 1 wrote the most overall, but only user 2 wrote during the last week
+	weekStart := time.Now().AddDate(0, 0, -7)
+	for i, m := range []struct {
+		userID    int64
+		createdAt time.Time
+	}{
+		{1, weekStart.AddDate(0, 0, -30)},
+		{1, weekStart.AddDate(0, 0, -30)},
+		{1, weekStart.AddDate(0, 0, -1)},
+		{2, time.Now()},
+	} {
+		text := "message"
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        m.userID,
+			UserFirstName: "User",
+			Text:          &text,
+			CreatedAt:     m.createdAt,
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("SaveMessage returned error: %v", err)
+		}
+	}
+
+	stats, err := r.GetUserMessageStatsSince(ctx, chatID, weekStart, 3)
+	if err != nil {
+		t.Fatalf("GetUserMessageStatsSince returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].UserID != 2 || stats[0].MessageCount != 1 {
+		t.Errorf("Expected only the week's messages counted, got %+v", stats)
+	}
+
+	stats, err = r.GetUserMessageStats(ctx, chatID, 3, false)
+	if err != nil {
+		t.Fatalf("GetUserMessageStats returned error: %v", err)
+	}
+	if len(stats) != 2 || stats[0].UserID != 1 || stats[0].MessageCount != 3 {
+		t.Errorf("Expected all-time stats unchanged, got %+v", stats)
+	}
+}
+
+func TestMarkCommandHandled(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM handled_commands WHERE chat_id = $1`, chatID)
+	})
+
+	first, err := r.MarkCommandHandled(ctx, chatID, 42)
+	if err != nil {
+		t.Fatalf("MarkCommandHandled failed: %v", err)
+	}
+	if !first {
+		t.Error("Expected first delivery to be new")
+	}
+
+	again, err := r.MarkCommandHandled(ctx, chatID, 42)
+	if err != nil {
+		t.Fatalf("MarkCommandHandled failed: %v", err)
+	}
+	if again {
+		t.Error("Expected redelivered command to be reported as handled")
+	}
+
+	other, err := r.MarkCommandHandled(ctx, chatID, 43)
+	if err != nil {
+		t.Fatalf("MarkCommandHandled failed: %v", err)
+	}
+	if !other {
+		t.Error("Expected another message to be new")
+	}
+}
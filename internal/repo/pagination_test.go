@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPageLimit(t *testing.T) {
+	tests := map[int]int{0: DefaultPageSize, -1: DefaultPageSize, 10: 10, MaxPageSize + 1: MaxPageSize}
+	for size, want := range tests {
+		if got := pageLimit(size); got != want {
+			t.Errorf("pageLimit(%d) = %d, want %d", size, got, want)
+		}
+	}
+}
+
+func TestGetUserRolesByChatIDPages(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM user_roles WHERE telegram_chat_id = $1`, chatID)
+	})
+
+	for userID := int64(1); userID <= 5; userID++ {
+		if _, err := r.SetUserRole(ctx, userID, chatID, "moderator", nil); err != nil {
+			t.Fatalf("SetUserRole returned error: %v", err)
+		}
+	}
+
+	seen := make(map[int64]bool)
+	var pageToken int64
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected pagination to finish in 3 pages")
+		}
+
+		roles, next, err := r.GetUserRolesByChatID(ctx, chatID, pageToken, 2)
+		if err != nil {
+			t.Fatalf("GetUserRolesByChatID returned error: %v", err)
+		}
+		if len(roles) > 2 {
+			t.Fatalf("Expected at most 2 roles per page, got %d", len(roles))
+		}
+		for _, role := range roles {
+			if seen[role.TelegramUserID] {
+				t.Errorf("User %d returned on more than one page", role.TelegramUserID)
+			}
+			seen[role.TelegramUserID] = true
+		}
+
+		if next == 0 {
+			break
+		}
+		pageToken = next
+	}
+
+	if len(seen) != 5 {
+		t.Errorf("Expected all 5 roles across pages, got %d", len(seen))
+	}
+}
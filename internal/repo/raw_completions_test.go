@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRawCompletions(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(5)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM summarize_raw WHERE chat_id = $1`, chatID)
+	})
+
+	if raw, err := r.GetLastRawCompletion(ctx, chatID, &topicID); err != nil || raw != nil {
+		t.Fatalf("Expected no raw completion, got %+v, %v", raw, err)
+	}
+
+	for _, completion := range []string{`{"run":1}`, `{"run":2}`} {
+		if err := r.SaveRawCompletion(ctx, chatID, &topicID, completion); err != nil {
+			t.Fatalf("SaveRawCompletion returned error: %v", err)
+		}
+	}
+	if err := r.SaveRawCompletion(ctx, chatID, nil, `{"general":true}`); err != nil {
+		t.Fatalf("SaveRawCompletion returned error: %v", err)
+	}
+
+	raw, err := r.GetLastRawCompletion(ctx, chatID, &topicID)
+	if err != nil {
+		t.Fatalf("GetLastRawCompletion returned error: %v", err)
+	}
+	if raw == nil || raw.Completion != `{"run":2}` || raw.TopicID == nil || *raw.TopicID != topicID {
+		t.Errorf("Unexpected last raw completion: %+v", raw)
+	}
+
+	general, err := r.GetLastRawCompletion(ctx, chatID, nil)
+	if err != nil {
+		t.Fatalf("GetLastRawCompletion returned error: %v", err)
+	}
+	if general == nil || general.Completion != `{"general":true}` {
+		t.Errorf("Unexpected general topic raw completion: %+v", general)
+	}
+
+	if _, err := r.DeleteRawCompletionsBefore(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("DeleteRawCompletionsBefore returned error: %v", err)
+	}
+	if raw, _ := r.GetLastRawCompletion(ctx, chatID, &topicID); raw != nil {
+		t.Errorf("Expected raw completions to be deleted, got %+v", raw)
+	}
+}
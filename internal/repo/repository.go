@@ -4,22 +4,75 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xdefrag/william/internal/secrets"
 	"github.com/xdefrag/william/pkg/models"
 )
 
 // Repository provides database operations
 type Repository struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	cipher *secrets.Cipher // Encrypts sensitive chat settings; nil if no key is configured
 }
 
 // New creates a new repository instance
-func New(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+func New(pool *pgxpool.Pool, cipher *secrets.Cipher) *Repository {
+	return &Repository{pool: pool, cipher: cipher}
+}
+
+// queryRower runs single-row queries on the pool or within a transaction
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// execer runs statements on the pool or within a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Tx is a repository transaction passed to WithTx
+type Tx struct {
+	tx pgx.Tx
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling back otherwise
+func (r *Repository) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(&Tx{tx: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SaveChatSummary upserts the chat summary of a topic within the transaction
+func (t *Tx) SaveChatSummary(ctx context.Context, summary *models.ChatSummary) error {
+	return saveChatSummary(ctx, t.tx, summary)
+}
+
+// SaveUserSummary upserts the user summary within the transaction
+func (t *Tx) SaveUserSummary(ctx context.Context, summary *models.UserSummary) error {
+	return saveUserSummary(ctx, t.tx, summary)
+}
+
+// SaveChatEvents stores extracted events within the transaction
+func (t *Tx) SaveChatEvents(ctx context.Context, events []*models.ChatEvent) error {
+	return saveChatEvents(ctx, t.tx, events)
 }
 
 // JSONB handles JSON marshaling/unmarshaling for PostgreSQL JSONB
@@ -45,20 +98,70 @@ func (j *JSONB) Scan(value interface{}) error {
 
 // Messages operations
 
-func (r *Repository) SaveMessage(ctx context.Context, msg *models.Message) error {
+// ErrMessageNotFound is returned when a message to change is not stored
+var ErrMessageNotFound = fmt.Errorf("message not found")
+
+// SaveMessage stores a message and reports whether it is new; a redelivered message
+// (same chat and Telegram message ID) is ignored
+func (r *Repository) SaveMessage(ctx context.Context, msg *models.Message) (bool, error) {
 	query := `
-		INSERT INTO messages (telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO messages (telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (chat_id, telegram_msg_id) DO NOTHING
 		RETURNING id`
 
-	return r.pool.QueryRow(ctx, query, msg.TelegramMsgID, msg.ChatID, msg.UserID, msg.TopicID, msg.IsBot, msg.UserFirstName, msg.UserLastName, msg.Username, msg.Text, msg.CreatedAt).Scan(&msg.ID)
+	err := r.pool.QueryRow(ctx, query, msg.TelegramMsgID, msg.ChatID, msg.UserID, msg.TopicID, msg.IsBot, msg.UserFirstName, msg.UserLastName, msg.Username, msg.Text, msg.ForwardOrigin, msg.ReplyToMsgID, msg.ReplyToBot, msg.EditedAt, msg.CreatedAt).Scan(&msg.ID)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MarkMessageDeleted soft-deletes a stored message so it is left out of summaries, context and stats.
+// Returns ErrMessageNotFound if no such message is stored or it is already deleted.
+func (r *Repository) MarkMessageDeleted(ctx context.Context, chatID, telegramMsgID int64) error {
+	query := `
+		UPDATE messages
+		SET deleted_at = now()
+		WHERE chat_id = $1 AND telegram_msg_id = $2 AND deleted_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, chatID, telegramMsgID)
+	if err != nil {
+		return fmt.Errorf("failed to mark message deleted: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrMessageNotFound
+	}
+
+	return nil
+}
+
+// UpdateMessageText replaces the text of a stored message after an edit and reports
+// whether the message was found
+func (r *Repository) UpdateMessageText(ctx context.Context, chatID, telegramMsgID int64, text string, editedAt time.Time) (bool, error) {
+	query := `
+		UPDATE messages
+		SET text = $3, edited_at = $4
+		WHERE chat_id = $1 AND telegram_msg_id = $2`
+
+	result, err := r.pool.Exec(ctx, query, chatID, telegramMsgID, text, editedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update message text: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
 }
 
 func (r *Repository) GetLatestMessagesByChatID(ctx context.Context, chatID int64, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, created_at
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
 		FROM messages
-		WHERE chat_id = $1
+		WHERE chat_id = $1 AND deleted_at IS NULL
 		ORDER BY id DESC
 		LIMIT $2`
 
@@ -71,7 +174,7 @@ func (r *Repository) GetLatestMessagesByChatID(ctx context.Context, chatID int64
 	var messages []*models.Message
 	for rows.Next() {
 		msg := &models.Message{}
-		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.CreatedAt)
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -81,14 +184,21 @@ func (r *Repository) GetLatestMessagesByChatID(ctx context.Context, chatID int64
 	return messages, rows.Err()
 }
 
-func (r *Repository) GetMessagesAfterID(ctx context.Context, chatID, afterID int64) ([]*models.Message, error) {
+// GetMessagesAfterID returns the latest messages after specific ID, at most limit (<= 0 = no limit),
+// in chronological order
+func (r *Repository) GetMessagesAfterID(ctx context.Context, chatID, afterID int64, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, created_at
-		FROM messages
-		WHERE chat_id = $1 AND id > $2
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+		FROM (
+			SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+			FROM messages
+			WHERE chat_id = $1 AND id > $2 AND deleted_at IS NULL
+			ORDER BY id DESC
+			LIMIT NULLIF($3, 0)
+		) recent
 		ORDER BY id ASC`
 
-	rows, err := r.pool.Query(ctx, query, chatID, afterID)
+	rows, err := r.pool.Query(ctx, query, chatID, afterID, max(limit, 0))
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +207,7 @@ func (r *Repository) GetMessagesAfterID(ctx context.Context, chatID, afterID int
 	var messages []*models.Message
 	for rows.Next() {
 		msg := &models.Message{}
-		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.CreatedAt)
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -107,15 +217,108 @@ func (r *Repository) GetMessagesAfterID(ctx context.Context, chatID, afterID int
 	return messages, rows.Err()
 }
 
-// GetMessagesAfterIDInTopic returns messages after specific ID within a specific topic
-func (r *Repository) GetMessagesAfterIDInTopic(ctx context.Context, chatID int64, topicID *int64, afterID int64) ([]*models.Message, error) {
+// GetMessagesAfterIDInTopic returns the latest messages after specific ID within a specific topic,
+// at most limit (<= 0 = no limit), in chronological order
+func (r *Repository) GetMessagesAfterIDInTopic(ctx context.Context, chatID int64, topicID *int64, afterID int64, limit int) ([]*models.Message, error) {
 	query := `
-		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, created_at
-		FROM messages
-		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2) AND id > $3
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+		FROM (
+			SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+			FROM messages
+			WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2) AND id > $3 AND deleted_at IS NULL
+			ORDER BY id DESC
+			LIMIT NULLIF($4, 0)
+		) recent
 		ORDER BY id ASC`
 
-	rows, err := r.pool.Query(ctx, query, chatID, topicID, afterID)
+	rows, err := r.pool.Query(ctx, query, chatID, topicID, afterID, max(limit, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		msg := &models.Message{}
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetUnsummarizedMessages returns the oldest messages of a topic after the newest summarized one,
+// at most limit, in chronological order. Reading oldest first lets later runs catch up on a backlog
+// without skipping messages.
+func (r *Repository) GetUnsummarizedMessages(ctx context.Context, chatID int64, topicID *int64, afterID int64, limit int) ([]*models.Message, error) {
+	query := `
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+		FROM messages
+		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2) AND id > $3 AND deleted_at IS NULL
+		ORDER BY id ASC
+		LIMIT $4`
+
+	rows, err := r.pool.Query(ctx, query, chatID, topicID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		msg := &models.Message{}
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetLatestMessagesByUser returns a user's latest messages in a chat, newest first
+func (r *Repository) GetLatestMessagesByUser(ctx context.Context, chatID, userID int64, limit int) ([]*models.Message, error) {
+	query := `
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+		FROM messages
+		WHERE chat_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		ORDER BY id DESC
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, chatID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		msg := &models.Message{}
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetRecentBotMessages returns the bot's latest messages in a chat topic (nil = general chat),
+// newest first
+func (r *Repository) GetRecentBotMessages(ctx context.Context, chatID int64, topicID *int64, limit int) ([]*models.Message, error) {
+	query := `
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at
+		FROM messages
+		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2) AND is_bot = true AND deleted_at IS NULL
+		ORDER BY id DESC
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, chatID, topicID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +327,7 @@ func (r *Repository) GetMessagesAfterIDInTopic(ctx context.Context, chatID int64
 	var messages []*models.Message
 	for rows.Next() {
 		msg := &models.Message{}
-		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.CreatedAt)
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -134,17 +337,78 @@ func (r *Repository) GetMessagesAfterIDInTopic(ctx context.Context, chatID int64
 	return messages, rows.Err()
 }
 
+// MessageSearchResult is a message matching a full-text search with its rank and highlighted snippet
+type MessageSearchResult struct {
+	Message *models.Message
+	Rank    float64
+	Snippet string
+}
+
+// searchLanguages are the text search configurations the messages table has an index for
+var searchLanguages = map[string]bool{"russian": true, "english": true}
+
+// SearchMessages returns the chat's messages matching a full-text query, best match first.
+// The query uses web search syntax: quoted phrases, "or" and -excluded words are supported.
+// The language must be one of the indexed text search configurations (russian or english).
+func (r *Repository) SearchMessages(ctx context.Context, chatID int64, query, language string, limit int) ([]*MessageSearchResult, error) {
+	if !searchLanguages[language] {
+		return nil, fmt.Errorf("unsupported search language %q", language)
+	}
+
+	// The language is inlined so the planner can match the per-language GIN index
+	vector := fmt.Sprintf("to_tsvector('%s', coalesce(text, ''))", language)
+	sqlQuery := `
+		SELECT id, telegram_msg_id, chat_id, user_id, topic_id, is_bot, user_first_name, user_last_name, username, text, forward_origin, reply_to_msg_id, reply_to_bot, edited_at, created_at,
+			ts_rank(` + vector + `, q) AS rank,
+			ts_headline('` + language + `', coalesce(text, ''), q, 'MaxWords=20, MinWords=5') AS snippet
+		FROM messages, websearch_to_tsquery('` + language + `', $2) q
+		WHERE chat_id = $1 AND deleted_at IS NULL AND ` + vector + ` @@ q
+		ORDER BY rank DESC, id DESC
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, sqlQuery, chatID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*MessageSearchResult
+	for rows.Next() {
+		msg := &models.Message{}
+		result := &MessageSearchResult{Message: msg}
+		err := rows.Scan(&msg.ID, &msg.TelegramMsgID, &msg.ChatID, &msg.UserID, &msg.TopicID, &msg.IsBot, &msg.UserFirstName, &msg.UserLastName, &msg.Username, &msg.Text, &msg.ForwardOrigin, &msg.ReplyToMsgID, &msg.ReplyToBot, &msg.EditedAt, &msg.CreatedAt,
+			&result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message search result: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message search results: %w", err)
+	}
+
+	return results, nil
+}
+
 // Chat summaries operations
 
 func (r *Repository) SaveChatSummary(ctx context.Context, summary *models.ChatSummary) error {
+	return saveChatSummary(ctx, r.pool, summary)
+}
+
+// saveChatSummary upserts the chat summary of a topic using q
+func saveChatSummary(ctx context.Context, q queryRower, summary *models.ChatSummary) error {
 	query := `
-		INSERT INTO chat_summaries (chat_id, topic_id, summary, topics_json, next_events, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO chat_summaries (chat_id, topic_id, summary, topics_json, next_events, next_events_json, last_message_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (chat_id, topic_id)
 		DO UPDATE SET
 			summary = EXCLUDED.summary,
 			topics_json = EXCLUDED.topics_json,
 			next_events = EXCLUDED.next_events,
+			next_events_json = EXCLUDED.next_events_json,
+			last_message_id = GREATEST(chat_summaries.last_message_id, EXCLUDED.last_message_id),
 			updated_at = EXCLUDED.updated_at
 		RETURNING id`
 
@@ -153,18 +417,27 @@ func (r *Repository) SaveChatSummary(ctx context.Context, summary *models.ChatSu
 		return fmt.Errorf("failed to marshal topics JSON: %w", err)
 	}
 
+	events := summary.NextEventsJSON
+	if events == nil {
+		events = []models.Event{}
+	}
+	nextEventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal next events JSON: %w", err)
+	}
+
 	now := time.Now()
 	summary.UpdatedAt = now
 	if summary.CreatedAt.IsZero() {
 		summary.CreatedAt = now
 	}
 
-	return r.pool.QueryRow(ctx, query, summary.ChatID, summary.TopicID, summary.Summary, topicsJSON, summary.NextEvents, summary.CreatedAt, summary.UpdatedAt).Scan(&summary.ID)
+	return q.QueryRow(ctx, query, summary.ChatID, summary.TopicID, summary.Summary, topicsJSON, summary.NextEvents, nextEventsJSON, summary.LastMessageID, summary.CreatedAt, summary.UpdatedAt).Scan(&summary.ID)
 }
 
 func (r *Repository) GetLatestChatSummary(ctx context.Context, chatID int64) (*models.ChatSummary, error) {
 	query := `
-		SELECT id, chat_id, topic_id, summary, topics_json, next_events, created_at, updated_at
+		SELECT id, chat_id, topic_id, summary, topics_json, next_events, next_events_json, last_message_id, created_at, updated_at
 		FROM chat_summaries
 		WHERE chat_id = $1 AND topic_id IS NULL
 		ORDER BY updated_at DESC
@@ -173,9 +446,9 @@ func (r *Repository) GetLatestChatSummary(ctx context.Context, chatID int64) (*m
 	row := r.pool.QueryRow(ctx, query, chatID)
 
 	summary := &models.ChatSummary{}
-	var topicsJSON []byte
+	var topicsJSON, nextEventsJSON []byte
 
-	err := row.Scan(&summary.ID, &summary.ChatID, &summary.TopicID, &summary.Summary, &topicsJSON, &summary.NextEvents, &summary.CreatedAt, &summary.UpdatedAt)
+	err := row.Scan(&summary.ID, &summary.ChatID, &summary.TopicID, &summary.Summary, &topicsJSON, &summary.NextEvents, &nextEventsJSON, &summary.LastMessageID, &summary.CreatedAt, &summary.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -187,13 +460,128 @@ func (r *Repository) GetLatestChatSummary(ctx context.Context, chatID int64) (*m
 		return nil, fmt.Errorf("failed to unmarshal topics JSON: %w", err)
 	}
 
+	summary.NextEventsJSON, err = unmarshalNextEvents(nextEventsJSON, summary.NextEvents)
+	if err != nil {
+		return nil, err
+	}
+
 	return summary, nil
 }
 
+// FindChatSummaries returns the chat's summaries whose text or topic names contain the keyword
+// (case-insensitive), most recently updated first
+func (r *Repository) FindChatSummaries(ctx context.Context, chatID int64, keyword string, limit int) ([]*models.ChatSummary, error) {
+	query := `
+		SELECT id, chat_id, topic_id, summary, topics_json, created_at, updated_at
+		FROM chat_summaries
+		WHERE chat_id = $1
+			AND (summary ILIKE $2 OR EXISTS (
+				SELECT 1 FROM jsonb_object_keys(topics_json) AS topic WHERE topic ILIKE $2
+			))
+		ORDER BY updated_at DESC
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, chatID, "%"+escapeLikePattern(keyword)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find chat summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*models.ChatSummary
+	for rows.Next() {
+		summary := &models.ChatSummary{}
+		var topicsJSON []byte
+
+		err := rows.Scan(&summary.ID, &summary.ChatID, &summary.TopicID, &summary.Summary, &topicsJSON, &summary.CreatedAt, &summary.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chat summary: %w", err)
+		}
+
+		if err := json.Unmarshal(topicsJSON, &summary.TopicsJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal topics JSON: %w", err)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chat summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// escapeLikePattern escapes LIKE wildcards so the value matches literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// ErrChatSummaryNotFound is returned when a chat summary to update does not exist
+var ErrChatSummaryNotFound = fmt.Errorf("chat summary not found")
+
+// UpdateChatSummaryEvents applies fn to the next events of the latest chat summary for a topic
+// in a single transaction and returns the updated events
+func (r *Repository) UpdateChatSummaryEvents(ctx context.Context, chatID int64, topicID *int64, fn func([]models.Event) ([]models.Event, error)) ([]models.Event, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := `
+		SELECT id, next_events, next_events_json
+		FROM chat_summaries
+		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2)
+		ORDER BY updated_at DESC
+		LIMIT 1
+		FOR UPDATE`
+
+	var id int64
+	var legacy *string
+	var raw []byte
+	err = tx.QueryRow(ctx, query, chatID, topicID).Scan(&id, &legacy, &raw)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrChatSummaryNotFound
+		}
+		return nil, fmt.Errorf("failed to get chat summary events: %w", err)
+	}
+
+	events, err := unmarshalNextEvents(raw, legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err = fn(events)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []models.Event{}
+	}
+
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal next events JSON: %w", err)
+	}
+
+	// updated_at is left as is: it tracks summarization runs used for staleness and decay
+	_, err = tx.Exec(ctx, `UPDATE chat_summaries SET next_events_json = $2 WHERE id = $1`, id, eventsJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update chat summary events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit chat summary events: %w", err)
+	}
+
+	return events, nil
+}
+
 // GetLatestChatSummaryByTopic returns the latest chat summary for a specific topic
 func (r *Repository) GetLatestChatSummaryByTopic(ctx context.Context, chatID int64, topicID *int64) (*models.ChatSummary, error) {
 	query := `
-		SELECT id, chat_id, topic_id, summary, topics_json, next_events, created_at, updated_at
+		SELECT id, chat_id, topic_id, summary, topics_json, next_events, next_events_json, last_message_id, created_at, updated_at
 		FROM chat_summaries
 		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2)
 		ORDER BY updated_at DESC
@@ -202,9 +590,9 @@ func (r *Repository) GetLatestChatSummaryByTopic(ctx context.Context, chatID int
 	row := r.pool.QueryRow(ctx, query, chatID, topicID)
 
 	summary := &models.ChatSummary{}
-	var topicsJSON []byte
+	var topicsJSON, nextEventsJSON []byte
 
-	err := row.Scan(&summary.ID, &summary.ChatID, &summary.TopicID, &summary.Summary, &topicsJSON, &summary.NextEvents, &summary.CreatedAt, &summary.UpdatedAt)
+	err := row.Scan(&summary.ID, &summary.ChatID, &summary.TopicID, &summary.Summary, &topicsJSON, &summary.NextEvents, &nextEventsJSON, &summary.LastMessageID, &summary.CreatedAt, &summary.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -216,16 +604,29 @@ func (r *Repository) GetLatestChatSummaryByTopic(ctx context.Context, chatID int
 		return nil, fmt.Errorf("failed to unmarshal topics JSON: %w", err)
 	}
 
+	summary.NextEventsJSON, err = unmarshalNextEvents(nextEventsJSON, summary.NextEvents)
+	if err != nil {
+		return nil, err
+	}
+
 	return summary, nil
 }
 
+// userSummaryIdentityColumns selects summary identity from the users table,
+// falling back to the identity stored with the summary for users not tracked there
+const userSummaryIdentityColumns = `CASE WHEN u.user_id IS NULL THEN s.username ELSE u.username END AS username,
+			CASE WHEN u.user_id IS NULL THEN s.first_name ELSE u.first_name END AS first_name,
+			CASE WHEN u.user_id IS NULL THEN s.last_name ELSE u.last_name END AS last_name`
+
 // GetAllUserSummariesByChatID returns all user summaries for a specific chat
 func (r *Repository) GetAllUserSummariesByChatID(ctx context.Context, chatID int64) ([]*models.UserSummary, error) {
-	query := `
-		SELECT id, chat_id, user_id, username, first_name, last_name, likes_json, dislikes_json, competencies_json, traits, created_at, updated_at
-		FROM user_summaries 
-		WHERE chat_id = $1 
-		ORDER BY updated_at DESC`
+	query := fmt.Sprintf(`
+		SELECT s.id, s.chat_id, s.user_id, %s,
+			s.likes_json, s.dislikes_json, s.competencies_json, s.traits, s.traits_json, s.created_at, s.updated_at
+		FROM user_summaries s
+		LEFT JOIN users u ON u.chat_id = s.chat_id AND u.user_id = s.user_id
+		WHERE s.chat_id = $1
+		ORDER BY s.updated_at DESC`, userSummaryIdentityColumns)
 
 	rows, err := r.pool.Query(ctx, query, chatID)
 	if err != nil {
@@ -236,9 +637,9 @@ func (r *Repository) GetAllUserSummariesByChatID(ctx context.Context, chatID int
 	var summaries []*models.UserSummary
 	for rows.Next() {
 		summary := &models.UserSummary{}
-		var likesJSON, dislikesJSON, competenciesJSON []byte
+		var likesJSON, dislikesJSON, competenciesJSON, traitsJSON []byte
 
-		err := rows.Scan(&summary.ID, &summary.ChatID, &summary.UserID, &summary.Username, &summary.FirstName, &summary.LastName, &likesJSON, &dislikesJSON, &competenciesJSON, &summary.Traits, &summary.CreatedAt, &summary.UpdatedAt)
+		err := rows.Scan(&summary.ID, &summary.ChatID, &summary.UserID, &summary.Username, &summary.FirstName, &summary.LastName, &likesJSON, &dislikesJSON, &competenciesJSON, &summary.Traits, &traitsJSON, &summary.CreatedAt, &summary.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user summary: %w", err)
 		}
@@ -255,6 +656,11 @@ func (r *Repository) GetAllUserSummariesByChatID(ctx context.Context, chatID int
 			return nil, fmt.Errorf("failed to unmarshal competencies JSON: %w", err)
 		}
 
+		summary.TraitsJSON, err = unmarshalTraits(traitsJSON, summary.Traits)
+		if err != nil {
+			return nil, err
+		}
+
 		summaries = append(summaries, summary)
 	}
 
@@ -265,12 +671,51 @@ func (r *Repository) GetAllUserSummariesByChatID(ctx context.Context, chatID int
 	return summaries, nil
 }
 
+// unmarshalNextEvents decodes structured events, falling back to the legacy text field
+// when no structured events are stored
+func unmarshalNextEvents(raw []byte, legacy *string) ([]models.Event, error) {
+	var events []models.Event
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &events); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal next events JSON: %w", err)
+		}
+	}
+
+	if len(events) == 0 && legacy != nil && *legacy != "" {
+		events = []models.Event{{Title: *legacy}}
+	}
+
+	return events, nil
+}
+
+// unmarshalTraits decodes structured traits, falling back to the legacy text field
+// when no structured traits are stored
+func unmarshalTraits(raw []byte, legacy *string) (models.UserTrait, error) {
+	var traits models.UserTrait
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &traits); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal traits JSON: %w", err)
+		}
+	}
+
+	if len(traits) == 0 && legacy != nil && *legacy != "" {
+		traits = models.UserTrait{"description": *legacy}
+	}
+
+	return traits, nil
+}
+
 // User summaries operations
 
 func (r *Repository) SaveUserSummary(ctx context.Context, summary *models.UserSummary) error {
+	return saveUserSummary(ctx, r.pool, summary)
+}
+
+// saveUserSummary upserts the user summary using q
+func saveUserSummary(ctx context.Context, q queryRower, summary *models.UserSummary) error {
 	query := `
-		INSERT INTO user_summaries (chat_id, user_id, username, first_name, last_name, likes_json, dislikes_json, competencies_json, traits, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO user_summaries (chat_id, user_id, username, first_name, last_name, likes_json, dislikes_json, competencies_json, traits, traits_json, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (chat_id, user_id) 
 		DO UPDATE SET 
 			username = EXCLUDED.username,
@@ -280,6 +725,7 @@ func (r *Repository) SaveUserSummary(ctx context.Context, summary *models.UserSu
 			dislikes_json = EXCLUDED.dislikes_json,
 			competencies_json = EXCLUDED.competencies_json,
 			traits = EXCLUDED.traits,
+			traits_json = EXCLUDED.traits_json,
 			updated_at = EXCLUDED.updated_at
 		RETURNING id`
 
@@ -298,29 +744,56 @@ func (r *Repository) SaveUserSummary(ctx context.Context, summary *models.UserSu
 		return fmt.Errorf("failed to marshal competencies JSON: %w", err)
 	}
 
+	traits := summary.TraitsJSON
+	if traits == nil {
+		traits = models.UserTrait{}
+	}
+	traitsJSON, err := json.Marshal(traits)
+	if err != nil {
+		return fmt.Errorf("failed to marshal traits JSON: %w", err)
+	}
+
 	now := time.Now()
 	summary.UpdatedAt = now
 	if summary.CreatedAt.IsZero() {
 		summary.CreatedAt = now
 	}
 
-	return r.pool.QueryRow(ctx, query, summary.ChatID, summary.UserID, summary.Username, summary.FirstName, summary.LastName, likesJSON, dislikesJSON, competenciesJSON, summary.Traits, summary.CreatedAt, summary.UpdatedAt).Scan(&summary.ID)
+	return q.QueryRow(ctx, query, summary.ChatID, summary.UserID, summary.Username, summary.FirstName, summary.LastName, likesJSON, dislikesJSON, competenciesJSON, summary.Traits, traitsJSON, summary.CreatedAt, summary.UpdatedAt).Scan(&summary.ID)
 }
 
-func (r *Repository) GetLatestUserSummary(ctx context.Context, chatID, userID int64) (*models.UserSummary, error) {
+// SeedUserSummary creates an empty user summary holding only identity fields.
+// Existing summaries are left untouched. Returns true if a new row was created.
+func (r *Repository) SeedUserSummary(ctx context.Context, chatID, userID int64, username *string, firstName string, lastName *string) (bool, error) {
 	query := `
-		SELECT id, chat_id, user_id, username, first_name, last_name, likes_json, dislikes_json, competencies_json, traits, created_at, updated_at
-		FROM user_summaries 
-		WHERE chat_id = $1 AND user_id = $2 
-		ORDER BY updated_at DESC 
-		LIMIT 1`
+		INSERT INTO user_summaries (chat_id, user_id, username, first_name, last_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (chat_id, user_id) DO NOTHING`
+
+	result, err := r.pool.Exec(ctx, query, chatID, userID, username, firstName, lastName, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to seed user summary: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *Repository) GetLatestUserSummary(ctx context.Context, chatID, userID int64) (*models.UserSummary, error) {
+	query := fmt.Sprintf(`
+		SELECT s.id, s.chat_id, s.user_id, %s,
+			s.likes_json, s.dislikes_json, s.competencies_json, s.traits, s.traits_json, s.created_at, s.updated_at
+		FROM user_summaries s
+		LEFT JOIN users u ON u.chat_id = s.chat_id AND u.user_id = s.user_id
+		WHERE s.chat_id = $1 AND s.user_id = $2
+		ORDER BY s.updated_at DESC
+		LIMIT 1`, userSummaryIdentityColumns)
 
 	row := r.pool.QueryRow(ctx, query, chatID, userID)
 
 	summary := &models.UserSummary{}
-	var likesJSON, dislikesJSON, competenciesJSON []byte
+	var likesJSON, dislikesJSON, competenciesJSON, traitsJSON []byte
 
-	err := row.Scan(&summary.ID, &summary.ChatID, &summary.UserID, &summary.Username, &summary.FirstName, &summary.LastName, &likesJSON, &dislikesJSON, &competenciesJSON, &summary.Traits, &summary.CreatedAt, &summary.UpdatedAt)
+	err := row.Scan(&summary.ID, &summary.ChatID, &summary.UserID, &summary.Username, &summary.FirstName, &summary.LastName, &likesJSON, &dislikesJSON, &competenciesJSON, &summary.Traits, &traitsJSON, &summary.CreatedAt, &summary.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -340,25 +813,87 @@ func (r *Repository) GetLatestUserSummary(ctx context.Context, chatID, userID in
 		return nil, fmt.Errorf("failed to unmarshal competencies JSON: %w", err)
 	}
 
+	summary.TraitsJSON, err = unmarshalTraits(traitsJSON, summary.Traits)
+	if err != nil {
+		return nil, err
+	}
+
 	return summary, nil
 }
 
-// IsChatTopicEnabled checks if chat has topic support enabled
-func (r *Repository) IsChatTopicEnabled(ctx context.Context, chatID int64) (bool, error) {
-	query := `
-		SELECT EXISTS(
-			SELECT 1 FROM messages
-			WHERE chat_id = $1 AND topic_id > 0
-			LIMIT 1
-		)`
+// UserExpertise represents a user's strongest competency matching a topic
+type UserExpertise struct {
+	UserID     int64
+	Username   *string
+	FirstName  string
+	LastName   *string
+	Competency string
+	Score      int
+}
 
-	var hasTopics bool
-	err := r.pool.QueryRow(ctx, query, chatID).Scan(&hasTopics)
+// FindTopicExperts returns users of a chat whose competencies contain the topic
+// (case-insensitive), ranked by their best matching competency score
+func (r *Repository) FindTopicExperts(ctx context.Context, chatID int64, topic string, limit int) ([]*UserExpertise, error) {
+	query := fmt.Sprintf(`
+		SELECT user_id, username, first_name, last_name, competency, score
+		FROM (
+			SELECT s.user_id, %s, s.updated_at,
+				c.key AS competency,
+				ROUND((c.value)::numeric)::int AS score,
+				ROW_NUMBER() OVER (PARTITION BY s.user_id ORDER BY (c.value)::numeric DESC, c.key) AS rank
+			FROM user_summaries s
+			LEFT JOIN users u ON u.chat_id = s.chat_id AND u.user_id = s.user_id
+			CROSS JOIN LATERAL jsonb_each(s.competencies_json) c
+			WHERE s.chat_id = $1
+				AND jsonb_typeof(c.value) = 'number'
+				AND c.key ILIKE $2
+		) ranked
+		WHERE rank = 1
+		ORDER BY score DESC, updated_at DESC
+		LIMIT $3`, userSummaryIdentityColumns)
+
+	rows, err := r.pool.Query(ctx, query, chatID, "%"+escapeLikePattern(topic)+"%", limit)
 	if err != nil {
-		return false, fmt.Errorf("failed to check topic support: %w", err)
+		return nil, fmt.Errorf("failed to query topic experts: %w", err)
 	}
+	defer rows.Close()
 
-	return hasTopics, nil
+	var experts []*UserExpertise
+	for rows.Next() {
+		e := &UserExpertise{}
+		var firstName *string
+		if err := rows.Scan(&e.UserID, &e.Username, &firstName, &e.LastName, &e.Competency, &e.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan topic expert: %w", err)
+		}
+		if firstName != nil {
+			e.FirstName = *firstName
+		}
+		experts = append(experts, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating topic experts: %w", err)
+	}
+
+	return experts, nil
+}
+
+// IsChatTopicEnabled checks if chat has topic support enabled
+func (r *Repository) IsChatTopicEnabled(ctx context.Context, chatID int64) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM messages
+			WHERE chat_id = $1 AND topic_id > 0
+			LIMIT 1
+		)`
+
+	var hasTopics bool
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&hasTopics)
+	if err != nil {
+		return false, fmt.Errorf("failed to check topic support: %w", err)
+	}
+
+	return hasTopics, nil
 }
 
 // GetActiveChatIDs returns list of chat IDs that have recent messages
@@ -386,6 +921,38 @@ func (r *Repository) GetActiveChatIDs(ctx context.Context, since time.Time) ([]i
 	return chatIDs, rows.Err()
 }
 
+// ChatVolume represents the number of messages a chat received in a period
+type ChatVolume struct {
+	ChatID       int64
+	MessageCount int
+}
+
+// GetActiveChatVolumes returns chats that have messages since the given time with their message counts
+func (r *Repository) GetActiveChatVolumes(ctx context.Context, since time.Time) ([]*ChatVolume, error) {
+	query := `
+		SELECT chat_id, COUNT(*)
+		FROM messages
+		WHERE created_at >= $1 AND deleted_at IS NULL
+		GROUP BY chat_id`
+
+	rows, err := r.pool.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active chat volumes: %w", err)
+	}
+	defer rows.Close()
+
+	var volumes []*ChatVolume
+	for rows.Next() {
+		var volume ChatVolume
+		if err := rows.Scan(&volume.ChatID, &volume.MessageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan chat volume: %w", err)
+		}
+		volumes = append(volumes, &volume)
+	}
+
+	return volumes, rows.Err()
+}
+
 // Allowed chats operations
 
 // IsAllowedChat checks if the given chat ID is in the allowed chats list
@@ -423,17 +990,73 @@ func (r *Repository) GetAllowedChats(ctx context.Context) ([]int64, error) {
 	return chatIDs, rows.Err()
 }
 
-// GetAllowedChatsDetailed returns all allowed chats with full information
-func (r *Repository) GetAllowedChatsDetailed(ctx context.Context) ([]*models.AllowedChat, error) {
+// GetWeeklyDigestChats returns the IDs of allowed chats opted in to the weekly digest
+func (r *Repository) GetWeeklyDigestChats(ctx context.Context) ([]int64, error) {
+	query := `SELECT chat_id FROM allowed_chats WHERE weekly_digest ORDER BY created_at`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly digest chats: %w", err)
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, fmt.Errorf("failed to scan chat ID: %w", err)
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+
+	return chatIDs, rows.Err()
+}
+
+// SetAllowedChatWeeklyDigest opts an allowed chat in to or out of the weekly digest
+func (r *Repository) SetAllowedChatWeeklyDigest(ctx context.Context, chatID int64, enabled bool) error {
+	query := `UPDATE allowed_chats SET weekly_digest = $2 WHERE chat_id = $1`
+
+	result, err := r.pool.Exec(ctx, query, chatID, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to set weekly digest: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("allowed chat not found")
+	}
+
+	return nil
+}
+
+// DefaultPageSize and MaxPageSize bound the pages returned by paginated listings
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// pageLimit returns the page size to query: the default for non-positive sizes, capped at MaxPageSize
+func pageLimit(pageSize int) int {
+	if pageSize <= 0 {
+		return DefaultPageSize
+	}
+	return min(pageSize, MaxPageSize)
+}
+
+// GetAllowedChatsDetailed returns a page of allowed chats with full information, newest first.
+// pageToken is the token returned for the previous page (0 = first page); the returned
+// token is 0 on the last page.
+func (r *Repository) GetAllowedChatsDetailed(ctx context.Context, pageToken int64, pageSize int) ([]*models.AllowedChat, int64, error) {
+	limit := pageLimit(pageSize)
 	query := `
-		SELECT id, chat_id, name, created_at
-		FROM allowed_chats 
-		ORDER BY created_at DESC
+		SELECT id, chat_id, name, weekly_digest, created_at
+		FROM allowed_chats
+		WHERE $1 = 0 OR id < $1
+		ORDER BY id DESC
+		LIMIT $2
 	`
 
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.pool.Query(ctx, query, pageToken, limit+1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query allowed chats: %w", err)
+		return nil, 0, fmt.Errorf("failed to query allowed chats: %w", err)
 	}
 	defer rows.Close()
 
@@ -444,19 +1067,26 @@ func (r *Repository) GetAllowedChatsDetailed(ctx context.Context) ([]*models.All
 			&chat.ID,
 			&chat.ChatID,
 			&chat.Name,
+			&chat.WeeklyDigest,
 			&chat.CreatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan allowed chat: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan allowed chat: %w", err)
 		}
 		chats = append(chats, &chat)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating allowed chats: %w", err)
+		return nil, 0, fmt.Errorf("error iterating allowed chats: %w", err)
+	}
+
+	var nextPageToken int64
+	if len(chats) > limit {
+		chats = chats[:limit]
+		nextPageToken = chats[limit-1].ID
 	}
 
-	return chats, nil
+	return chats, nextPageToken, nil
 }
 
 // AddAllowedChat adds a new chat to the allowed list
@@ -482,7 +1112,7 @@ func (r *Repository) AddAllowedChatDetailed(ctx context.Context, chatID int64, n
 		ON CONFLICT (chat_id) 
 		DO UPDATE SET 
 			name = EXCLUDED.name
-		RETURNING id, chat_id, name, created_at
+		RETURNING id, chat_id, name, weekly_digest, created_at
 	`
 
 	var chat models.AllowedChat
@@ -490,6 +1120,7 @@ func (r *Repository) AddAllowedChatDetailed(ctx context.Context, chatID int64, n
 		&chat.ID,
 		&chat.ChatID,
 		&chat.Name,
+		&chat.WeeklyDigest,
 		&chat.CreatedAt,
 	)
 	if err != nil {
@@ -533,6 +1164,22 @@ func (r *Repository) GetMessageCounter(ctx context.Context, chatID int64) (int,
 	return count, nil
 }
 
+// GetTopicMessageCounter gets the current message counter for a chat/topic, 0 if none exists yet
+func (r *Repository) GetTopicMessageCounter(ctx context.Context, chatID int64, topicID *int64) (int, error) {
+	query := `SELECT count FROM message_counters WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2)`
+
+	var count int
+	err := r.pool.QueryRow(ctx, query, chatID, topicID).Scan(&count)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get message counter: %w", err)
+	}
+
+	return count, nil
+}
+
 // IncrementMessageCounter increments the message counter for a chat/topic and returns the new count
 func (r *Repository) IncrementMessageCounter(ctx context.Context, chatID int64, topicID *int64) (int, error) {
 	query := `
@@ -571,6 +1218,24 @@ func (r *Repository) ResetMessageCounter(ctx context.Context, chatID int64, topi
 	return nil
 }
 
+// SetMessageCounter sets the message counter for a chat/topic to the given value
+func (r *Repository) SetMessageCounter(ctx context.Context, chatID int64, topicID *int64, count int) error {
+	query := `
+		INSERT INTO message_counters (chat_id, topic_id, count, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat_id, topic_id)
+		DO UPDATE SET
+			count = EXCLUDED.count,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err := r.pool.Exec(ctx, query, chatID, topicID, count, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set message counter: %w", err)
+	}
+
+	return nil
+}
+
 // ResetAllMessageCounters resets all message counters to 0
 func (r *Repository) ResetAllMessageCounters(ctx context.Context) error {
 	query := `UPDATE message_counters SET count = 0, updated_at = $1`
@@ -583,20 +1248,37 @@ func (r *Repository) ResetAllMessageCounters(ctx context.Context) error {
 	return nil
 }
 
+// ResetGeneralMessageCounters resets the message counters of general topics (no topic or topic 0)
+// to 0, keeping forum topic counters
+func (r *Repository) ResetGeneralMessageCounters(ctx context.Context) error {
+	query := `UPDATE message_counters SET count = 0, updated_at = $1 WHERE COALESCE(topic_id, 0) = 0`
+
+	_, err := r.pool.Exec(ctx, query, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reset general message counters: %w", err)
+	}
+
+	return nil
+}
+
 // User roles operations
 
-// GetUserRolesByChatID retrieves all user roles for a specific chat
-func (r *Repository) GetUserRolesByChatID(ctx context.Context, chatID int64) ([]*models.UserRole, error) {
+// GetUserRolesByChatID retrieves a page of user roles for a specific chat, newest first.
+// pageToken is the token returned for the previous page (0 = first page); the returned
+// token is 0 on the last page.
+func (r *Repository) GetUserRolesByChatID(ctx context.Context, chatID int64, pageToken int64, pageSize int) ([]*models.UserRole, int64, error) {
+	limit := pageLimit(pageSize)
 	query := `
 		SELECT id, telegram_user_id, telegram_chat_id, role, expires_at, created_at, updated_at
 		FROM user_roles
-		WHERE telegram_chat_id = $1
-		ORDER BY created_at DESC
+		WHERE telegram_chat_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
 	`
 
-	rows, err := r.pool.Query(ctx, query, chatID)
+	rows, err := r.pool.Query(ctx, query, chatID, pageToken, limit+1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user roles: %w", err)
+		return nil, 0, fmt.Errorf("failed to query user roles: %w", err)
 	}
 	defer rows.Close()
 
@@ -613,16 +1295,22 @@ func (r *Repository) GetUserRolesByChatID(ctx context.Context, chatID int64) ([]
 			&role.UpdatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user role: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan user role: %w", err)
 		}
 		roles = append(roles, &role)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating user roles: %w", err)
+		return nil, 0, fmt.Errorf("error iterating user roles: %w", err)
+	}
+
+	var nextPageToken int64
+	if len(roles) > limit {
+		roles = roles[:limit]
+		nextPageToken = roles[limit-1].ID
 	}
 
-	return roles, nil
+	return roles, nextPageToken, nil
 }
 
 // GetUserRole retrieves a specific user's role in a chat
@@ -712,6 +1400,55 @@ type UserMessageStats struct {
 	MessageCount int
 }
 
+// ChatBacklog represents messages collected in a chat topic since its last summary
+type ChatBacklog struct {
+	ChatID       int64
+	TopicID      *int64
+	MessageCount int
+}
+
+// GetSummarizeBacklog returns allowed chat topics with at least as many messages newer than their
+// latest summary as the chat's message buffer, largest backlog first. minMessages is the buffer of
+// chats without their own.
+func (r *Repository) GetSummarizeBacklog(ctx context.Context, minMessages, limit int) ([]*ChatBacklog, error) {
+	query := `
+		SELECT m.chat_id, m.topic_id, COUNT(*) as message_count
+		FROM messages m
+		JOIN allowed_chats a ON a.chat_id = m.chat_id
+		LEFT JOIN chat_settings cs ON cs.chat_id = m.chat_id
+		LEFT JOIN (
+			SELECT chat_id, topic_id, MAX(updated_at) as updated_at
+			FROM chat_summaries
+			GROUP BY chat_id, topic_id
+		) s ON s.chat_id = m.chat_id AND s.topic_id IS NOT DISTINCT FROM m.topic_id
+		WHERE m.is_bot = false AND m.deleted_at IS NULL AND (s.updated_at IS NULL OR m.created_at > s.updated_at)
+		GROUP BY m.chat_id, m.topic_id, cs.max_msg_buffer
+		HAVING COUNT(*) >= COALESCE(NULLIF(cs.max_msg_buffer, 0), $1)
+		ORDER BY message_count DESC
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, minMessages, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query summarize backlog: %w", err)
+	}
+	defer rows.Close()
+
+	var backlog []*ChatBacklog
+	for rows.Next() {
+		b := &ChatBacklog{}
+		if err := rows.Scan(&b.ChatID, &b.TopicID, &b.MessageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan summarize backlog: %w", err)
+		}
+		backlog = append(backlog, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating summarize backlog: %w", err)
+	}
+
+	return backlog, nil
+}
+
 // UserCharStats represents user statistics by character count
 type UserCharStats struct {
 	UserID    int64
@@ -730,8 +1467,24 @@ type UserLastMessageStats struct {
 	LastMessageAt time.Time
 }
 
-// GetUserMessageStats returns message count statistics for users in a chat
+// userIdentityColumns selects a user's current identity in the chat from the users table,
+// falling back to names stored with messages for users not tracked there
+const userIdentityColumns = `CASE WHEN MAX(u.user_id) IS NULL THEN MAX(m.username) ELSE MAX(u.username) END as username,
+			CASE WHEN MAX(u.user_id) IS NULL THEN MAX(m.user_first_name) ELSE MAX(u.first_name) END as first_name,
+			CASE WHEN MAX(u.user_id) IS NULL THEN MAX(m.user_last_name) ELSE MAX(u.last_name) END as last_name`
+
+// GetUserMessageStats returns message count statistics for users in a chat; a zero limit returns all users
 func (r *Repository) GetUserMessageStats(ctx context.Context, chatID int64, limit int, ascending bool) ([]*UserMessageStats, error) {
+	return r.getUserMessageStats(ctx, chatID, nil, limit, ascending)
+}
+
+// GetUserMessageStatsSince returns the users with the most messages in a chat since the given time
+func (r *Repository) GetUserMessageStatsSince(ctx context.Context, chatID int64, since time.Time, limit int) ([]*UserMessageStats, error) {
+	return r.getUserMessageStats(ctx, chatID, &since, limit, false)
+}
+
+// getUserMessageStats counts messages per user, only those sent since the given time when set
+func (r *Repository) getUserMessageStats(ctx context.Context, chatID int64, since *time.Time, limit int, ascending bool) ([]*UserMessageStats, error) {
 	order := "DESC"
 	if ascending {
 		order = "ASC"
@@ -739,18 +1492,18 @@ func (r *Repository) GetUserMessageStats(ctx context.Context, chatID int64, limi
 
 	query := fmt.Sprintf(`
 		SELECT
-			user_id,
-			MAX(username) as username,
-			MAX(user_first_name) as first_name,
-			MAX(user_last_name) as last_name,
+			m.user_id,
+			%s,
 			COUNT(*) as message_count
-		FROM messages
-		WHERE chat_id = $1 AND is_bot = false
-		GROUP BY user_id
+		FROM messages m
+		LEFT JOIN users u ON u.chat_id = m.chat_id AND u.user_id = m.user_id
+		WHERE m.chat_id = $1 AND m.is_bot = false AND m.deleted_at IS NULL
+		  AND ($3::timestamptz IS NULL OR m.created_at >= $3)
+		GROUP BY m.user_id
 		ORDER BY message_count %s
-		LIMIT $2`, order)
+		LIMIT NULLIF($2, 0)`, userIdentityColumns, order)
 
-	rows, err := r.pool.Query(ctx, query, chatID, limit)
+	rows, err := r.pool.Query(ctx, query, chatID, limit, since)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query user message stats: %w", err)
 	}
@@ -773,7 +1526,101 @@ func (r *Repository) GetUserMessageStats(ctx context.Context, chatID int64, limi
 	return stats, nil
 }
 
-// GetUserCharStats returns character count statistics for users in a chat
+// UserMessageRank is a user's position in the message count leaderboard of a chat
+type UserMessageRank struct {
+	Rank         int // 0 when the user has no messages
+	Total        int // users with messages in the chat
+	MessageCount int
+}
+
+// GetUserMessageRank returns the user's rank by message count in a chat; users with the same
+// count share a rank
+func (r *Repository) GetUserMessageRank(ctx context.Context, chatID, userID int64) (*UserMessageRank, error) {
+	query := `
+		WITH ranked AS (
+			SELECT
+				user_id,
+				COUNT(*) as message_count,
+				RANK() OVER (ORDER BY COUNT(*) DESC) as rank
+			FROM messages
+			WHERE chat_id = $1 AND is_bot = false AND deleted_at IS NULL
+			GROUP BY user_id
+		)
+		SELECT
+			COALESCE((SELECT rank FROM ranked WHERE user_id = $2), 0),
+			(SELECT COUNT(*) FROM ranked),
+			COALESCE((SELECT message_count FROM ranked WHERE user_id = $2), 0)`
+
+	var rank UserMessageRank
+	err := r.pool.QueryRow(ctx, query, chatID, userID).Scan(&rank.Rank, &rank.Total, &rank.MessageCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user message rank: %w", err)
+	}
+
+	return &rank, nil
+}
+
+// Message filters for count-based stats. These are fixed SQL fragments, never user input.
+const (
+	questionMessageFilter = `RTRIM(text) LIKE '%?'`
+	linkMessageFilter     = `text ~* '(https?://|www\.)[^[:space:]]+'`
+)
+
+// GetUserQuestionStats returns statistics of questions asked (messages ending in "?") for users in a chat
+func (r *Repository) GetUserQuestionStats(ctx context.Context, chatID int64, limit int, ascending bool) ([]*UserMessageStats, error) {
+	return r.getUserFilteredMessageStats(ctx, chatID, limit, ascending, questionMessageFilter)
+}
+
+// GetUserLinkStats returns statistics of messages with links for users in a chat.
+// Entities are not stored, so links are detected by URL pattern in the text.
+func (r *Repository) GetUserLinkStats(ctx context.Context, chatID int64, limit int, ascending bool) ([]*UserMessageStats, error) {
+	return r.getUserFilteredMessageStats(ctx, chatID, limit, ascending, linkMessageFilter)
+}
+
+// getUserFilteredMessageStats counts messages matching the filter per user in a chat; a zero limit
+// returns all users
+func (r *Repository) getUserFilteredMessageStats(ctx context.Context, chatID int64, limit int, ascending bool, filter string) ([]*UserMessageStats, error) {
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			m.user_id,
+			%s,
+			COUNT(*) as message_count
+		FROM messages m
+		LEFT JOIN users u ON u.chat_id = m.chat_id AND u.user_id = m.user_id
+		WHERE m.chat_id = $1 AND m.is_bot = false AND m.deleted_at IS NULL AND m.text IS NOT NULL AND %s
+		GROUP BY m.user_id
+		ORDER BY message_count %s
+		LIMIT NULLIF($2, 0)`, userIdentityColumns, filter, order)
+
+	rows, err := r.pool.Query(ctx, query, chatID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user filtered message stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*UserMessageStats
+	for rows.Next() {
+		s := &UserMessageStats{}
+		err := rows.Scan(&s.UserID, &s.Username, &s.FirstName, &s.LastName, &s.MessageCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user filtered message stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user filtered message stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetUserCharStats returns character count statistics for users in a chat; a zero limit returns all users
 func (r *Repository) GetUserCharStats(ctx context.Context, chatID int64, limit int, ascending bool) ([]*UserCharStats, error) {
 	order := "DESC"
 	if ascending {
@@ -782,16 +1629,15 @@ func (r *Repository) GetUserCharStats(ctx context.Context, chatID int64, limit i
 
 	query := fmt.Sprintf(`
 		SELECT
-			user_id,
-			MAX(username) as username,
-			MAX(user_first_name) as first_name,
-			MAX(user_last_name) as last_name,
-			COALESCE(SUM(LENGTH(text)), 0) as char_count
-		FROM messages
-		WHERE chat_id = $1 AND is_bot = false
-		GROUP BY user_id
+			m.user_id,
+			%s,
+			COALESCE(SUM(LENGTH(m.text)), 0) as char_count
+		FROM messages m
+		LEFT JOIN users u ON u.chat_id = m.chat_id AND u.user_id = m.user_id
+		WHERE m.chat_id = $1 AND m.is_bot = false AND m.deleted_at IS NULL
+		GROUP BY m.user_id
 		ORDER BY char_count %s
-		LIMIT $2`, order)
+		LIMIT NULLIF($2, 0)`, userIdentityColumns, order)
 
 	rows, err := r.pool.Query(ctx, query, chatID, limit)
 	if err != nil {
@@ -825,16 +1671,15 @@ func (r *Repository) GetUserLastMessageStats(ctx context.Context, chatID int64,
 
 	query := fmt.Sprintf(`
 		SELECT
-			user_id,
-			MAX(username) as username,
-			MAX(user_first_name) as first_name,
-			MAX(user_last_name) as last_name,
-			MAX(created_at) as last_message_at
-		FROM messages
-		WHERE chat_id = $1 AND is_bot = false
-		GROUP BY user_id
+			m.user_id,
+			%s,
+			MAX(m.created_at) as last_message_at
+		FROM messages m
+		LEFT JOIN users u ON u.chat_id = m.chat_id AND u.user_id = m.user_id
+		WHERE m.chat_id = $1 AND m.is_bot = false AND m.deleted_at IS NULL
+		GROUP BY m.user_id
 		ORDER BY last_message_at %s
-		LIMIT $2`, order)
+		LIMIT $2`, userIdentityColumns, order)
 
 	rows, err := r.pool.Query(ctx, query, chatID, limit)
 	if err != nil {
@@ -896,16 +1741,106 @@ func (r *Repository) GetUserChats(ctx context.Context, userID int64) ([]int64, e
 // ErrWelcomeMessageNotFound indicates no welcome message is configured for the chat
 var ErrWelcomeMessageNotFound = fmt.Errorf("welcome message not found")
 
-// GetWelcomeMessage retrieves the welcome message for a chat/topic if enabled
+// welcomeMessageColumns selects a welcome message; rows created before enabled was set count as enabled
+const welcomeMessageColumns = `id, chat_id, topic_id, message, COALESCE(enabled, true), created_at, updated_at`
+
+// GetWelcomeMessage retrieves the welcome message stored for a chat/topic, enabled or not.
+// A nil or zero topic means the general chat.
 func (r *Repository) GetWelcomeMessage(ctx context.Context, chatID int64, topicID *int64) (*models.WelcomeMessage, error) {
 	query := `
-		SELECT id, chat_id, topic_id, message, enabled, created_at, updated_at
+		SELECT ` + welcomeMessageColumns + `
 		FROM welcome_messages
-		WHERE chat_id = $1 AND COALESCE(topic_id, 0) = COALESCE($2, 0) AND enabled = true
+		WHERE chat_id = $1 AND COALESCE(topic_id, 0) = COALESCE($2, 0)
 	`
 
+	wm, err := scanWelcomeMessage(r.pool.QueryRow(ctx, query, chatID, topicID))
+	if err != nil {
+		if errors.Is(err, ErrWelcomeMessageNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get welcome message: %w", err)
+	}
+
+	return wm, nil
+}
+
+// SetWelcomeMessage creates or replaces the welcome message template of a chat/topic
+func (r *Repository) SetWelcomeMessage(ctx context.Context, chatID int64, topicID *int64, message string, enabled bool) (*models.WelcomeMessage, error) {
+	if topicID != nil && *topicID == 0 {
+		topicID = nil
+	}
+
+	query := `
+		INSERT INTO welcome_messages (chat_id, topic_id, message, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, now(), now())
+		ON CONFLICT (chat_id, COALESCE(topic_id, 0))
+		DO UPDATE SET
+			message = EXCLUDED.message,
+			enabled = EXCLUDED.enabled,
+			updated_at = now()
+		RETURNING ` + welcomeMessageColumns
+
+	wm, err := scanWelcomeMessage(r.pool.QueryRow(ctx, query, chatID, topicID, message, enabled))
+	if err != nil {
+		if errors.Is(err, ErrWelcomeMessageNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to set welcome message: %w", err)
+	}
+
+	return wm, nil
+}
+
+// DeleteWelcomeMessage removes the welcome message of a chat/topic
+func (r *Repository) DeleteWelcomeMessage(ctx context.Context, chatID int64, topicID *int64) error {
+	query := `
+		DELETE FROM welcome_messages
+		WHERE chat_id = $1 AND COALESCE(topic_id, 0) = COALESCE($2, 0)`
+
+	result, err := r.pool.Exec(ctx, query, chatID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to delete welcome message: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrWelcomeMessageNotFound
+	}
+
+	return nil
+}
+
+// MarkUserWelcomed records that the user is welcomed in the chat now, unless they were already
+// welcomed within the cooldown. Returns false if the welcome should be skipped.
+func (r *Repository) MarkUserWelcomed(ctx context.Context, chatID, userID int64, cooldown time.Duration) (bool, error) {
+	query := `
+		INSERT INTO welcome_log (chat_id, user_id, welcomed_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chat_id, user_id) DO UPDATE SET welcomed_at = EXCLUDED.welcomed_at
+		WHERE welcome_log.welcomed_at <= $4`
+
+	now := time.Now()
+	result, err := r.pool.Exec(ctx, query, chatID, userID, now, now.Add(-cooldown))
+	if err != nil {
+		return false, fmt.Errorf("failed to mark user welcomed: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ClearUserWelcomed forgets the user's last welcome in the chat, e.g. when sending it failed
+func (r *Repository) ClearUserWelcomed(ctx context.Context, chatID, userID int64) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM welcome_log WHERE chat_id = $1 AND user_id = $2`, chatID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear user welcome: %w", err)
+	}
+
+	return nil
+}
+
+// scanWelcomeMessage scans a row selected with welcomeMessageColumns
+func scanWelcomeMessage(row pgx.Row) (*models.WelcomeMessage, error) {
 	var wm models.WelcomeMessage
-	err := r.pool.QueryRow(ctx, query, chatID, topicID).Scan(
+	err := row.Scan(
 		&wm.ID,
 		&wm.ChatID,
 		&wm.TopicID,
@@ -914,13 +1849,764 @@ func (r *Repository) GetWelcomeMessage(ctx context.Context, chatID int64, topicI
 		&wm.CreatedAt,
 		&wm.UpdatedAt,
 	)
-
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, ErrWelcomeMessageNotFound
 		}
-		return nil, fmt.Errorf("failed to get welcome message: %w", err)
+		return nil, err
 	}
 
 	return &wm, nil
 }
+
+// User identity operations
+
+// UpdateUserIdentity upserts the current identity of a user in a chat.
+// The row is not rewritten when the identity is unchanged.
+func (r *Repository) UpdateUserIdentity(ctx context.Context, identity models.UserIdentity) error {
+	query := `
+		INSERT INTO users (chat_id, user_id, username, first_name, last_name, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (chat_id, user_id)
+		DO UPDATE SET
+			username = EXCLUDED.username,
+			first_name = EXCLUDED.first_name,
+			last_name = EXCLUDED.last_name,
+			updated_at = now()
+		WHERE (users.username, users.first_name, users.last_name)
+			IS DISTINCT FROM (EXCLUDED.username, EXCLUDED.first_name, EXCLUDED.last_name)`
+
+	_, err := r.pool.Exec(ctx, query, identity.ChatID, identity.UserID, identity.Username, identity.FirstName, identity.LastName)
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserIdentity returns the stored identity of a user in a chat, or nil if the user is not tracked
+func (r *Repository) GetUserIdentity(ctx context.Context, chatID, userID int64) (*models.UserIdentity, error) {
+	query := `
+		SELECT chat_id, user_id, username, first_name, last_name, updated_at
+		FROM users
+		WHERE chat_id = $1 AND user_id = $2`
+
+	var identity models.UserIdentity
+	err := r.pool.QueryRow(ctx, query, chatID, userID).Scan(
+		&identity.ChatID,
+		&identity.UserID,
+		&identity.Username,
+		&identity.FirstName,
+		&identity.LastName,
+		&identity.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// ErrUserNotFound is returned when no message of a user is stored in the chat
+var ErrUserNotFound = fmt.Errorf("user not found")
+
+// FindUserIDByUsername returns the ID of the user who most recently wrote in the chat under the
+// username (case-insensitive, without @)
+func (r *Repository) FindUserIDByUsername(ctx context.Context, chatID int64, username string) (int64, error) {
+	query := `
+		SELECT user_id
+		FROM messages
+		WHERE chat_id = $1 AND lower(username) = lower($2) AND is_bot = false
+		ORDER BY id DESC
+		LIMIT 1`
+
+	var userID int64
+	err := r.pool.QueryRow(ctx, query, chatID, username).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to find user by username: %w", err)
+	}
+
+	return userID, nil
+}
+
+// messageDeleteBatchSize bounds the rows removed per statement when pruning messages
+const messageDeleteBatchSize = 5000
+
+// DeleteMessagesOlderThan removes messages created before the cutoff in batches and returns the
+// number deleted. Only messages up to the last message id summarized in their topic are removed,
+// so messages no summary has read yet are kept.
+func (r *Repository) DeleteMessagesOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM messages
+		WHERE id IN (
+			SELECT m.id FROM messages m
+			WHERE m.created_at < $1
+			  AND EXISTS (
+				SELECT 1 FROM chat_summaries s
+				WHERE s.chat_id = m.chat_id
+				  AND s.topic_id IS NOT DISTINCT FROM m.topic_id
+				  AND s.last_message_id >= m.id
+			  )
+			LIMIT $2
+		)`
+
+	var deleted int64
+	for {
+		tag, err := r.pool.Exec(ctx, query, before, messageDeleteBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete old messages: %w", err)
+		}
+		deleted += tag.RowsAffected()
+		if tag.RowsAffected() < messageDeleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// Chat settings operations
+
+// ErrEmptySummarizePrompt is returned when a blank summarize prompt override is set
+var ErrEmptySummarizePrompt = fmt.Errorf("summarize prompt is empty")
+
+// GetChatSettings returns per-chat settings, or empty settings if none are stored
+func (r *Repository) GetChatSettings(ctx context.Context, chatID int64) (*models.ChatSettings, error) {
+	query := `
+		SELECT chat_id, disabled_commands, pinned_summary_message_id, pinned_summary_topic_id,
+			ui_language, summarize_prompt, reactions_enabled, created_at, updated_at
+		FROM chat_settings
+		WHERE chat_id = $1`
+
+	var settings models.ChatSettings
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(
+		&settings.ChatID,
+		&settings.DisabledCommands,
+		&settings.PinnedSummaryMessageID,
+		&settings.PinnedSummaryTopicID,
+		&settings.UILanguage,
+		&settings.SummarizePrompt,
+		&settings.ReactionsEnabled,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &models.ChatSettings{ChatID: chatID, ReactionsEnabled: true}, nil
+		}
+		return nil, fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SetChatOpenAIKey sets or clears (nil) the OpenAI API key override for a chat.
+// The key is encrypted at rest; setting a key requires an encryption key to be configured.
+// No bot command calls it: a command would post the key in the chat, so operators set keys
+// until an admin API exists.
+func (r *Repository) SetChatOpenAIKey(ctx context.Context, chatID int64, apiKey *string) error {
+	var storedKey *string
+	if apiKey != nil {
+		encrypted, err := r.cipher.Encrypt(*apiKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt chat OpenAI key: %w", err)
+		}
+		storedKey = &encrypted
+	}
+
+	query := `
+		INSERT INTO chat_settings (chat_id, openai_api_key, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			openai_api_key = EXCLUDED.openai_api_key,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, storedKey)
+	if err != nil {
+		return fmt.Errorf("failed to set chat OpenAI key: %w", err)
+	}
+
+	return nil
+}
+
+// GetChatOpenAIKey returns the decrypted OpenAI API key override for a chat, or empty string if unset.
+// The key is only read here, so a key that can't be decrypted fails the calls that use it and
+// leaves the other chat settings readable.
+func (r *Repository) GetChatOpenAIKey(ctx context.Context, chatID int64) (string, error) {
+	query := `SELECT openai_api_key FROM chat_settings WHERE chat_id = $1`
+
+	var storedKey *string
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&storedKey)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get chat OpenAI key: %w", err)
+	}
+
+	if storedKey == nil {
+		return "", nil
+	}
+
+	apiKey, err := r.cipher.Decrypt(*storedKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt chat OpenAI key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// GetChatDisabledCommands returns commands disabled in a chat, or nil if all are enabled
+func (r *Repository) GetChatDisabledCommands(ctx context.Context, chatID int64) ([]string, error) {
+	query := `SELECT disabled_commands FROM chat_settings WHERE chat_id = $1`
+
+	var commands []string
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&commands)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get chat disabled commands: %w", err)
+	}
+
+	return commands, nil
+}
+
+// SetChatDisabledCommands replaces the list of commands disabled in a chat
+func (r *Repository) SetChatDisabledCommands(ctx context.Context, chatID int64, commands []string) error {
+	if commands == nil {
+		commands = []string{}
+	}
+
+	query := `
+		INSERT INTO chat_settings (chat_id, disabled_commands, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			disabled_commands = EXCLUDED.disabled_commands,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, commands)
+	if err != nil {
+		return fmt.Errorf("failed to set chat disabled commands: %w", err)
+	}
+
+	return nil
+}
+
+// GetChatUILanguage returns the UI language of a chat, or empty string if unset
+func (r *Repository) GetChatUILanguage(ctx context.Context, chatID int64) (string, error) {
+	query := `SELECT ui_language FROM chat_settings WHERE chat_id = $1`
+
+	var lang string
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&lang)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get chat UI language: %w", err)
+	}
+
+	return lang, nil
+}
+
+// SetChatUILanguage sets the UI language of a chat (empty string resets to default)
+func (r *Repository) SetChatUILanguage(ctx context.Context, chatID int64, lang string) error {
+	query := `
+		INSERT INTO chat_settings (chat_id, ui_language, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			ui_language = EXCLUDED.ui_language,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, lang)
+	if err != nil {
+		return fmt.Errorf("failed to set chat UI language: %w", err)
+	}
+
+	return nil
+}
+
+// SetChatSummarizePrompt sets or clears (nil) the summarize system prompt override for a chat.
+// Returns ErrEmptySummarizePrompt for a blank prompt.
+func (r *Repository) SetChatSummarizePrompt(ctx context.Context, chatID int64, prompt *string) error {
+	if prompt != nil && strings.TrimSpace(*prompt) == "" {
+		return ErrEmptySummarizePrompt
+	}
+
+	query := `
+		INSERT INTO chat_settings (chat_id, summarize_prompt, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			summarize_prompt = EXCLUDED.summarize_prompt,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to set chat summarize prompt: %w", err)
+	}
+
+	return nil
+}
+
+// GetChatReactionsEnabled reports whether the bot may react to mentions in a chat (default true)
+func (r *Repository) GetChatReactionsEnabled(ctx context.Context, chatID int64) (bool, error) {
+	query := `SELECT reactions_enabled FROM chat_settings WHERE chat_id = $1`
+
+	var enabled bool
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&enabled)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get chat reactions setting: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// SetChatReactionsEnabled enables or disables reactions to mentions in a chat
+func (r *Repository) SetChatReactionsEnabled(ctx context.Context, chatID int64, enabled bool) error {
+	query := `
+		INSERT INTO chat_settings (chat_id, reactions_enabled, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			reactions_enabled = EXCLUDED.reactions_enabled,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to set chat reactions setting: %w", err)
+	}
+
+	return nil
+}
+
+// ChatLimits holds the summarization thresholds of a chat
+type ChatLimits struct {
+	// MaxMsgBuffer is the number of messages collected before a topic is summarized
+	MaxMsgBuffer int
+	// SummarizeMaxMessages is the number of latest messages read for a summary
+	SummarizeMaxMessages int
+}
+
+// GetChatLimits returns the summarization thresholds of a chat. Unset (zero) values
+// fall back to the given defaults.
+func (r *Repository) GetChatLimits(ctx context.Context, chatID int64, defaults ChatLimits) (ChatLimits, error) {
+	query := `SELECT max_msg_buffer, summarize_max_messages FROM chat_settings WHERE chat_id = $1`
+
+	var limits ChatLimits
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(&limits.MaxMsgBuffer, &limits.SummarizeMaxMessages)
+	if err != nil && err != pgx.ErrNoRows {
+		return defaults, fmt.Errorf("failed to get chat limits: %w", err)
+	}
+
+	if limits.MaxMsgBuffer <= 0 {
+		limits.MaxMsgBuffer = defaults.MaxMsgBuffer
+	}
+	if limits.SummarizeMaxMessages <= 0 {
+		limits.SummarizeMaxMessages = defaults.SummarizeMaxMessages
+	}
+
+	return limits, nil
+}
+
+// SetChatLimits sets the summarization thresholds of a chat (zero resets a value to the default)
+func (r *Repository) SetChatLimits(ctx context.Context, chatID int64, limits ChatLimits) error {
+	query := `
+		INSERT INTO chat_settings (chat_id, max_msg_buffer, summarize_max_messages, created_at, updated_at)
+		VALUES ($1, $2, $3, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			max_msg_buffer = EXCLUDED.max_msg_buffer,
+			summarize_max_messages = EXCLUDED.summarize_max_messages,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, max(limits.MaxMsgBuffer, 0), max(limits.SummarizeMaxMessages, 0))
+	if err != nil {
+		return fmt.Errorf("failed to set chat limits: %w", err)
+	}
+
+	return nil
+}
+
+// SetChatMaxMsgBuffer sets the summarization buffer of a chat, keeping its other limits
+// (zero resets it to the default)
+func (r *Repository) SetChatMaxMsgBuffer(ctx context.Context, chatID int64, maxMsgBuffer int) error {
+	query := `
+		INSERT INTO chat_settings (chat_id, max_msg_buffer, created_at, updated_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			max_msg_buffer = EXCLUDED.max_msg_buffer,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, max(maxMsgBuffer, 0))
+	if err != nil {
+		return fmt.Errorf("failed to set chat message buffer: %w", err)
+	}
+
+	return nil
+}
+
+// SetChatPinnedSummary records the pinned summary message of a chat; nil messageID clears it
+func (r *Repository) SetChatPinnedSummary(ctx context.Context, chatID int64, topicID *int64, messageID *int64) error {
+	if messageID == nil {
+		topicID = nil
+	}
+
+	query := `
+		INSERT INTO chat_settings (chat_id, pinned_summary_message_id, pinned_summary_topic_id, created_at, updated_at)
+		VALUES ($1, $2, $3, now(), now())
+		ON CONFLICT (chat_id)
+		DO UPDATE SET
+			pinned_summary_message_id = EXCLUDED.pinned_summary_message_id,
+			pinned_summary_topic_id = EXCLUDED.pinned_summary_topic_id,
+			updated_at = now()`
+
+	_, err := r.pool.Exec(ctx, query, chatID, messageID, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to set chat pinned summary: %w", err)
+	}
+
+	return nil
+}
+
+// Raw completions operations
+
+// SaveRawCompletion stores a raw summarization completion for a chat/topic
+func (r *Repository) SaveRawCompletion(ctx context.Context, chatID int64, topicID *int64, completion string) error {
+	query := `
+		INSERT INTO summarize_raw (chat_id, topic_id, completion, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err := r.pool.Exec(ctx, query, chatID, topicID, completion, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save raw completion: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSummarizationRun records the metadata of a summarization run
+func (r *Repository) SaveSummarizationRun(ctx context.Context, run *models.SummarizationRun) error {
+	query := `
+		INSERT INTO summarization_runs (chat_id, topic_id, message_count, model, prompt_tokens, completion_tokens, duration_ms, status, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.pool.Exec(ctx, query, run.ChatID, run.TopicID, run.MessageCount, run.Model, run.PromptTokens, run.CompletionTokens, run.DurationMs, run.Status, run.Error, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save summarization run: %w", err)
+	}
+
+	return nil
+}
+
+// SummarizationRunFilter narrows GetSummarizationRuns; zero fields don't filter
+type SummarizationRunFilter struct {
+	ChatID *int64
+	Status string
+	Since  time.Time
+	Limit  int
+}
+
+// GetSummarizationRuns returns recorded summarization runs matching the filter, newest first.
+// The limit defaults to DefaultPageSize and is capped at MaxPageSize.
+func (r *Repository) GetSummarizationRuns(ctx context.Context, filter SummarizationRunFilter) ([]*models.SummarizationRun, error) {
+	query := `
+		SELECT id, chat_id, topic_id, message_count, model, prompt_tokens, completion_tokens, duration_ms, status, error, created_at
+		FROM summarization_runs
+		WHERE ($1::bigint IS NULL OR chat_id = $1) AND ($2 = '' OR status = $2) AND created_at >= $3
+		ORDER BY id DESC
+		LIMIT $4`
+
+	rows, err := r.pool.Query(ctx, query, filter.ChatID, filter.Status, filter.Since, pageLimit(filter.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query summarization runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.SummarizationRun
+	for rows.Next() {
+		run := &models.SummarizationRun{}
+		err := rows.Scan(&run.ID, &run.ChatID, &run.TopicID, &run.MessageCount, &run.Model, &run.PromptTokens, &run.CompletionTokens, &run.DurationMs, &run.Status, &run.Error, &run.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan summarization run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating summarization runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// SaveFailedEvent records an event that failed all handler attempts
+func (r *Repository) SaveFailedEvent(ctx context.Context, event *models.FailedEvent) error {
+	query := `
+		INSERT INTO failed_events (topic, handler, payload, error, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := r.pool.Exec(ctx, query, event.Topic, event.Handler, event.Payload, event.Error, event.Attempts, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save failed event: %w", err)
+	}
+
+	return nil
+}
+
+// GetFailedEvents returns recorded failed events of a topic (empty = all topics), newest first.
+// The limit defaults to DefaultPageSize and is capped at MaxPageSize.
+func (r *Repository) GetFailedEvents(ctx context.Context, topic string, limit int) ([]*models.FailedEvent, error) {
+	query := `
+		SELECT id, topic, handler, payload, error, attempts, created_at
+		FROM failed_events
+		WHERE $1 = '' OR topic = $1
+		ORDER BY id DESC
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, topic, pageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.FailedEvent
+	for rows.Next() {
+		event := &models.FailedEvent{}
+		err := rows.Scan(&event.ID, &event.Topic, &event.Handler, &event.Payload, &event.Error, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan failed event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating failed events: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetLastRawCompletion returns the latest raw completion for a chat/topic, or nil if none is stored
+func (r *Repository) GetLastRawCompletion(ctx context.Context, chatID int64, topicID *int64) (*models.RawCompletion, error) {
+	query := `
+		SELECT id, chat_id, topic_id, completion, created_at
+		FROM summarize_raw
+		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+
+	var raw models.RawCompletion
+	err := r.pool.QueryRow(ctx, query, chatID, topicID).Scan(
+		&raw.ID,
+		&raw.ChatID,
+		&raw.TopicID,
+		&raw.Completion,
+		&raw.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last raw completion: %w", err)
+	}
+
+	return &raw, nil
+}
+
+// DeleteRawCompletionsBefore removes raw completions created before the cutoff and returns the number deleted
+func (r *Repository) DeleteRawCompletionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM summarize_raw WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete raw completions: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Token usage operations
+
+// ChatTokenUsage holds token totals of a chat for one model
+type ChatTokenUsage struct {
+	ChatID           int64
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// SaveTokenUsage records the tokens used by a completion for a chat
+func (r *Repository) SaveTokenUsage(ctx context.Context, chatID int64, model string, promptTokens, completionTokens int64) error {
+	query := `
+		INSERT INTO token_usage (chat_id, model, prompt_tokens, completion_tokens, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.pool.Exec(ctx, query, chatID, model, promptTokens, completionTokens, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save token usage: %w", err)
+	}
+
+	return nil
+}
+
+// GetUsageStats returns token totals per chat and model for completions created in [from, to)
+func (r *Repository) GetUsageStats(ctx context.Context, from, to time.Time) ([]*ChatTokenUsage, error) {
+	query := `
+		SELECT chat_id, model, SUM(prompt_tokens), SUM(completion_tokens)
+		FROM token_usage
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY chat_id, model
+		ORDER BY chat_id, model`
+
+	rows, err := r.pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*ChatTokenUsage
+	for rows.Next() {
+		var usage ChatTokenUsage
+		if err := rows.Scan(&usage.ChatID, &usage.Model, &usage.PromptTokens, &usage.CompletionTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan usage stats: %w", err)
+		}
+		stats = append(stats, &usage)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetSchedulerLastRun returns when a scheduled job last ran, or nil if it never ran
+func (r *Repository) GetSchedulerLastRun(ctx context.Context, job string) (*time.Time, error) {
+	query := `SELECT last_run_at FROM scheduler_state WHERE job = $1`
+
+	var lastRun time.Time
+	err := r.pool.QueryRow(ctx, query, job).Scan(&lastRun)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get scheduler last run: %w", err)
+	}
+
+	return &lastRun, nil
+}
+
+// SetSchedulerLastRun records when a scheduled job last ran
+func (r *Repository) SetSchedulerLastRun(ctx context.Context, job string, at time.Time) error {
+	query := `
+		INSERT INTO scheduler_state (job, last_run_at)
+		VALUES ($1, $2)
+		ON CONFLICT (job) DO UPDATE SET last_run_at = EXCLUDED.last_run_at`
+
+	if _, err := r.pool.Exec(ctx, query, job, at); err != nil {
+		return fmt.Errorf("failed to set scheduler last run: %w", err)
+	}
+
+	return nil
+}
+
+// SaveChatEvents stores events of a chat topic, skipping events already stored with the
+// same title (case-insensitive) and date
+func (r *Repository) SaveChatEvents(ctx context.Context, events []*models.ChatEvent) error {
+	return saveChatEvents(ctx, r.pool, events)
+}
+
+func saveChatEvents(ctx context.Context, q execer, events []*models.ChatEvent) error {
+	query := `
+		INSERT INTO chat_events (chat_id, topic_id, title, date, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING`
+
+	now := time.Now()
+	for _, event := range events {
+		_, err := q.Exec(ctx, query, event.ChatID, event.TopicID, event.Title, event.Date, event.EndsAt, now)
+		if err != nil {
+			return fmt.Errorf("failed to save chat event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetUpcomingEvents returns events of a chat topic that are not over at now, soonest first;
+// events with an unknown date come last. The limit defaults to DefaultPageSize and is
+// capped at MaxPageSize.
+func (r *Repository) GetUpcomingEvents(ctx context.Context, chatID int64, topicID *int64, now time.Time, limit int) ([]*models.ChatEvent, error) {
+	query := `
+		SELECT id, chat_id, topic_id, title, date, ends_at, created_at
+		FROM chat_events
+		WHERE chat_id = $1 AND ($2::bigint IS NULL AND topic_id IS NULL OR topic_id = $2)
+		  AND (ends_at IS NULL OR ends_at >= $3)
+		ORDER BY ends_at ASC NULLS LAST, id ASC
+		LIMIT $4`
+
+	rows, err := r.pool.Query(ctx, query, chatID, topicID, now, pageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upcoming events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.ChatEvent
+	for rows.Next() {
+		event := &models.ChatEvent{}
+		err := rows.Scan(&event.ID, &event.ChatID, &event.TopicID, &event.Title, &event.Date, &event.EndsAt, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chat event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chat events: %w", err)
+	}
+
+	return events, nil
+}
+
+// DeleteChatEvent deletes a stored event of a chat
+func (r *Repository) DeleteChatEvent(ctx context.Context, chatID, id int64) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM chat_events WHERE chat_id = $1 AND id = $2`, chatID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete chat event: %w", err)
+	}
+
+	return nil
+}
+
+// Handled commands operations
+
+// MarkCommandHandled records a command message of a chat and reports whether it was not handled before
+func (r *Repository) MarkCommandHandled(ctx context.Context, chatID, telegramMsgID int64) (bool, error) {
+	query := `
+		INSERT INTO handled_commands (chat_id, telegram_msg_id)
+		VALUES ($1, $2)
+		ON CONFLICT (chat_id, telegram_msg_id) DO NOTHING`
+
+	tag, err := r.pool.Exec(ctx, query, chatID, telegramMsgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark command handled: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteHandledCommandsBefore removes handled command records created before the cutoff and returns the number deleted
+func (r *Repository) DeleteHandledCommandsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM handled_commands WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete handled commands: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
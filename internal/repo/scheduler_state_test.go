@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSchedulerLastRun(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	job := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM scheduler_state WHERE job = $1`, job)
+	})
+
+	lastRun, err := r.GetSchedulerLastRun(ctx, job)
+	if err != nil {
+		t.Fatalf("GetSchedulerLastRun returned error: %v", err)
+	}
+	if lastRun != nil {
+		t.Errorf("Expected no last run for a new job, got %v", lastRun)
+	}
+
+	first := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	for _, at := range []time.Time{first, second} {
+		if err := r.SetSchedulerLastRun(ctx, job, at); err != nil {
+			t.Fatalf("SetSchedulerLastRun returned error: %v", err)
+		}
+	}
+
+	lastRun, err = r.GetSchedulerLastRun(ctx, job)
+	if err != nil {
+		t.Fatalf("GetSchedulerLastRun returned error: %v", err)
+	}
+	if lastRun == nil || !lastRun.Equal(second) {
+		t.Errorf("Expected last run %v, got %v", second, lastRun)
+	}
+}
@@ -0,0 +1,291 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/internal/secrets"
+)
+
+func TestChatDisabledCommands(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	otherChatID := chatID - 1
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id IN ($1, $2)`, chatID, otherChatID)
+	})
+
+	if err := r.SetChatDisabledCommands(ctx, chatID, []string{"/stats"}); err != nil {
+		t.Fatalf("SetChatDisabledCommands returned error: %v", err)
+	}
+
+	disabled, err := r.GetChatDisabledCommands(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatDisabledCommands returned error: %v", err)
+	}
+	if len(disabled) != 1 || disabled[0] != "/stats" {
+		t.Errorf("Expected [/stats], got %v", disabled)
+	}
+
+	other, err := r.GetChatDisabledCommands(ctx, otherChatID)
+	if err != nil {
+		t.Fatalf("GetChatDisabledCommands returned error: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("Expected no disabled commands in other chat, got %v", other)
+	}
+}
+
+func TestChatPinnedSummary(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(7)
+	messageID := int64(123)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	if err := r.SetChatPinnedSummary(ctx, chatID, &topicID, &messageID); err != nil {
+		t.Fatalf("SetChatPinnedSummary returned error: %v", err)
+	}
+
+	settings, err := r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.PinnedSummaryMessageID == nil || *settings.PinnedSummaryMessageID != messageID {
+		t.Errorf("Expected pinned message %d, got %v", messageID, settings.PinnedSummaryMessageID)
+	}
+	if settings.PinnedSummaryTopicID == nil || *settings.PinnedSummaryTopicID != topicID {
+		t.Errorf("Expected pinned topic %d, got %v", topicID, settings.PinnedSummaryTopicID)
+	}
+
+	if err := r.SetChatPinnedSummary(ctx, chatID, &topicID, nil); err != nil {
+		t.Fatalf("SetChatPinnedSummary returned error: %v", err)
+	}
+
+	settings, err = r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.PinnedSummaryMessageID != nil || settings.PinnedSummaryTopicID != nil {
+		t.Errorf("Expected pinned summary to be cleared, got %+v", settings)
+	}
+}
+
+func TestChatUILanguage(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	lang, err := r.GetChatUILanguage(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatUILanguage returned error: %v", err)
+	}
+	if lang != "" {
+		t.Errorf("Expected empty language for chat without settings, got %q", lang)
+	}
+
+	if err := r.SetChatUILanguage(ctx, chatID, "en"); err != nil {
+		t.Fatalf("SetChatUILanguage returned error: %v", err)
+	}
+
+	settings, err := r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.UILanguage != "en" {
+		t.Errorf("Expected language en, got %q", settings.UILanguage)
+	}
+}
+
+func TestChatLimits(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	defaults := ChatLimits{MaxMsgBuffer: 100, SummarizeMaxMessages: 500}
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	limits, err := r.GetChatLimits(ctx, chatID, defaults)
+	if err != nil {
+		t.Fatalf("GetChatLimits returned error: %v", err)
+	}
+	if limits != defaults {
+		t.Errorf("Expected defaults %+v for unset chat, got %+v", defaults, limits)
+	}
+
+	if err := r.SetChatLimits(ctx, chatID, ChatLimits{MaxMsgBuffer: 20}); err != nil {
+		t.Fatalf("SetChatLimits returned error: %v", err)
+	}
+
+	limits, err = r.GetChatLimits(ctx, chatID, defaults)
+	if err != nil {
+		t.Fatalf("GetChatLimits returned error: %v", err)
+	}
+	if limits.MaxMsgBuffer != 20 || limits.SummarizeMaxMessages != defaults.SummarizeMaxMessages {
+		t.Errorf("Expected buffer 20 with default summarize limit, got %+v", limits)
+	}
+}
+
+func TestSetChatMaxMsgBuffer(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	defaults := ChatLimits{MaxMsgBuffer: 50, SummarizeMaxMessages: 200}
+	if err := r.SetChatLimits(ctx, chatID, ChatLimits{SummarizeMaxMessages: 300}); err != nil {
+		t.Fatalf("SetChatLimits returned error: %v", err)
+	}
+	if err := r.SetChatMaxMsgBuffer(ctx, chatID, 15); err != nil {
+		t.Fatalf("SetChatMaxMsgBuffer returned error: %v", err)
+	}
+
+	limits, err := r.GetChatLimits(ctx, chatID, defaults)
+	if err != nil {
+		t.Fatalf("GetChatLimits returned error: %v", err)
+	}
+	if limits.MaxMsgBuffer != 15 || limits.SummarizeMaxMessages != 300 {
+		t.Errorf("Expected buffer 15 with the summarize limit kept, got %+v", limits)
+	}
+
+	if err := r.SetChatMaxMsgBuffer(ctx, chatID, 0); err != nil {
+		t.Fatalf("SetChatMaxMsgBuffer returned error: %v", err)
+	}
+	if limits, _ := r.GetChatLimits(ctx, chatID, defaults); limits.MaxMsgBuffer != defaults.MaxMsgBuffer {
+		t.Errorf("Expected the default buffer after reset, got %+v", limits)
+	}
+}
+
+func TestChatSummarizePrompt(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	blank := "   "
+	if err := r.SetChatSummarizePrompt(ctx, chatID, &blank); !errors.Is(err, ErrEmptySummarizePrompt) {
+		t.Errorf("Expected ErrEmptySummarizePrompt, got %v", err)
+	}
+
+	prompt := "Extract support issues"
+	if err := r.SetChatSummarizePrompt(ctx, chatID, &prompt); err != nil {
+		t.Fatalf("SetChatSummarizePrompt returned error: %v", err)
+	}
+
+	settings, err := r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.SummarizePrompt == nil || *settings.SummarizePrompt != prompt {
+		t.Errorf("Expected prompt %q, got %v", prompt, settings.SummarizePrompt)
+	}
+
+	if err := r.SetChatSummarizePrompt(ctx, chatID, nil); err != nil {
+		t.Fatalf("SetChatSummarizePrompt returned error: %v", err)
+	}
+	settings, err = r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.SummarizePrompt != nil {
+		t.Errorf("Expected cleared prompt, got %q", *settings.SummarizePrompt)
+	}
+}
+
+func TestChatReactionsEnabled(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	enabled, err := r.GetChatReactionsEnabled(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatReactionsEnabled returned error: %v", err)
+	}
+	if !enabled {
+		t.Error("Expected reactions enabled for chat without settings")
+	}
+
+	if err := r.SetChatUILanguage(ctx, chatID, "en"); err != nil {
+		t.Fatalf("SetChatUILanguage returned error: %v", err)
+	}
+	if enabled, _ := r.GetChatReactionsEnabled(ctx, chatID); !enabled {
+		t.Error("Expected reactions enabled by default for stored settings")
+	}
+
+	if err := r.SetChatReactionsEnabled(ctx, chatID, false); err != nil {
+		t.Fatalf("SetChatReactionsEnabled returned error: %v", err)
+	}
+
+	settings, err := r.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("GetChatSettings returned error: %v", err)
+	}
+	if settings.ReactionsEnabled || settings.UILanguage != "en" {
+		t.Errorf("Expected reactions disabled and language kept, got %+v", settings)
+	}
+}
+
+func TestChatOpenAIKey(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+	})
+
+	cipher, err := secrets.NewCipher("test-key")
+	if err != nil {
+		t.Fatalf("NewCipher returned error: %v", err)
+	}
+	encrypted := New(r.pool, cipher)
+
+	apiKey := "sk-chat"
+	if err := encrypted.SetChatOpenAIKey(ctx, chatID, &apiKey); err != nil {
+		t.Fatalf("SetChatOpenAIKey returned error: %v", err)
+	}
+
+	got, err := encrypted.GetChatOpenAIKey(ctx, chatID)
+	if err != nil || got != apiKey {
+		t.Errorf("Expected the decrypted chat key, got %q (err %v)", got, err)
+	}
+
+	// Without the encryption key only the API key lookup fails; other settings stay readable
+	if _, err := r.GetChatOpenAIKey(ctx, chatID); !errors.Is(err, secrets.ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey without an encryption key, got %v", err)
+	}
+	if _, err := r.GetChatSettings(ctx, chatID); err != nil {
+		t.Errorf("Expected chat settings to be readable without an encryption key, got %v", err)
+	}
+
+	if err := encrypted.SetChatOpenAIKey(ctx, chatID, nil); err != nil {
+		t.Fatalf("SetChatOpenAIKey returned error: %v", err)
+	}
+	if got, err := r.GetChatOpenAIKey(ctx, chatID); err != nil || got != "" {
+		t.Errorf("Expected a cleared key to be empty, got %q (err %v)", got, err)
+	}
+}
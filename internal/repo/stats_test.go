@@ -0,0 +1,287 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/xdefrag/william/internal/migrations"
+	"github.com/xdefrag/william/pkg/models"
+)
+
+// newTestRepository connects to TEST_PG_DSN and applies migrations, skipping the test if unset
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PG_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+	if err := migrations.Run(ctx, sqlDB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	return New(pool, nil)
+}
+
+func TestQuestionAndLinkStats(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	dataset := []struct {
+		userID int64
+		text   string
+	}{
+		{1, "Как дела?"},
+		{1, "Кто идёт на встречу? "},
+		{1, "смотрите https://example.com"},
+		{2, "Что нового?"},
+		{2, "www.example.org и http://example.net"},
+		{2, "https://example.com/a"},
+		{3, "просто сообщение"},
+	}
+
+	for i, d := range dataset {
+		text := d.text
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        d.userID,
+			UserFirstName: "User",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("Failed to save message: %v", err)
+		}
+	}
+
+	questions, err := r.GetUserQuestionStats(ctx, chatID, 10, false)
+	if err != nil {
+		t.Fatalf("GetUserQuestionStats returned error: %v", err)
+	}
+	if len(questions) != 2 || questions[0].UserID != 1 || questions[0].MessageCount != 2 || questions[1].MessageCount != 1 {
+		t.Errorf("Unexpected question stats: %+v", questions)
+	}
+
+	links, err := r.GetUserLinkStats(ctx, chatID, 10, false)
+	if err != nil {
+		t.Fatalf("GetUserLinkStats returned error: %v", err)
+	}
+	if len(links) != 2 || links[0].UserID != 2 || links[0].MessageCount != 2 || links[1].MessageCount != 1 {
+		t.Errorf("Unexpected link stats: %+v", links)
+	}
+}
+
+func TestGetSummarizeBacklog(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	backloggedChatID := -time.Now().UnixNano()
+	summarizedChatID := backloggedChatID - 1
+	smallBufferChatID := backloggedChatID - 2
+	largeBufferChatID := backloggedChatID - 3
+	chatIDs := []int64{backloggedChatID, summarizedChatID, smallBufferChatID, largeBufferChatID}
+
+	t.Cleanup(func() {
+		for _, chatID := range chatIDs {
+			_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+			_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+			_, _ = r.pool.Exec(ctx, `DELETE FROM chat_settings WHERE chat_id = $1`, chatID)
+			_, _ = r.pool.Exec(ctx, `DELETE FROM allowed_chats WHERE chat_id = $1`, chatID)
+		}
+	})
+
+	// Per-chat buffers override the default threshold of 3
+	if err := r.SetChatMaxMsgBuffer(ctx, smallBufferChatID, 2); err != nil {
+		t.Fatalf("SetChatMaxMsgBuffer returned error: %v", err)
+	}
+	if err := r.SetChatMaxMsgBuffer(ctx, largeBufferChatID, 10); err != nil {
+		t.Fatalf("SetChatMaxMsgBuffer returned error: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	for _, chatID := range chatIDs {
+		if err := r.AddAllowedChat(ctx, chatID, "test"); err != nil {
+			t.Fatalf("AddAllowedChat returned error: %v", err)
+		}
+		count := 3
+		if chatID == smallBufferChatID {
+			count = 2
+		}
+		for i := 0; i < count; i++ {
+			text := "сообщение"
+			msg := &models.Message{
+				TelegramMsgID: int64(i + 1),
+				ChatID:        chatID,
+				UserID:        1,
+				UserFirstName: "User",
+				Text:          &text,
+				CreatedAt:     past,
+			}
+			if _, err := r.SaveMessage(ctx, msg); err != nil {
+				t.Fatalf("Failed to save message: %v", err)
+			}
+		}
+	}
+
+	summary := &models.ChatSummary{ChatID: summarizedChatID, Summary: "итоги", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := r.SaveChatSummary(ctx, summary); err != nil {
+		t.Fatalf("SaveChatSummary returned error: %v", err)
+	}
+
+	backlog, err := r.GetSummarizeBacklog(ctx, 3, 100)
+	if err != nil {
+		t.Fatalf("GetSummarizeBacklog returned error: %v", err)
+	}
+
+	found := map[int64]int{}
+	for _, b := range backlog {
+		found[b.ChatID] = b.MessageCount
+	}
+	if found[backloggedChatID] != 3 {
+		t.Errorf("Expected backlogged chat with 3 messages, got %v", found[backloggedChatID])
+	}
+	if _, ok := found[summarizedChatID]; ok {
+		t.Errorf("Expected summarized chat to be excluded from backlog")
+	}
+	if found[smallBufferChatID] != 2 {
+		t.Errorf("Expected chat with a smaller buffer to be backlogged, got %v", found[smallBufferChatID])
+	}
+	if _, ok := found[largeBufferChatID]; ok {
+		t.Errorf("Expected chat below its larger buffer to be excluded from backlog")
+	}
+}
+
+func TestWeeklyDigestChats(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	optedInChatID := -time.Now().UnixNano()
+	otherChatID := optedInChatID - 1
+
+	t.Cleanup(func() {
+		for _, chatID := range []int64{optedInChatID, otherChatID} {
+			_, _ = r.pool.Exec(ctx, `DELETE FROM allowed_chats WHERE chat_id = $1`, chatID)
+		}
+	})
+
+	for _, chatID := range []int64{optedInChatID, otherChatID} {
+		if err := r.AddAllowedChat(ctx, chatID, "test"); err != nil {
+			t.Fatalf("AddAllowedChat returned error: %v", err)
+		}
+	}
+	if err := r.SetAllowedChatWeeklyDigest(ctx, optedInChatID, true); err != nil {
+		t.Fatalf("SetAllowedChatWeeklyDigest returned error: %v", err)
+	}
+	if err := r.SetAllowedChatWeeklyDigest(ctx, otherChatID-1, true); err == nil {
+		t.Error("Expected error for a chat that is not allowed")
+	}
+
+	chatIDs, err := r.GetWeeklyDigestChats(ctx)
+	if err != nil {
+		t.Fatalf("GetWeeklyDigestChats returned error: %v", err)
+	}
+	var optedIn, other bool
+	for _, chatID := range chatIDs {
+		optedIn = optedIn || chatID == optedInChatID
+		other = other || chatID == otherChatID
+	}
+	if !optedIn || other {
+		t.Errorf("Expected only the opted-in chat, got %v", chatIDs)
+	}
+}
+
+func TestResetGeneralMessageCounters(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM message_counters WHERE chat_id = $1`, chatID)
+	})
+
+	general, topic := int64(0), int64(5)
+	for _, topicID := range []*int64{&general, &topic} {
+		if err := r.SetMessageCounter(ctx, chatID, topicID, 7); err != nil {
+			t.Fatalf("SetMessageCounter returned error: %v", err)
+		}
+	}
+
+	if err := r.ResetGeneralMessageCounters(ctx); err != nil {
+		t.Fatalf("ResetGeneralMessageCounters returned error: %v", err)
+	}
+
+	for topicID, want := range map[int64]int{general: 0, topic: 7} {
+		var count int
+		err := r.pool.QueryRow(ctx, `SELECT count FROM message_counters WHERE chat_id = $1 AND topic_id = $2`, chatID, topicID).Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to get message counter: %v", err)
+		}
+		if count != want {
+			t.Errorf("Expected topic %d count %d, got %d", topicID, want, count)
+		}
+	}
+}
+
+func TestGetUserMessageRank(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+	})
+
+	// User 1: 3 messages, users 2 and 3: 2 each, user 4: 1
+	for i, userID := range []int64{1, 1, 1, 2, 2, 3, 3, 4} {
+		text := "message"
+		msg := &models.Message{
+			TelegramMsgID: int64(i + 1),
+			ChatID:        chatID,
+			UserID:        userID,
+			UserFirstName: "User",
+			Text:          &text,
+			CreatedAt:     time.Now(),
+		}
+		if _, err := r.SaveMessage(ctx, msg); err != nil {
+			t.Fatalf("Failed to save message: %v", err)
+		}
+	}
+
+	tests := []struct {
+		userID int64
+		want   UserMessageRank
+	}{
+		{1, UserMessageRank{Rank: 1, Total: 4, MessageCount: 3}},
+		{3, UserMessageRank{Rank: 2, Total: 4, MessageCount: 2}},
+		{4, UserMessageRank{Rank: 4, Total: 4, MessageCount: 1}},
+		{5, UserMessageRank{Rank: 0, Total: 4, MessageCount: 0}},
+	}
+
+	for _, tt := range tests {
+		rank, err := r.GetUserMessageRank(ctx, chatID, tt.userID)
+		if err != nil {
+			t.Fatalf("GetUserMessageRank returned error: %v", err)
+		}
+		if *rank != tt.want {
+			t.Errorf("User %d: expected %+v, got %+v", tt.userID, tt.want, *rank)
+		}
+	}
+}
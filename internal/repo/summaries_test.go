@@ -0,0 +1,289 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestUnmarshalNextEventsFallback(t *testing.T) {
+	legacy := "Meetup on Friday"
+
+	events, err := unmarshalNextEvents([]byte(`[{"title":"Release","date":"2025-12-01"}]`), &legacy)
+	if err != nil {
+		t.Fatalf("unmarshalNextEvents returned error: %v", err)
+	}
+	if len(events) != 1 || events[0].Title != "Release" || events[0].Date != "2025-12-01" {
+		t.Errorf("Expected structured events to take precedence, got %+v", events)
+	}
+
+	for _, raw := range [][]byte{nil, []byte(`[]`)} {
+		events, err := unmarshalNextEvents(raw, &legacy)
+		if err != nil {
+			t.Fatalf("unmarshalNextEvents returned error: %v", err)
+		}
+		if len(events) != 1 || events[0].Title != legacy {
+			t.Errorf("Expected legacy fallback for %q, got %+v", raw, events)
+		}
+	}
+
+	if events, _ := unmarshalNextEvents(nil, nil); len(events) != 0 {
+		t.Errorf("Expected no events, got %+v", events)
+	}
+}
+
+func TestUnmarshalTraitsFallback(t *testing.T) {
+	legacy := "curious"
+
+	traits, err := unmarshalTraits([]byte(`{"tone":"friendly"}`), &legacy)
+	if err != nil {
+		t.Fatalf("unmarshalTraits returned error: %v", err)
+	}
+	if len(traits) != 1 || traits["tone"] != "friendly" {
+		t.Errorf("Expected structured traits to take precedence, got %+v", traits)
+	}
+
+	traits, err = unmarshalTraits([]byte(`{}`), &legacy)
+	if err != nil {
+		t.Fatalf("unmarshalTraits returned error: %v", err)
+	}
+	if traits["description"] != legacy {
+		t.Errorf("Expected legacy fallback, got %+v", traits)
+	}
+}
+
+func TestSummaryLegacyAndJSONRoundTrip(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+		_, _ = r.pool.Exec(ctx, `DELETE FROM user_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	legacyEvents := "Old meetup"
+	chatSummary := &models.ChatSummary{
+		ChatID:         chatID,
+		Summary:        "summary",
+		TopicsJSON:     map[string]interface{}{"go": 1},
+		NextEvents:     &legacyEvents,
+		NextEventsJSON: []models.Event{{Title: "Release", Date: "2025-12-01T10:00:00Z"}},
+	}
+	if err := r.SaveChatSummary(ctx, chatSummary); err != nil {
+		t.Fatalf("SaveChatSummary returned error: %v", err)
+	}
+
+	gotChat, err := r.GetLatestChatSummary(ctx, chatID)
+	if err != nil || gotChat == nil {
+		t.Fatalf("GetLatestChatSummary returned %+v, %v", gotChat, err)
+	}
+	if gotChat.NextEvents == nil || *gotChat.NextEvents != legacyEvents {
+		t.Errorf("Expected legacy events %q, got %v", legacyEvents, gotChat.NextEvents)
+	}
+	if len(gotChat.NextEventsJSON) != 1 || gotChat.NextEventsJSON[0] != chatSummary.NextEventsJSON[0] {
+		t.Errorf("Expected structured events %+v, got %+v", chatSummary.NextEventsJSON, gotChat.NextEventsJSON)
+	}
+
+	legacyTraits := "curious"
+	userSummary := &models.UserSummary{
+		ChatID:           chatID,
+		UserID:           1,
+		LikesJSON:        map[string]interface{}{},
+		DislikesJSON:     map[string]interface{}{},
+		CompetenciesJSON: map[string]interface{}{},
+		Traits:           &legacyTraits,
+		TraitsJSON:       models.UserTrait{"tone": "friendly"},
+	}
+	if err := r.SaveUserSummary(ctx, userSummary); err != nil {
+		t.Fatalf("SaveUserSummary returned error: %v", err)
+	}
+
+	gotUser, err := r.GetLatestUserSummary(ctx, chatID, 1)
+	if err != nil || gotUser == nil {
+		t.Fatalf("GetLatestUserSummary returned %+v, %v", gotUser, err)
+	}
+	if gotUser.Traits == nil || *gotUser.Traits != legacyTraits {
+		t.Errorf("Expected legacy traits %q, got %v", legacyTraits, gotUser.Traits)
+	}
+	if gotUser.TraitsJSON["tone"] != "friendly" {
+		t.Errorf("Expected structured traits, got %+v", gotUser.TraitsJSON)
+	}
+}
+
+func TestUpdateChatSummaryEvents(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	addParty := func(events []models.Event) ([]models.Event, error) {
+		return append(events, models.Event{Title: "Party", Date: "2025-12-31"}), nil
+	}
+
+	if _, err := r.UpdateChatSummaryEvents(ctx, chatID, nil, addParty); err != ErrChatSummaryNotFound {
+		t.Fatalf("Expected ErrChatSummaryNotFound, got %v", err)
+	}
+
+	summary := &models.ChatSummary{ChatID: chatID, Summary: "summary", TopicsJSON: map[string]interface{}{}}
+	if err := r.SaveChatSummary(ctx, summary); err != nil {
+		t.Fatalf("SaveChatSummary returned error: %v", err)
+	}
+
+	if _, err := r.UpdateChatSummaryEvents(ctx, chatID, nil, addParty); err != nil {
+		t.Fatalf("UpdateChatSummaryEvents returned error: %v", err)
+	}
+
+	got, err := r.GetLatestChatSummary(ctx, chatID)
+	if err != nil || got == nil {
+		t.Fatalf("GetLatestChatSummary returned %+v, %v", got, err)
+	}
+	if len(got.NextEventsJSON) != 1 || got.NextEventsJSON[0].Title != "Party" {
+		t.Errorf("Expected added event, got %+v", got.NextEventsJSON)
+	}
+}
+
+func TestWithTxSavesSummariesAtomically(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+		_, _ = r.pool.Exec(ctx, `DELETE FROM user_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	failure := errors.New("user save failed")
+	err := r.WithTx(ctx, func(tx *Tx) error {
+		if err := tx.SaveChatSummary(ctx, &models.ChatSummary{ChatID: chatID, Summary: "rolled back"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+	if got, err := r.GetLatestChatSummary(ctx, chatID); err != nil || got != nil {
+		t.Fatalf("Expected the chat summary to be rolled back, got %+v, %v", got, err)
+	}
+
+	err = r.WithTx(ctx, func(tx *Tx) error {
+		if err := tx.SaveChatSummary(ctx, &models.ChatSummary{ChatID: chatID, Summary: "committed"}); err != nil {
+			return err
+		}
+		return tx.SaveUserSummary(ctx, &models.UserSummary{ChatID: chatID, UserID: 1})
+	})
+	if err != nil {
+		t.Fatalf("WithTx returned error: %v", err)
+	}
+	if got, err := r.GetLatestChatSummary(ctx, chatID); err != nil || got == nil || got.Summary != "committed" {
+		t.Errorf("Expected the committed chat summary, got %+v, %v", got, err)
+	}
+	if got, err := r.GetLatestUserSummary(ctx, chatID, 1); err != nil || got == nil {
+		t.Errorf("Expected the committed user summary, got %+v, %v", got, err)
+	}
+}
+
+func TestFindChatSummaries(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(3)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM chat_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	summaries := []*models.ChatSummary{
+		{ChatID: chatID, Summary: "Обсуждали отпуск и погоду", TopicsJSON: map[string]interface{}{"Kubernetes": 0.9}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ChatID: chatID, TopicID: &topicID, Summary: "Планировали деплой 50% сервисов", TopicsJSON: map[string]interface{}{"Релиз": 0.5}, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	}
+	for _, s := range summaries {
+		if err := r.SaveChatSummary(ctx, s); err != nil {
+			t.Fatalf("SaveChatSummary returned error: %v", err)
+		}
+	}
+
+	byTopic, err := r.FindChatSummaries(ctx, chatID, "kubernetes", 10)
+	if err != nil {
+		t.Fatalf("FindChatSummaries returned error: %v", err)
+	}
+	if len(byTopic) != 1 || byTopic[0].TopicID != nil {
+		t.Errorf("Expected topic name match in the general summary, got %+v", byTopic)
+	}
+
+	byText, err := r.FindChatSummaries(ctx, chatID, "ДЕПЛОЙ", 10)
+	if err != nil {
+		t.Fatalf("FindChatSummaries returned error: %v", err)
+	}
+	if len(byText) != 1 || byText[0].TopicID == nil || *byText[0].TopicID != topicID {
+		t.Errorf("Expected text match in the topic summary, got %+v", byText)
+	}
+
+	literal, err := r.FindChatSummaries(ctx, chatID, "5%", 10)
+	if err != nil {
+		t.Fatalf("FindChatSummaries returned error: %v", err)
+	}
+	if len(literal) != 0 {
+		t.Errorf("Expected %% to match literally, got %+v", literal)
+	}
+}
+
+func TestFindTopicExperts(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM user_summaries WHERE chat_id = $1`, chatID)
+	})
+
+	names := []string{"Alice", "Bob", "Carol", "Dave"}
+	competencies := []map[string]interface{}{
+		{"Kubernetes": 6, "Go": 9},
+		{"kubernetes networking": 8, "Kubernetes": 4},
+		{"Python": 10},
+		{"Kubernetes": "expert"},
+	}
+	for i, c := range competencies {
+		summary := &models.UserSummary{
+			ChatID:           chatID,
+			UserID:           int64(i + 1),
+			FirstName:        &names[i],
+			CompetenciesJSON: c,
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if err := r.SaveUserSummary(ctx, summary); err != nil {
+			t.Fatalf("SaveUserSummary returned error: %v", err)
+		}
+	}
+
+	experts, err := r.FindTopicExperts(ctx, chatID, "kubernetes", 10)
+	if err != nil {
+		t.Fatalf("FindTopicExperts returned error: %v", err)
+	}
+	if len(experts) != 2 {
+		t.Fatalf("Expected 2 experts, got %+v", experts)
+	}
+	if experts[0].FirstName != "Bob" || experts[0].Competency != "kubernetes networking" || experts[0].Score != 8 {
+		t.Errorf("Expected Bob ranked first by his best competency, got %+v", experts[0])
+	}
+	if experts[1].FirstName != "Alice" || experts[1].Score != 6 {
+		t.Errorf("Expected Alice ranked second, got %+v", experts[1])
+	}
+
+	none, err := r.FindTopicExperts(ctx, chatID, "Rust", 10)
+	if err != nil {
+		t.Fatalf("FindTopicExperts returned error: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("Expected no experts, got %+v", none)
+	}
+}
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestSummarizationRuns(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(5)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM summarization_runs WHERE chat_id = $1`, chatID)
+	})
+
+	model, failure := "gpt-4o-mini", "failed to summarize with GPT: timeout"
+	runs := []*models.SummarizationRun{
+		{ChatID: chatID, TopicID: &topicID, MessageCount: 40, Model: &model, PromptTokens: 900, CompletionTokens: 200, DurationMs: 1200, Status: models.SummarizationRunSuccess},
+		{ChatID: chatID, MessageCount: 10, DurationMs: 30000, Status: models.SummarizationRunFailure, Error: &failure},
+	}
+	for _, run := range runs {
+		if err := r.SaveSummarizationRun(ctx, run); err != nil {
+			t.Fatalf("SaveSummarizationRun returned error: %v", err)
+		}
+	}
+
+	all, err := r.GetSummarizationRuns(ctx, SummarizationRunFilter{ChatID: &chatID})
+	if err != nil {
+		t.Fatalf("GetSummarizationRuns returned error: %v", err)
+	}
+	if len(all) != 2 || all[0].Status != models.SummarizationRunFailure || all[1].Status != models.SummarizationRunSuccess {
+		t.Fatalf("Expected both runs newest first, got %+v", all)
+	}
+	if all[0].Error == nil || *all[0].Error != failure || all[1].Model == nil || *all[1].Model != model || *all[1].TopicID != topicID {
+		t.Errorf("Unexpected run details: %+v, %+v", all[0], all[1])
+	}
+
+	succeeded, err := r.GetSummarizationRuns(ctx, SummarizationRunFilter{ChatID: &chatID, Status: models.SummarizationRunSuccess})
+	if err != nil {
+		t.Fatalf("GetSummarizationRuns returned error: %v", err)
+	}
+	if len(succeeded) != 1 || succeeded[0].PromptTokens != 900 {
+		t.Errorf("Expected only the successful run, got %+v", succeeded)
+	}
+
+	if future, _ := r.GetSummarizationRuns(ctx, SummarizationRunFilter{ChatID: &chatID, Since: time.Now().Add(time.Hour)}); len(future) != 0 {
+		t.Errorf("Expected no runs after the since filter, got %+v", future)
+	}
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetUsageStats(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM token_usage WHERE chat_id = $1`, chatID)
+	})
+
+	for _, tokens := range [][2]int64{{100, 20}, {50, 5}} {
+		if err := r.SaveTokenUsage(ctx, chatID, "gpt-4o-mini", tokens[0], tokens[1]); err != nil {
+			t.Fatalf("SaveTokenUsage returned error: %v", err)
+		}
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO token_usage (chat_id, model, prompt_tokens, completion_tokens, created_at)
+		VALUES ($1, 'gpt-4o-mini', 1000, 1000, now() - interval '10 days')`, chatID)
+	if err != nil {
+		t.Fatalf("Failed to insert old usage: %v", err)
+	}
+
+	stats, err := r.GetUsageStats(ctx, time.Now().Add(-24*time.Hour), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GetUsageStats returned error: %v", err)
+	}
+
+	var found *ChatTokenUsage
+	for _, s := range stats {
+		if s.ChatID == chatID {
+			found = s
+		}
+	}
+	if found == nil {
+		t.Fatalf("Expected usage for chat %d, got %+v", chatID, stats)
+	}
+	if found.PromptTokens != 150 || found.CompletionTokens != 25 {
+		t.Errorf("Expected 150/25 tokens within range, got %d/%d", found.PromptTokens, found.CompletionTokens)
+	}
+}
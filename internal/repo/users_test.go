@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xdefrag/william/pkg/models"
+)
+
+func TestUpdateUserIdentityUpsertsOnChange(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	otherChatID := chatID - 1
+	userID := int64(1)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM users WHERE chat_id IN ($1, $2)`, chatID, otherChatID)
+	})
+
+	username := "alice"
+	identity := models.UserIdentity{ChatID: chatID, UserID: userID, Username: &username, FirstName: "Alice"}
+	if err := r.UpdateUserIdentity(ctx, identity); err != nil {
+		t.Fatalf("UpdateUserIdentity returned error: %v", err)
+	}
+
+	first, err := r.GetUserIdentity(ctx, chatID, userID)
+	if err != nil || first == nil {
+		t.Fatalf("GetUserIdentity returned %v, %v", first, err)
+	}
+
+	// Unchanged identity must not rewrite the row
+	if err := r.UpdateUserIdentity(ctx, identity); err != nil {
+		t.Fatalf("UpdateUserIdentity returned error: %v", err)
+	}
+	same, err := r.GetUserIdentity(ctx, chatID, userID)
+	if err != nil {
+		t.Fatalf("GetUserIdentity returned error: %v", err)
+	}
+	if !same.UpdatedAt.Equal(first.UpdatedAt) {
+		t.Errorf("Expected unchanged identity to keep updated_at %v, got %v", first.UpdatedAt, same.UpdatedAt)
+	}
+
+	renamed := "alice_new"
+	identity.Username = &renamed
+	if err := r.UpdateUserIdentity(ctx, identity); err != nil {
+		t.Fatalf("UpdateUserIdentity returned error: %v", err)
+	}
+	changed, err := r.GetUserIdentity(ctx, chatID, userID)
+	if err != nil {
+		t.Fatalf("GetUserIdentity returned error: %v", err)
+	}
+	if changed.Username == nil || *changed.Username != renamed {
+		t.Errorf("Expected username %q, got %v", renamed, changed.Username)
+	}
+
+	other, err := r.GetUserIdentity(ctx, otherChatID, userID)
+	if err != nil {
+		t.Fatalf("GetUserIdentity returned error: %v", err)
+	}
+	if other != nil {
+		t.Errorf("Expected identity to be scoped to its chat, got %+v", other)
+	}
+}
+
+func TestUserIdentityJoinedIntoStatsAndSummaries(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	userID := int64(1)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM messages WHERE chat_id = $1`, chatID)
+		_, _ = r.pool.Exec(ctx, `DELETE FROM user_summaries WHERE chat_id = $1`, chatID)
+		_, _ = r.pool.Exec(ctx, `DELETE FROM users WHERE chat_id = $1`, chatID)
+	})
+
+	oldUsername := "old_name"
+	text := "привет"
+	msg := &models.Message{
+		TelegramMsgID: 1,
+		ChatID:        chatID,
+		UserID:        userID,
+		UserFirstName: "Old",
+		Username:      &oldUsername,
+		Text:          &text,
+		CreatedAt:     time.Now(),
+	}
+	if _, err := r.SaveMessage(ctx, msg); err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+	if _, err := r.SeedUserSummary(ctx, chatID, userID, &oldUsername, "Old", nil); err != nil {
+		t.Fatalf("SeedUserSummary returned error: %v", err)
+	}
+
+	// Without a users row the stored message identity is shown
+	stats, err := r.GetUserMessageStats(ctx, chatID, 10, false)
+	if err != nil {
+		t.Fatalf("GetUserMessageStats returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Username == nil || *stats[0].Username != oldUsername {
+		t.Fatalf("Expected fallback to message identity, got %+v", stats)
+	}
+
+	newUsername := "new_name"
+	if err := r.UpdateUserIdentity(ctx, models.UserIdentity{ChatID: chatID, UserID: userID, Username: &newUsername, FirstName: "New"}); err != nil {
+		t.Fatalf("UpdateUserIdentity returned error: %v", err)
+	}
+
+	stats, err = r.GetUserMessageStats(ctx, chatID, 10, false)
+	if err != nil {
+		t.Fatalf("GetUserMessageStats returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Username == nil || *stats[0].Username != newUsername || stats[0].FirstName != "New" {
+		t.Errorf("Expected stats to show the new identity, got %+v", stats)
+	}
+
+	summary, err := r.GetLatestUserSummary(ctx, chatID, userID)
+	if err != nil {
+		t.Fatalf("GetLatestUserSummary returned error: %v", err)
+	}
+	if summary == nil || summary.Username == nil || *summary.Username != newUsername {
+		t.Errorf("Expected user summary to show the new identity, got %+v", summary)
+	}
+}
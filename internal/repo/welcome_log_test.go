@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMarkUserWelcomedCooldown(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	userID := int64(42)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM welcome_log WHERE chat_id = $1`, chatID)
+	})
+
+	welcome, err := r.MarkUserWelcomed(ctx, chatID, userID, time.Hour)
+	if err != nil {
+		t.Fatalf("MarkUserWelcomed returned error: %v", err)
+	}
+	if !welcome {
+		t.Fatal("Expected the first join to be welcomed")
+	}
+
+	welcome, err = r.MarkUserWelcomed(ctx, chatID, userID, time.Hour)
+	if err != nil {
+		t.Fatalf("MarkUserWelcomed returned error: %v", err)
+	}
+	if welcome {
+		t.Error("Expected a rejoin within the cooldown not to be welcomed again")
+	}
+
+	if welcome, _ := r.MarkUserWelcomed(ctx, chatID, userID+1, time.Hour); !welcome {
+		t.Error("Expected another user to be welcomed")
+	}
+
+	// Move the last welcome out of the window
+	if _, err := r.pool.Exec(ctx, `UPDATE welcome_log SET welcomed_at = now() - interval '2 hours' WHERE chat_id = $1`, chatID); err != nil {
+		t.Fatalf("Failed to age welcome log: %v", err)
+	}
+
+	if welcome, _ := r.MarkUserWelcomed(ctx, chatID, userID, time.Hour); !welcome {
+		t.Error("Expected a rejoin after the cooldown to be welcomed")
+	}
+
+	// A welcome that failed to send is cleared and does not count toward the cooldown
+	if err := r.ClearUserWelcomed(ctx, chatID, userID); err != nil {
+		t.Fatalf("ClearUserWelcomed returned error: %v", err)
+	}
+	if welcome, _ := r.MarkUserWelcomed(ctx, chatID, userID, time.Hour); !welcome {
+		t.Error("Expected a cleared welcome to be sent again")
+	}
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWelcomeMessageLifecycle(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	chatID := -time.Now().UnixNano()
+	topicID := int64(12)
+
+	t.Cleanup(func() {
+		_, _ = r.pool.Exec(ctx, `DELETE FROM welcome_messages WHERE chat_id = $1`, chatID)
+	})
+
+	if _, err := r.GetWelcomeMessage(ctx, chatID, nil); !errors.Is(err, ErrWelcomeMessageNotFound) {
+		t.Fatalf("Expected ErrWelcomeMessageNotFound, got %v", err)
+	}
+
+	if _, err := r.SetWelcomeMessage(ctx, chatID, nil, "Привет, {name}!", true); err != nil {
+		t.Fatalf("SetWelcomeMessage returned error: %v", err)
+	}
+	if _, err := r.SetWelcomeMessage(ctx, chatID, &topicID, "Welcome to the topic", true); err != nil {
+		t.Fatalf("SetWelcomeMessage returned error: %v", err)
+	}
+
+	// Zero topic is the general chat, so this replaces the first template
+	zero := int64(0)
+	updated, err := r.SetWelcomeMessage(ctx, chatID, &zero, "Здравствуйте, {name}", false)
+	if err != nil {
+		t.Fatalf("SetWelcomeMessage returned error: %v", err)
+	}
+	if updated.TopicID != nil || updated.Enabled {
+		t.Errorf("Expected disabled general chat message, got %+v", updated)
+	}
+
+	general, err := r.GetWelcomeMessage(ctx, chatID, &zero)
+	if err != nil {
+		t.Fatalf("GetWelcomeMessage returned error: %v", err)
+	}
+	if general.Message != "Здравствуйте, {name}" || general.Enabled {
+		t.Errorf("Expected the disabled message to be readable, got %+v", general)
+	}
+
+	topic, err := r.GetWelcomeMessage(ctx, chatID, &topicID)
+	if err != nil {
+		t.Fatalf("GetWelcomeMessage returned error: %v", err)
+	}
+	if topic.Message != "Welcome to the topic" || !topic.Enabled {
+		t.Errorf("Unexpected topic message %+v", topic)
+	}
+
+	if err := r.DeleteWelcomeMessage(ctx, chatID, &topicID); err != nil {
+		t.Fatalf("DeleteWelcomeMessage returned error: %v", err)
+	}
+	if err := r.DeleteWelcomeMessage(ctx, chatID, &topicID); !errors.Is(err, ErrWelcomeMessageNotFound) {
+		t.Errorf("Expected ErrWelcomeMessageNotFound for a missing message, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package runtimestats
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Stats keeps in-memory process counters since start. A nil *Stats is valid and counts nothing.
+type Stats struct {
+	startedAt   time.Time
+	messages    atomic.Int64
+	openAICalls atomic.Int64
+}
+
+// Snapshot is a point-in-time view of the runtime counters
+type Snapshot struct {
+	Uptime            time.Duration
+	MessagesProcessed int64
+	OpenAICalls       int64
+	Goroutines        int
+}
+
+// New creates runtime stats starting now
+func New() *Stats {
+	return &Stats{startedAt: time.Now()}
+}
+
+// IncMessages counts a processed incoming message
+func (s *Stats) IncMessages() {
+	if s != nil {
+		s.messages.Add(1)
+	}
+}
+
+// IncOpenAICalls counts a request sent to OpenAI
+func (s *Stats) IncOpenAICalls() {
+	if s != nil {
+		s.openAICalls.Add(1)
+	}
+}
+
+// Snapshot returns the current counters, process uptime and goroutine count
+func (s *Stats) Snapshot() Snapshot {
+	snapshot := Snapshot{Goroutines: runtime.NumGoroutine()}
+	if s == nil {
+		return snapshot
+	}
+
+	snapshot.Uptime = time.Since(s.startedAt)
+	snapshot.MessagesProcessed = s.messages.Load()
+	snapshot.OpenAICalls = s.openAICalls.Load()
+	return snapshot
+}
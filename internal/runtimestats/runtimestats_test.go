@@ -0,0 +1,34 @@
+package runtimestats
+
+import "testing"
+
+func TestStatsCounters(t *testing.T) {
+	s := New()
+	s.IncMessages()
+	s.IncMessages()
+	s.IncOpenAICalls()
+
+	snapshot := s.Snapshot()
+	if snapshot.MessagesProcessed != 2 {
+		t.Errorf("Expected 2 messages, got %d", snapshot.MessagesProcessed)
+	}
+	if snapshot.OpenAICalls != 1 {
+		t.Errorf("Expected 1 OpenAI call, got %d", snapshot.OpenAICalls)
+	}
+	if snapshot.Goroutines <= 0 {
+		t.Errorf("Expected goroutine count, got %d", snapshot.Goroutines)
+	}
+	if snapshot.Uptime < 0 {
+		t.Errorf("Expected non-negative uptime, got %v", snapshot.Uptime)
+	}
+}
+
+func TestNilStats(t *testing.T) {
+	var s *Stats
+	s.IncMessages()
+	s.IncOpenAICalls()
+
+	if snapshot := s.Snapshot(); snapshot.MessagesProcessed != 0 || snapshot.OpenAICalls != 0 {
+		t.Errorf("Expected zero counters for nil stats, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are shorthands for common schedules
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// maxCronSearch bounds the search for the next run of a schedule that never matches (e.g. Feb 30)
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// cronSchedule is a parsed five-field cron expression: minute, hour, day of month, month
+// and day of week (0 or 7 is Sunday), each a bitset of allowed values
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny mark "*" day fields: if both day fields are restricted, either matches
+	domAny, dowAny bool
+}
+
+// parseCron parses a standard five-field cron expression or a descriptor such as @daily.
+// Fields support "*", single values, ranges ("1-5"), steps ("*/15", "0-30/10") and lists.
+func parseCron(spec string) (*cronSchedule, error) {
+	if descriptor, ok := cronDescriptors[strings.TrimSpace(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron minute %q: %w", fields[0], err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron hour %q: %w", fields[1], err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron day of month %q: %w", fields[2], err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron month %q: %w", fields[3], err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron day of week %q: %w", fields[4], err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday too
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	return &s, nil
+}
+
+// parseCronField parses a comma-separated cron field into a bitset of values in [min, max]
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+		}
+
+		low, high := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				high = max // "5/15" means from 5 to the end
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("value out of range %d-%d", min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first run strictly after t in loc. Runs are set on the wall clock, so DST
+// changes don't shift them; a run inside a skipped DST hour doesn't fire that day. Returns the
+// zero time if the schedule never matches.
+func (s *cronSchedule) next(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	run := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute()+1, 0, 0, loc)
+	limit := run.Add(maxCronSearch)
+
+	for run.Before(limit) {
+		switch {
+		case s.month&(1<<uint(run.Month())) == 0:
+			run = time.Date(run.Year(), run.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(run):
+			run = time.Date(run.Year(), run.Month(), run.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(run.Hour())) == 0:
+			run = time.Date(run.Year(), run.Month(), run.Day(), run.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(run.Minute())) == 0:
+			run = run.Add(time.Minute)
+		default:
+			return run
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day of month and day of week fields
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// missedRun returns the latest run of the schedule due at or before now and whether it hasn't
+// run since lastRun. Several missed runs still make a single run.
+func missedRun(schedule *cronSchedule, lastRun, now time.Time, loc *time.Location) (time.Time, bool) {
+	due := schedule.next(lastRun, loc)
+	if due.IsZero() || due.After(now) {
+		return time.Time{}, false
+	}
+
+	for {
+		next := schedule.next(due, loc)
+		if next.IsZero() || next.After(now) {
+			return due, true
+		}
+		due = next
+	}
+}
@@ -11,12 +11,14 @@ import (
 	"github.com/samber/do"
 	"github.com/xdefrag/william/internal/bot"
 	"github.com/xdefrag/william/internal/config"
+	"github.com/xdefrag/william/internal/repo"
 )
 
 // Scheduler handles cron-based events
 type Scheduler struct {
 	publisher message.Publisher
 	listener  *bot.Listener
+	repo      *repo.Repository
 	config    *config.Config
 	logger    *slog.Logger
 
@@ -28,24 +30,31 @@ type Scheduler struct {
 func New(
 	publisher message.Publisher,
 	listener *bot.Listener,
+	repo *repo.Repository,
 	config *config.Config,
 	logger *slog.Logger,
 ) *Scheduler {
 	return &Scheduler{
 		publisher: publisher,
 		listener:  listener,
+		repo:      repo,
 		config:    config,
 		logger:    logger.WithGroup("scheduler"),
 		stopCh:    make(chan struct{}),
 	}
 }
 
-// Start starts the scheduler with midnight cron job
+// Start starts the scheduler with the configured cron jobs
 func (s *Scheduler) Start(ctx context.Context) error {
 	s.logger.InfoContext(ctx, "Starting scheduler")
 
-	// Start midnight scheduler goroutine
-	go s.runMidnightScheduler(ctx)
+	jobs, err := parseJobs(scheduledJobs(s.config))
+	if err != nil {
+		return fmt.Errorf("failed to parse scheduled jobs: %w", err)
+	}
+
+	// Start jobs scheduler goroutine
+	go s.runJobs(ctx, jobs)
 
 	// Wait for context cancellation or stop signal
 	select {
@@ -63,11 +72,83 @@ func (s *Scheduler) Stop() {
 	close(s.stopCh)
 }
 
-// runMidnightScheduler runs the midnight scheduler
-func (s *Scheduler) runMidnightScheduler(ctx context.Context) {
+const (
+	// midnightJob is the scheduler_state key of the default daily summarization job
+	midnightJob = "midnight"
+	// midnightTopic is the topic of the daily summarization and counters reset
+	midnightTopic = "midnight"
+	// weeklyDigestJob posts the weekly digest on Sunday evenings to opted-in chats
+	weeklyDigestJob  = "weekly_digest"
+	weeklyDigestCron = "0 18 * * 0"
+	digestTopic      = "digest"
+)
+
+// job is a scheduled job with its parsed schedule and last run
+type job struct {
+	config.ScheduledJob
+	schedule *cronSchedule
+	lastRun  time.Time
+}
+
+// scheduledJobs returns the default jobs, the midnight job at scheduler.daily_summary_time and
+// the weekly digest job, merged with the configured jobs by name: a configured job replaces the
+// default of the same name and other configured jobs are added
+func scheduledJobs(cfg *config.Config) []config.ScheduledJob {
+	hour, minute := int(cfg.DailySummaryTime/time.Hour), int(cfg.DailySummaryTime%time.Hour/time.Minute)
+	jobs := []config.ScheduledJob{
+		{Name: midnightJob, Cron: fmt.Sprintf("%d %d * * *", minute, hour), Topic: midnightTopic},
+		{Name: weeklyDigestJob, Cron: weeklyDigestCron, Topic: digestTopic},
+	}
+
+	for _, configured := range cfg.App.Scheduler.Jobs {
+		overridden := false
+		for i := range jobs {
+			if jobs[i].Name == configured.Name {
+				jobs[i] = configured
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			jobs = append(jobs, configured)
+		}
+	}
+
+	return jobs
+}
+
+// parseJobs parses the cron specs of the jobs
+func parseJobs(scheduled []config.ScheduledJob) ([]*job, error) {
+	jobs := make([]*job, 0, len(scheduled))
+	for _, scheduledJob := range scheduled {
+		schedule, err := parseCron(scheduledJob.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", scheduledJob.Name, err)
+		}
+		jobs = append(jobs, &job{ScheduledJob: scheduledJob, schedule: schedule})
+	}
+	return jobs, nil
+}
+
+// runJobs publishes each job's event on its schedule in the configured timezone.
+// Last runs are persisted, so a run missed while the process was down or busy fires once
+// on startup or on the next tick.
+func (s *Scheduler) runJobs(ctx context.Context, jobs []*job) {
 	ticker := time.NewTicker(time.Minute) // Check every minute
 	defer ticker.Stop()
 
+	now := time.Now()
+	for _, j := range jobs {
+		j.lastRun = s.loadLastRun(ctx, j.Name, now)
+		s.logger.InfoContext(ctx, "Job scheduled",
+			slog.String("job", j.Name),
+			slog.String("cron", j.Cron),
+			slog.String("topic", j.Topic),
+			slog.Time("last_run", j.lastRun),
+		)
+		s.checkJob(ctx, j, now)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -75,28 +156,91 @@ func (s *Scheduler) runMidnightScheduler(ctx context.Context) {
 		case <-s.stopCh:
 			return
 		case now := <-ticker.C:
-			// Check if it's midnight (00:00)
-			if now.Hour() == 0 && now.Minute() == 0 {
-				s.logger.InfoContext(ctx, "Midnight reached, triggering events",
-					slog.Time("timestamp", now),
-				)
-
-				// Publish midnight event
-				event := bot.MidnightEvent{
-					TriggeredAt: now,
-				}
-
-				if err := s.publishMidnightEvent(ctx, event); err != nil {
-					s.logger.ErrorContext(ctx, "Failed to publish midnight event", slog.Any("error", err))
-				}
-
-				// Reset counters after publishing event
-				s.listener.ResetCountersForAllChats()
+			for _, j := range jobs {
+				s.checkJob(ctx, j, now)
 			}
 		}
 	}
 }
 
+// loadLastRun returns the persisted last run of the job. Without one (first start or a read
+// error) the current time is used so the job doesn't fire right after deploying.
+func (s *Scheduler) loadLastRun(ctx context.Context, name string, now time.Time) time.Time {
+	lastRun, err := s.repo.GetSchedulerLastRun(ctx, name)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get last job run", slog.Any("error", err), slog.String("job", name))
+		return now
+	}
+	if lastRun == nil {
+		s.saveLastRun(ctx, name, now)
+		return now
+	}
+	return *lastRun
+}
+
+// saveLastRun persists the last run of the job
+func (s *Scheduler) saveLastRun(ctx context.Context, name string, at time.Time) {
+	if err := s.repo.SetSchedulerLastRun(ctx, name, at); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save last job run", slog.Any("error", err), slog.String("job", name))
+	}
+}
+
+// checkJob publishes the job's event if a run is due since its last run. The last run only
+// advances once the event is published, so a failed publish is retried on the next tick.
+func (s *Scheduler) checkJob(ctx context.Context, j *job, now time.Time) {
+	due, missed := missedRun(j.schedule, j.lastRun, now, s.config.Location)
+	if !missed {
+		return
+	}
+
+	s.logger.InfoContext(ctx, "Job run is due, triggering event",
+		slog.String("job", j.Name),
+		slog.String("topic", j.Topic),
+		slog.Time("timestamp", now),
+		slog.Time("due", due),
+		slog.Time("last_run", j.lastRun),
+	)
+
+	if j.Topic == midnightTopic {
+		// Publish midnight event
+		event := bot.MidnightEvent{
+			TriggeredAt: now,
+		}
+
+		if err := s.publishMidnightEvent(ctx, event); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to publish midnight event", slog.Any("error", err))
+			return
+		}
+
+		// Reset counters after publishing event
+		s.listener.ResetCountersForAllChats()
+	} else if err := s.publishScheduledEvent(ctx, j, now); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to publish scheduled event", slog.Any("error", err),
+			slog.String("job", j.Name),
+		)
+		return
+	}
+
+	s.saveLastRun(ctx, j.Name, now)
+	j.lastRun = now
+}
+
+// publishScheduledEvent publishes the job's event to its topic
+func (s *Scheduler) publishScheduledEvent(ctx context.Context, j *job, now time.Time) error {
+	event := bot.ScheduledEvent{
+		Job:         j.Name,
+		TriggeredAt: now,
+	}
+
+	msgData, err := event.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled event: %w", err)
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), msgData)
+	return s.publisher.Publish(j.Topic, msg)
+}
+
 // publishMidnightEvent publishes midnight event
 func (s *Scheduler) publishMidnightEvent(ctx context.Context, event bot.MidnightEvent) error {
 	msgData, err := event.Marshal()
@@ -113,9 +257,10 @@ func RegisterDI(container *do.Injector) {
 	do.Provide(container, func(i *do.Injector) (*Scheduler, error) {
 		publisher := do.MustInvoke[message.Publisher](i)
 		listener := do.MustInvoke[*bot.Listener](i)
+		repository := do.MustInvoke[*repo.Repository](i)
 		config := do.MustInvoke[*config.Config](i)
 		logger := do.MustInvoke[*slog.Logger](i)
 
-		return New(publisher, listener, config, logger), nil
+		return New(publisher, listener, repository, config, logger), nil
 	})
 }
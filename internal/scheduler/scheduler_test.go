@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/xdefrag/william/internal/config"
+)
+
+func TestMissedRun(t *testing.T) {
+	belgrade, err := time.LoadLocation("Europe/Belgrade")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, belgrade)
+		if err != nil {
+			t.Fatalf("Failed to parse time: %v", err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		lastRun time.Time
+		now     time.Time
+		wantDue time.Time
+		wantRun bool
+	}{
+		{"not due yet", "0 0 * * *", at("2025-03-10 00:00"), at("2025-03-10 15:00"), time.Time{}, false},
+		{"midnight tick", "0 0 * * *", at("2025-03-09 00:00"), at("2025-03-10 00:00"), at("2025-03-10 00:00"), true},
+		{"downtime crossing midnight", "0 0 * * *", at("2025-03-09 23:55"), at("2025-03-10 00:07"), at("2025-03-10 00:00"), true},
+		{"several days missed", "0 0 * * *", at("2025-03-05 00:00"), at("2025-03-10 12:00"), at("2025-03-10 00:00"), true},
+		{"configured time later today", "30 3 * * *", at("2025-03-09 03:30"), at("2025-03-10 01:00"), time.Time{}, false},
+		{"server in another zone", "0 0 * * *", at("2025-03-09 00:00"), at("2025-03-10 00:01").UTC(), at("2025-03-10 00:00"), true},
+		{"across DST change", "30 3 * * *", at("2025-03-29 03:30"), at("2025-03-30 04:00"), at("2025-03-30 03:30"), true},
+		{"weekly digest", "0 18 * * 0", at("2025-03-02 18:00"), at("2025-03-10 09:00"), at("2025-03-09 18:00"), true},
+		{"weekly digest not due", "0 18 * * 0", at("2025-03-09 18:00"), at("2025-03-15 18:00"), time.Time{}, false},
+		{"hourly", "@hourly", at("2025-03-10 10:00"), at("2025-03-10 12:30"), at("2025-03-10 12:00"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("parseCron(%q) returned error: %v", tt.spec, err)
+			}
+			due, run := missedRun(schedule, tt.lastRun, tt.now, belgrade)
+			if !due.Equal(tt.wantDue) || run != tt.wantRun {
+				t.Errorf("missedRun() = %v, %v, want %v, %v", due, run, tt.wantDue, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2025, 3, 10, 10, 7, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, 3, 10, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("parseCron returned error: %v", err)
+			}
+			if got := schedule.next(from, time.UTC); !got.Equal(tt.want) {
+				t.Errorf("next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("Expected parseCron(%q) to fail", spec)
+		}
+	}
+}
+
+func TestScheduledJobsDefaults(t *testing.T) {
+	cfg := &config.Config{DailySummaryTime: 3*time.Hour + 30*time.Minute}
+
+	jobs := scheduledJobs(cfg)
+	if len(jobs) != 2 || jobs[0].Name != midnightJob || jobs[0].Topic != midnightTopic || jobs[0].Cron != "30 3 * * *" {
+		t.Errorf("Expected the daily midnight job first, got %+v", jobs)
+	}
+	if len(jobs) == 2 && (jobs[1].Topic != digestTopic || jobs[1].Cron != weeklyDigestCron) {
+		t.Errorf("Expected the weekly digest job, got %+v", jobs[1])
+	}
+
+	// Configured jobs override defaults of the same name; others don't turn the defaults off
+	cfg.App.Scheduler.Jobs = []config.ScheduledJob{
+		{Name: "hourly_digest", Cron: "0 * * * *", Topic: "digest"},
+		{Name: midnightJob, Cron: "0 2 * * *", Topic: midnightTopic},
+	}
+	jobs = scheduledJobs(cfg)
+	if len(jobs) != 3 {
+		t.Fatalf("Expected the defaults and the added job, got %+v", jobs)
+	}
+	if jobs[0].Name != midnightJob || jobs[0].Cron != "0 2 * * *" {
+		t.Errorf("Expected the configured midnight job to override the default, got %+v", jobs[0])
+	}
+	if jobs[1].Name != weeklyDigestJob || jobs[1].Cron != weeklyDigestCron {
+		t.Errorf("Expected the default weekly digest job kept, got %+v", jobs[1])
+	}
+	if jobs[2].Name != "hourly_digest" {
+		t.Errorf("Expected the added job last, got %+v", jobs[2])
+	}
+}
+
+// failingPublisher fails every publish
+type failingPublisher struct {
+	published int
+}
+
+func (p *failingPublisher) Publish(string, ...*message.Message) error {
+	p.published++
+	return errors.New("publish failed")
+}
+
+func (p *failingPublisher) Close() error { return nil }
+
+func TestCheckJobKeepsLastRunWhenPublishFails(t *testing.T) {
+	publisher := &failingPublisher{}
+	// No repository or listener: a failed publish must neither persist the run nor reset counters
+	s := &Scheduler{publisher: publisher, config: &config.Config{Location: time.UTC}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	jobs, err := parseJobs([]config.ScheduledJob{
+		{Name: midnightJob, Cron: "0 0 * * *", Topic: midnightTopic},
+		{Name: weeklyDigestJob, Cron: weeklyDigestCron, Topic: digestTopic},
+	})
+	if err != nil {
+		t.Fatalf("parseJobs failed: %v", err)
+	}
+
+	lastRun := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := lastRun.AddDate(0, 0, 8)
+	for _, j := range jobs {
+		j.lastRun = lastRun
+		s.checkJob(context.Background(), j, now)
+		if !j.lastRun.Equal(lastRun) {
+			t.Errorf("Expected job %s to keep its last run after a failed publish, got %v", j.Name, j.lastRun)
+		}
+	}
+	if publisher.published != len(jobs) {
+		t.Errorf("Expected each due job to be published, got %d publishes", publisher.published)
+	}
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks values encrypted by Cipher
+const encryptedPrefix = "enc:v1:"
+
+var (
+	// ErrMissingKey indicates encrypted data was found but no encryption key is configured
+	ErrMissingKey = errors.New("settings encryption key is not configured")
+	// ErrDecrypt indicates the value could not be decrypted (wrong key or tampered data)
+	ErrDecrypt = errors.New("failed to decrypt value")
+)
+
+// Cipher encrypts sensitive settings with AES-256-GCM.
+// A nil *Cipher is valid and represents a missing key: it refuses to encrypt
+// and fails to decrypt anything that was encrypted.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a secret key. The key is hashed with SHA-256
+// to derive the AES-256 key, so any non-empty string is accepted.
+func NewCipher(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, ErrMissingKey
+	}
+
+	derived := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// IsEncrypted reports whether the value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt encrypts plaintext into a prefixed base64 string
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if c == nil {
+		return "", ErrMissingKey
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values without the encryption
+// prefix are treated as legacy plaintext and returned as-is.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	if c == nil {
+		return "", ErrMissingKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", ErrDecrypt
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	c, err := NewCipher("test-encryption-key")
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+
+	encrypted, err := c.Encrypt("sk-secret")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	if !IsEncrypted(encrypted) {
+		t.Errorf("Expected encrypted value to have prefix, got %s", encrypted)
+	}
+	if strings.Contains(encrypted, "sk-secret") {
+		t.Error("Expected encrypted value not to contain plaintext")
+	}
+
+	decrypted, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if decrypted != "sk-secret" {
+		t.Errorf("Expected 'sk-secret', got %s", decrypted)
+	}
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	c, err := NewCipher("test-encryption-key")
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+
+	encrypted, err := c.Encrypt("sk-secret")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	// Flip a character in the payload
+	payload := []byte(encrypted)
+	i := len(payload) - 5
+	if payload[i] == 'A' {
+		payload[i] = 'B'
+	} else {
+		payload[i] = 'A'
+	}
+
+	if _, err := c.Decrypt(string(payload)); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for tampered value, got %v", err)
+	}
+
+	other, err := NewCipher("another-key")
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	if _, err := other.Decrypt(encrypted); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for wrong key, got %v", err)
+	}
+}
+
+func TestMissingKeyFailsClosed(t *testing.T) {
+	if _, err := NewCipher(""); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey for empty key, got %v", err)
+	}
+
+	var c *Cipher
+	if _, err := c.Encrypt("sk-secret"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey on encrypt without key, got %v", err)
+	}
+	if _, err := c.Decrypt(encryptedPrefix + "AAAA"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey on decrypt without key, got %v", err)
+	}
+
+	plain, err := c.Decrypt("legacy-plaintext")
+	if err != nil || plain != "legacy-plaintext" {
+		t.Errorf("Expected legacy plaintext to pass through, got %q, %v", plain, err)
+	}
+}
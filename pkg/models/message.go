@@ -13,17 +13,21 @@ type UserTrait map[string]interface{}
 
 // Message represents a Telegram message stored in DB
 type Message struct {
-	ID            int64     `json:"id" db:"id"`
-	TelegramMsgID int64     `json:"telegram_msg_id" db:"telegram_msg_id"`
-	ChatID        int64     `json:"chat_id" db:"chat_id"`
-	UserID        int64     `json:"user_id" db:"user_id"`
-	TopicID       *int64    `json:"topic_id" db:"topic_id"`
-	IsBot         bool      `json:"is_bot" db:"is_bot"`
-	UserFirstName string    `json:"user_first_name" db:"user_first_name"`
-	UserLastName  *string   `json:"user_last_name" db:"user_last_name"`
-	Username      *string   `json:"username" db:"username"`
-	Text          *string   `json:"text" db:"text"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	ID            int64      `json:"id" db:"id"`
+	TelegramMsgID int64      `json:"telegram_msg_id" db:"telegram_msg_id"`
+	ChatID        int64      `json:"chat_id" db:"chat_id"`
+	UserID        int64      `json:"user_id" db:"user_id"`
+	TopicID       *int64     `json:"topic_id" db:"topic_id"`
+	IsBot         bool       `json:"is_bot" db:"is_bot"`
+	UserFirstName string     `json:"user_first_name" db:"user_first_name"`
+	UserLastName  *string    `json:"user_last_name" db:"user_last_name"`
+	Username      *string    `json:"username" db:"username"`
+	Text          *string    `json:"text" db:"text"`
+	ForwardOrigin *string    `json:"forward_origin" db:"forward_origin"`   // Original sender/channel of forwarded messages
+	ReplyToMsgID  *int64     `json:"reply_to_msg_id" db:"reply_to_msg_id"` // Telegram ID of the message replied to
+	ReplyToBot    bool       `json:"reply_to_bot" db:"reply_to_bot"`       // Whether the replied message is from this bot
+	EditedAt      *time.Time `json:"edited_at" db:"edited_at"`             // Last edit time, nil if never edited
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 }
 
 // ChatSummary represents aggregated chat information
@@ -35,6 +39,7 @@ type ChatSummary struct {
 	TopicsJSON     map[string]interface{} `json:"topics_json" db:"topics_json"`
 	NextEvents     *string                `json:"next_events" db:"next_events"`           // Legacy field for backward compatibility
 	NextEventsJSON []Event                `json:"next_events_json" db:"next_events_json"` // New JSON field
+	LastMessageID  int64                  `json:"last_message_id" db:"last_message_id"`   // Newest message covered by the summary
 	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at" db:"updated_at"`
 }
@@ -69,10 +74,11 @@ type UserRole struct {
 
 // AllowedChat represents a chat that is allowed to use the bot
 type AllowedChat struct {
-	ID        int64     `json:"id" db:"id"`
-	ChatID    int64     `json:"chat_id" db:"chat_id"`
-	Name      *string   `json:"name" db:"name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID           int64     `json:"id" db:"id"`
+	ChatID       int64     `json:"chat_id" db:"chat_id"`
+	Name         *string   `json:"name" db:"name"`
+	WeeklyDigest bool      `json:"weekly_digest" db:"weekly_digest"` // Opted in to the weekly digest post
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
 // WelcomeMessage represents a welcome message for new chat members
@@ -85,3 +91,81 @@ type WelcomeMessage struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// RawCompletion represents a raw GPT summarization completion kept for debugging
+type RawCompletion struct {
+	ID         int64     `json:"id" db:"id"`
+	ChatID     int64     `json:"chat_id" db:"chat_id"`
+	TopicID    *int64    `json:"topic_id" db:"topic_id"`
+	Completion string    `json:"completion" db:"completion"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// Summarization run statuses
+const (
+	SummarizationRunSuccess   = "success"
+	SummarizationRunFailure   = "failure"
+	SummarizationRunTruncated = "truncated"
+)
+
+// SummarizationRun represents the metadata of a chat topic summarization run
+type SummarizationRun struct {
+	ID               int64     `json:"id" db:"id"`
+	ChatID           int64     `json:"chat_id" db:"chat_id"`
+	TopicID          *int64    `json:"topic_id" db:"topic_id"`
+	MessageCount     int       `json:"message_count" db:"message_count"`
+	Model            *string   `json:"model" db:"model"`
+	PromptTokens     int64     `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens" db:"completion_tokens"`
+	DurationMs       int64     `json:"duration_ms" db:"duration_ms"`
+	Status           string    `json:"status" db:"status"`
+	Error            *string   `json:"error" db:"error"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// FailedEvent represents an event whose handler kept failing, kept for inspection and replay
+type FailedEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	Topic     string    `json:"topic" db:"topic"`
+	Handler   string    `json:"handler" db:"handler"`
+	Payload   string    `json:"payload" db:"payload"`
+	Error     string    `json:"error" db:"error"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ChatEvent represents an upcoming event extracted from a chat topic by summarization
+type ChatEvent struct {
+	ID      int64  `json:"id" db:"id"`
+	ChatID  int64  `json:"chat_id" db:"chat_id"`
+	TopicID *int64 `json:"topic_id" db:"topic_id"`
+	Title   string `json:"title" db:"title"`
+	Date    string `json:"date" db:"date"` // ISO 8601 as extracted, empty when unknown
+	// EndsAt is when the event is over: its start, or the end of the day for date-only events
+	// (nil when the date is unknown)
+	EndsAt    *time.Time `json:"ends_at" db:"ends_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// UserIdentity represents the current display identity of a user in a chat
+type UserIdentity struct {
+	ChatID    int64     `json:"chat_id" db:"chat_id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Username  *string   `json:"username" db:"username"`
+	FirstName string    `json:"first_name" db:"first_name"`
+	LastName  *string   `json:"last_name" db:"last_name"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ChatSettings represents per-chat overrides of global configuration
+type ChatSettings struct {
+	ChatID                 int64     `json:"chat_id" db:"chat_id"`
+	DisabledCommands       []string  `json:"disabled_commands" db:"disabled_commands"`
+	PinnedSummaryMessageID *int64    `json:"pinned_summary_message_id" db:"pinned_summary_message_id"`
+	PinnedSummaryTopicID   *int64    `json:"pinned_summary_topic_id" db:"pinned_summary_topic_id"`
+	UILanguage             string    `json:"ui_language" db:"ui_language"`
+	SummarizePrompt        *string   `json:"summarize_prompt" db:"summarize_prompt"`
+	ReactionsEnabled       bool      `json:"reactions_enabled" db:"reactions_enabled"`
+	CreatedAt              time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at" db:"updated_at"`
+}